@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/lobsterdore/release-dash/cache"
+	"github.com/lobsterdore/release-dash/config"
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+// runBackfill handles release-dash backfill, which rebuilds the history of an
+// environment from its past tags. The history file can only be opened by one
+// process so the dashboard has to be stopped while it runs.
+func runBackfill(cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	environment := flags.String("environment", "", "Environment to backfill, e.g. prod")
+	tagPattern := flags.String("tags", "", "Pattern of the past tags of the environment, defaults to <environment>-*")
+	repoName := flags.String("repo", "", "Only backfill a single owner/repo")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *environment == "" {
+		return fmt.Errorf("-environment must be set")
+	}
+	if *tagPattern == "" {
+		*tagPattern = *environment + "-*"
+	}
+	if cfg.History.Path == "" {
+		return fmt.Errorf("HISTORY_PATH must be set to backfill the history")
+	}
+
+	ctx := context.Background()
+
+	githubAdapter, err := scm.NewGithubAdapter(ctx, cfg.Github.Pat, cfg.Github.UrlDefault, cfg.Github.UrlUpload)
+	if err != nil {
+		return fmt.Errorf("Could not setup Github client: %s", err)
+	}
+
+	historyAdapter, err := history.NewBoltHistoryAdapter(cfg.History.Path)
+	if err != nil {
+		return err
+	}
+	defer historyAdapter.Close()
+
+	localCacheAdapter := cache.NewLocalCacheAdapter(cfg.Cache.DefaultExpirationSeconds, cfg.Cache.CleanupIntervalSeconds)
+	dashboardService := dashboard.NewDashboardService(ctx, cfg, githubAdapter, nil, localCacheAdapter)
+	dashboardRepos, err := dashboardService.GetDashboardRepos(ctx)
+	if err != nil {
+		return fmt.Errorf("Could not get dashboard repos: %s", err)
+	}
+
+	if *repoName != "" {
+		var matchingRepos []dashboard.DashboardRepo
+		for _, dashboardRepo := range dashboardRepos {
+			if strings.EqualFold(dashboardRepo.Repository.OwnerName+"/"+dashboardRepo.Repository.Name, *repoName) {
+				matchingRepos = append(matchingRepos, dashboardRepo)
+			}
+		}
+		if len(matchingRepos) == 0 {
+			return fmt.Errorf("Repo %s is not on the dashboard", *repoName)
+		}
+		dashboardRepos = matchingRepos
+	}
+
+	log.Log().Msgf("Backfilling %s from tags %s", *environment, *tagPattern)
+	added, err := history.NewHistoryService(historyAdapter).Backfill(ctx, dashboardService, dashboardRepos, *environment, *tagPattern)
+	if err != nil {
+		return err
+	}
+	log.Log().Msgf("Backfilled %d movements of %s", added, *environment)
+
+	return nil
+}
@@ -11,24 +11,74 @@ type cache struct {
 
 type Config struct {
 	Cache     cache
+	Dashboard dashboard
+	Digest    digest
+	Dora      dora
 	Github    github
+	History   history
 	Logging   logging
+	Notify    notify
 	Profiling profiling
 	Server    server
+	Smtp      smtp
+}
+
+type dashboard struct {
+	CiStatus              bool     `env:"DASHBOARD_CI_STATUS" envDefault:"false"`
+	ConfigFile            string   `env:"DASHBOARD_CONFIG_FILE" envDefault:""`
+	ConfigRepo            string   `env:"DASHBOARD_CONFIG_REPO" envDefault:""`
+	ConfigRepoBranch      string   `env:"DASHBOARD_CONFIG_REPO_BRANCH" envDefault:""`
+	ConfigRepoFile        string   `env:"DASHBOARD_CONFIG_REPO_FILE" envDefault:"releasedash.yml"`
+	RepoConfigPaths       []string `env:"DASHBOARD_REPO_CONFIG_PATHS" envSeparator:"," envDefault:""`
+	StalenessAmberAge     string   `env:"DASHBOARD_STALENESS_AMBER_AGE" envDefault:""`
+	StalenessAmberCommits int      `env:"DASHBOARD_STALENESS_AMBER_COMMITS" envDefault:"0"`
+	StalenessRedAge       string   `env:"DASHBOARD_STALENESS_RED_AGE" envDefault:""`
+	StalenessRedCommits   int      `env:"DASHBOARD_STALENESS_RED_COMMITS" envDefault:"0"`
+}
+
+type digest struct {
+	Recipients []string `env:"DIGEST_RECIPIENTS" envSeparator:"," envDefault:""`
+	Schedule   string   `env:"DIGEST_SCHEDULE" envDefault:""`
+	Timezone   string   `env:"DIGEST_TIMEZONE" envDefault:"UTC"`
+}
+
+type dora struct {
+	ProductionEnvironments []string `env:"DORA_PRODUCTION_ENVIRONMENTS" envSeparator:"," envDefault:""`
 }
 
 type github struct {
 	ChangelogFetchTimerSeconds int    `env:"GITHUB_CHANGELOG_FETCH_TIMER_SECONDS" envDefault:"180"`
 	Pat                        string `env:"GITHUB_PAT" envDefault:""`
+	ReleasesEnabled            bool   `env:"GITHUB_RELEASES_ENABLED" envDefault:"false"`
+	ReleasesSecret             string `env:"GITHUB_RELEASES_SECRET" envDefault:""`
 	RepoFetchTimerSeconds      int    `env:"GITHUB_REPO_FETCH_TIMER_SECONDS" envDefault:"900"`
 	UrlDefault                 string `env:"GITHUB_URL_DEFAULT" envDefault:""`
 	UrlUpload                  string `env:"GITHUB_URL_UPLOAD" envDefault:""`
+	WritePat                   string `env:"GITHUB_WRITE_PAT" envDefault:""`
+}
+
+type history struct {
+	Path     string `env:"HISTORY_PATH" envDefault:""`
+	Timezone string `env:"HISTORY_TIMEZONE" envDefault:"UTC"`
 }
 
 type logging struct {
 	Level string `env:"LOGGING_LEVEL" envDefault:"error"`
 }
 
+type notify struct {
+	DashboardUrl         string   `env:"NOTIFY_DASHBOARD_URL" envDefault:""`
+	Level                string   `env:"NOTIFY_LEVEL" envDefault:"red"`
+	QuietHours           string   `env:"NOTIFY_QUIET_HOURS" envDefault:""`
+	RepeatHours          int      `env:"NOTIFY_REPEAT_HOURS" envDefault:"24"`
+	SlackTeamWebhookUrls []string `env:"NOTIFY_SLACK_TEAM_WEBHOOK_URLS" envSeparator:"," envDefault:""`
+	SlackWebhookUrl      string   `env:"NOTIFY_SLACK_WEBHOOK_URL" envDefault:""`
+	TeamsTeamWebhookUrls []string `env:"NOTIFY_TEAMS_TEAM_WEBHOOK_URLS" envSeparator:"," envDefault:""`
+	TeamsWebhookUrl      string   `env:"NOTIFY_TEAMS_WEBHOOK_URL" envDefault:""`
+	Timezone             string   `env:"NOTIFY_TIMEZONE" envDefault:"UTC"`
+	WebhookUrl           string   `env:"NOTIFY_WEBHOOK_URL" envDefault:""`
+}
+
 type profiling struct {
 	Enabled bool `env:"PROFILING_ENABLED" envDefault:"false"`
 }
@@ -46,6 +96,14 @@ type serverTimeout struct {
 	Write  int `env:"SERVER_TIMEOUT_READ" envDefault:"10"`
 }
 
+type smtp struct {
+	From     string `env:"SMTP_FROM" envDefault:""`
+	Host     string `env:"SMTP_HOST" envDefault:"localhost"`
+	Password string `env:"SMTP_PASSWORD" envDefault:""`
+	Port     string `env:"SMTP_PORT" envDefault:"25"`
+	Username string `env:"SMTP_USERNAME" envDefault:""`
+}
+
 func NewConfig() (Config, error) {
 
 	cfg := &Config{}
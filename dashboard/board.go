@@ -0,0 +1,197 @@
+package dashboard
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+// DashboardBoardFilter narrows down the repos shown on the board, a repo has
+// to match one of the values given for each field, empty fields match every
+// repo.
+type DashboardBoardFilter struct {
+	Groups []string
+	Labels []string
+	Owners []string
+	Teams  []string
+}
+
+// DashboardBoardGroup holds the repo changelogs that share a group, the name
+// is empty for repos without a group.
+type DashboardBoardGroup struct {
+	Name           string
+	RepoChangelogs []DashboardRepoChangelog
+}
+
+func (g DashboardBoardGroup) HasChangelogCommits() bool {
+	for _, repoChangelog := range g.RepoChangelogs {
+		if repoChangelog.HasChangelogCommits() {
+			return true
+		}
+	}
+	return false
+}
+
+// DashboardBoardFacets holds every value that the board can be filtered by.
+type DashboardBoardFacets struct {
+	Groups []string
+	Labels []string
+	Owners []string
+	Teams  []string
+}
+
+func (f DashboardBoardFilter) IsEmpty() bool {
+	return len(f.Groups) == 0 && len(f.Labels) == 0 && len(f.Owners) == 0 && len(f.Teams) == 0
+}
+
+func (f DashboardBoardFilter) Matches(repoChangelog DashboardRepoChangelog) bool {
+	return matchesAnyValue(f.Groups, repoChangelog.Config.Group) &&
+		matchesAnyValue(f.Labels, repoChangelog.Config.Labels...) &&
+		matchesAnyValue(f.Owners, repoChangelog.Repository.OwnerName) &&
+		matchesAnyValue(f.Teams, repoChangelog.Config.Team)
+}
+
+// Apply returns the repo changelogs that match the filter, in their original
+// order.
+func (f DashboardBoardFilter) Apply(repoChangelogs []DashboardRepoChangelog) []DashboardRepoChangelog {
+	if f.IsEmpty() {
+		return repoChangelogs
+	}
+
+	var matchedChangelogs []DashboardRepoChangelog
+	for _, repoChangelog := range repoChangelogs {
+		if f.Matches(repoChangelog) {
+			matchedChangelogs = append(matchedChangelogs, repoChangelog)
+		}
+	}
+	return matchedChangelogs
+}
+
+// GetDashboardBoardGroups splits the repo changelogs up by group, groups are
+// sorted by name with repos that don't have a group coming last.
+func GetDashboardBoardGroups(repoChangelogs []DashboardRepoChangelog) []DashboardBoardGroup {
+	groupedChangelogs := map[string][]DashboardRepoChangelog{}
+	for _, repoChangelog := range repoChangelogs {
+		group := strings.ToLower(repoChangelog.Config.Group)
+		groupedChangelogs[group] = append(groupedChangelogs[group], repoChangelog)
+	}
+
+	var boardGroups []DashboardBoardGroup
+	for _, group := range GetDashboardBoardFacets(repoChangelogs).Groups {
+		boardGroups = append(boardGroups, DashboardBoardGroup{Name: group, RepoChangelogs: groupedChangelogs[strings.ToLower(group)]})
+	}
+	if ungroupedChangelogs, found := groupedChangelogs[""]; found {
+		boardGroups = append(boardGroups, DashboardBoardGroup{RepoChangelogs: ungroupedChangelogs})
+	}
+	return boardGroups
+}
+
+// OldestCommit returns the pending commit with the earliest author date, nil
+// if there are no commits with a date.
+func (c DashboardChangelogCommits) OldestCommit() *scm.ScmCommit {
+	return oldestCommit(c.Commits)
+}
+
+func (d DashboardRepoChangelog) OldestCommit() *scm.ScmCommit {
+	var commits []scm.ScmCommit
+	for _, changelogCommits := range d.ChangelogCommits {
+		commits = append(commits, changelogCommits.Commits...)
+	}
+	return oldestCommit(commits)
+}
+
+// GetDashboardBoardOldestCommit returns the oldest pending commit across every
+// repo changelog along with the repo changelog it is pending in.
+func GetDashboardBoardOldestCommit(repoChangelogs []DashboardRepoChangelog) (*scm.ScmCommit, *DashboardRepoChangelog) {
+	var oldest *scm.ScmCommit
+	var oldestRepoChangelog *DashboardRepoChangelog
+	for index := range repoChangelogs {
+		commit := repoChangelogs[index].OldestCommit()
+		if commit == nil {
+			continue
+		}
+		if oldest == nil || commit.AuthoredAt.Before(oldest.AuthoredAt) {
+			oldest = commit
+			oldestRepoChangelog = &repoChangelogs[index]
+		}
+	}
+	return oldest, oldestRepoChangelog
+}
+
+func oldestCommit(commits []scm.ScmCommit) *scm.ScmCommit {
+	var oldest *scm.ScmCommit
+	for index := range commits {
+		commit := &commits[index]
+		if commit.AuthoredAt.IsZero() {
+			continue
+		}
+		if oldest == nil || commit.AuthoredAt.Before(oldest.AuthoredAt) {
+			oldest = commit
+		}
+	}
+	return oldest
+}
+
+// GetDashboardBoardFacets collects the groups, labels, owners and teams of the
+// repo changelogs, values are compared without case and the first spelling
+// seen is kept.
+func GetDashboardBoardFacets(repoChangelogs []DashboardRepoChangelog) DashboardBoardFacets {
+	groups := boardFacetValues{}
+	labels := boardFacetValues{}
+	owners := boardFacetValues{}
+	teams := boardFacetValues{}
+	for _, repoChangelog := range repoChangelogs {
+		groups.add(repoChangelog.Config.Group)
+		labels.add(repoChangelog.Config.Labels...)
+		owners.add(repoChangelog.Repository.OwnerName)
+		teams.add(repoChangelog.Config.Team)
+	}
+
+	return DashboardBoardFacets{
+		Groups: groups.sorted(),
+		Labels: labels.sorted(),
+		Owners: owners.sorted(),
+		Teams:  teams.sorted(),
+	}
+}
+
+// boardFacetValues maps lower case values to their first spelling.
+type boardFacetValues map[string]string
+
+func (v boardFacetValues) add(values ...string) {
+	for _, value := range values {
+		key := strings.ToLower(value)
+		if _, found := v[key]; !found && value != "" {
+			v[key] = value
+		}
+	}
+}
+
+func (v boardFacetValues) sorted() []string {
+	var keys []string
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var values []string
+	for _, key := range keys {
+		values = append(values, v[key])
+	}
+	return values
+}
+
+func matchesAnyValue(filterValues []string, values ...string) bool {
+	if len(filterValues) == 0 {
+		return true
+	}
+	for _, filterValue := range filterValues {
+		for _, value := range values {
+			if strings.EqualFold(filterValue, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package dashboard_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	dashboard "github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+var mockBoardChangelogs = []dashboard.DashboardRepoChangelog{
+	{
+		Config:     &dashboard.DashboardRepoConfig{Group: "backend", Labels: []string{"critical", "pci"}, Name: "payments", Team: "payments"},
+		Repository: scm.ScmRepository{Name: "payments", OwnerName: "acme"},
+	},
+	{
+		Config:     &dashboard.DashboardRepoConfig{Group: "frontend", Labels: []string{"critical"}, Name: "web", Team: "web"},
+		Repository: scm.ScmRepository{Name: "web", OwnerName: "acme"},
+	},
+	{
+		Config:     &dashboard.DashboardRepoConfig{Name: "tools"},
+		Repository: scm.ScmRepository{Name: "tools", OwnerName: "other"},
+	},
+	{
+		Config:     &dashboard.DashboardRepoConfig{Group: "backend", Name: "ledger", Team: "Payments"},
+		Repository: scm.ScmRepository{Name: "ledger", OwnerName: "acme"},
+	},
+}
+
+func TestDashboardBoardFilterApplyEmpty(t *testing.T) {
+	filter := dashboard.DashboardBoardFilter{}
+
+	assert.True(t, filter.IsEmpty())
+	assert.Equal(t, mockBoardChangelogs, filter.Apply(mockBoardChangelogs))
+}
+
+func TestDashboardBoardFilterApply(t *testing.T) {
+	filter := dashboard.DashboardBoardFilter{Teams: []string{"payments"}}
+	assert.Equal(t, []dashboard.DashboardRepoChangelog{mockBoardChangelogs[0], mockBoardChangelogs[3]}, filter.Apply(mockBoardChangelogs))
+
+	filter = dashboard.DashboardBoardFilter{Labels: []string{"CRITICAL"}, Owners: []string{"acme"}}
+	assert.Equal(t, []dashboard.DashboardRepoChangelog{mockBoardChangelogs[0], mockBoardChangelogs[1]}, filter.Apply(mockBoardChangelogs))
+
+	filter = dashboard.DashboardBoardFilter{Labels: []string{"critical"}, Teams: []string{"payments", "web"}, Groups: []string{"frontend"}}
+	assert.Equal(t, []dashboard.DashboardRepoChangelog{mockBoardChangelogs[1]}, filter.Apply(mockBoardChangelogs))
+
+	filter = dashboard.DashboardBoardFilter{Owners: []string{"missing"}}
+	assert.Empty(t, filter.Apply(mockBoardChangelogs))
+}
+
+func TestGetDashboardBoardGroups(t *testing.T) {
+	boardGroups := dashboard.GetDashboardBoardGroups(mockBoardChangelogs)
+
+	assert.Equal(t, []dashboard.DashboardBoardGroup{
+		{Name: "backend", RepoChangelogs: []dashboard.DashboardRepoChangelog{mockBoardChangelogs[0], mockBoardChangelogs[3]}},
+		{Name: "frontend", RepoChangelogs: []dashboard.DashboardRepoChangelog{mockBoardChangelogs[1]}},
+		{RepoChangelogs: []dashboard.DashboardRepoChangelog{mockBoardChangelogs[2]}},
+	}, boardGroups)
+}
+
+func TestGetDashboardBoardFacets(t *testing.T) {
+	facets := dashboard.GetDashboardBoardFacets(mockBoardChangelogs)
+
+	assert.Equal(t, dashboard.DashboardBoardFacets{
+		Groups: []string{"backend", "frontend"},
+		Labels: []string{"critical", "pci"},
+		Owners: []string{"acme", "other"},
+		Teams:  []string{"payments", "web"},
+	}, facets)
+}
+
+func TestGetDashboardBoardOldestCommit(t *testing.T) {
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	mockOldCommit := scm.ScmCommit{AuthoredAt: now.Add(-72 * time.Hour), Sha: "old"}
+	mockNewCommit := scm.ScmCommit{AuthoredAt: now.Add(-1 * time.Hour), Sha: "new"}
+	mockUndatedCommit := scm.ScmCommit{Sha: "undated"}
+
+	repoChangelogs := []dashboard.DashboardRepoChangelog{
+		{ChangelogCommits: []dashboard.DashboardChangelogCommits{
+			{Commits: []scm.ScmCommit{mockUndatedCommit, mockNewCommit}},
+		}},
+		{ChangelogCommits: []dashboard.DashboardChangelogCommits{
+			{Commits: []scm.ScmCommit{mockNewCommit}},
+			{Commits: []scm.ScmCommit{mockOldCommit, mockUndatedCommit}},
+		}},
+		{},
+	}
+
+	assert.Equal(t, &mockNewCommit, repoChangelogs[0].OldestCommit())
+	assert.Equal(t, &mockOldCommit, repoChangelogs[1].OldestCommit())
+	assert.Nil(t, repoChangelogs[2].OldestCommit())
+	assert.Equal(t, &mockNewCommit, repoChangelogs[0].ChangelogCommits[0].OldestCommit())
+	oldestCommit, oldestRepoChangelog := dashboard.GetDashboardBoardOldestCommit(repoChangelogs)
+	assert.Equal(t, &mockOldCommit, oldestCommit)
+	assert.Equal(t, &repoChangelogs[1], oldestRepoChangelog)
+	oldestCommit, oldestRepoChangelog = dashboard.GetDashboardBoardOldestCommit(nil)
+	assert.Nil(t, oldestCommit)
+	assert.Nil(t, oldestRepoChangelog)
+}
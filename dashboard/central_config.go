@@ -0,0 +1,207 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+const (
+	CentralConfigModeMerge    = "merge"
+	CentralConfigModeOverride = "override"
+)
+
+type DashboardCentralConfig struct {
+	// Defaults uses the same layout as .releasedash.yml and sits underneath
+	// every repo config
+	Defaults map[string]interface{}       `yaml:"defaults"`
+	Repos    []DashboardCentralRepoConfig `yaml:"repos"`
+
+	defaultsContent []byte
+}
+
+// DashboardCentralRepoConfig holds the central settings for a single repo,
+// any keys other than mode/owner/repo use the same layout as .releasedash.yml.
+type DashboardCentralRepoConfig struct {
+	Mode  string `yaml:"mode"`
+	Owner string `yaml:"owner"`
+	Repo  string `yaml:"repo"`
+
+	content []byte
+}
+
+func NewDashboardCentralConfig(content []byte) (*DashboardCentralConfig, error) {
+	centralConfig := &DashboardCentralConfig{}
+	err := yaml.Unmarshal(content, centralConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Could not unmarshal central config: %s", err)
+	}
+
+	for _, repoConfig := range centralConfig.Repos {
+		if repoConfig.Owner == "" || repoConfig.Repo == "" {
+			return nil, fmt.Errorf("Central config repos must have an owner and repo")
+		}
+	}
+
+	if centralConfig.Defaults != nil {
+		centralConfig.defaultsContent, err = yaml.Marshal(centralConfig.Defaults)
+		if err != nil {
+			return nil, fmt.Errorf("Could not marshal central config defaults: %s", err)
+		}
+		if _, err := centralConfig.DefaultRepoConfig(); err != nil {
+			return nil, fmt.Errorf("Could not parse central config defaults: %s", err)
+		}
+	}
+
+	return centralConfig, nil
+}
+
+// DefaultRepoConfig returns a new repo config with the central defaults set,
+// nil is returned if there aren't any defaults.
+func (c *DashboardCentralConfig) DefaultRepoConfig() (*DashboardRepoConfig, error) {
+	if c == nil || c.defaultsContent == nil {
+		return nil, nil
+	}
+	return newDashboardRepoConfig(nil, c.defaultsContent, yaml.Unmarshal)
+}
+
+func (c *DashboardCentralRepoConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain DashboardCentralRepoConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	switch c.Mode {
+	case "":
+		c.Mode = CentralConfigModeMerge
+	case CentralConfigModeMerge, CentralConfigModeOverride:
+	default:
+		return fmt.Errorf("Unknown central config mode %s for %s/%s", c.Mode, c.Owner, c.Repo)
+	}
+
+	var raw map[string]interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	delete(raw, "mode")
+	delete(raw, "owner")
+	delete(raw, "repo")
+
+	content, err := yaml.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	c.content = content
+
+	return nil
+}
+
+func (c *DashboardCentralConfig) GetRepoConfig(owner string, repo string) *DashboardCentralRepoConfig {
+	if c == nil {
+		return nil
+	}
+	for index, repoConfig := range c.Repos {
+		if strings.EqualFold(repoConfig.Owner, owner) && strings.EqualFold(repoConfig.Repo, repo) {
+			return &c.Repos[index]
+		}
+	}
+	return nil
+}
+
+// warnMissingRepos logs every repo in the central config that the PAT can't
+// see, these would otherwise be left off the dashboard without a trace.
+func (c *DashboardCentralConfig) warnMissingRepos(repos []scm.ScmRepository) {
+	if c == nil {
+		return
+	}
+	for _, repoConfig := range c.Repos {
+		found := false
+		for _, repo := range repos {
+			if strings.EqualFold(repoConfig.Owner, repo.OwnerName) && strings.EqualFold(repoConfig.Repo, repo.Name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			log.Warn().Msgf("Central config repo %s/%s is not accessible via the GH PAT and has been skipped", repoConfig.Owner, repoConfig.Repo)
+		}
+	}
+}
+
+func (c *DashboardCentralRepoConfig) IsOverride() bool {
+	return c.Mode == CentralConfigModeOverride
+}
+
+// Apply layers the central settings over a repo config, defaults are used
+// when there is no repo config to layer over.
+func (c *DashboardCentralRepoConfig) Apply(repoConfig *DashboardRepoConfig) (*DashboardRepoConfig, error) {
+	appliedConfig, err := newDashboardRepoConfig(repoConfig, c.content, yaml.Unmarshal)
+	if err != nil {
+		return nil, fmt.Errorf("Could not apply central config for %s/%s: %s", c.Owner, c.Repo, err)
+	}
+
+	if appliedConfig.Name == "" {
+		appliedConfig.Name = c.Repo
+	}
+
+	return appliedConfig, nil
+}
+
+func (d *DashboardService) GetDashboardCentralConfig(ctx context.Context) (*DashboardCentralConfig, error) {
+	dashboardConfig := d.Config.Dashboard
+
+	var content []byte
+	var err error
+
+	switch {
+	case dashboardConfig.ConfigFile != "":
+		log.Debug().Msgf("Reading central config file %s", dashboardConfig.ConfigFile)
+		content, err = ioutil.ReadFile(dashboardConfig.ConfigFile)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read central config file: %s", err)
+		}
+	case dashboardConfig.ConfigRepo != "":
+		repoParts := strings.SplitN(dashboardConfig.ConfigRepo, "/", 2)
+		if len(repoParts) != 2 || repoParts[0] == "" || repoParts[1] == "" {
+			return nil, fmt.Errorf("Central config repo %s should be in the form owner/repo", dashboardConfig.ConfigRepo)
+		}
+		owner, repo := repoParts[0], repoParts[1]
+
+		// The default branch of the repo is used unless a branch is set
+		branchName := dashboardConfig.ConfigRepoBranch
+		if branchName == "" {
+			repository, err := d.ScmService.GetRepo(ctx, owner, repo)
+			if err != nil {
+				return nil, err
+			}
+			branchName = repository.DefaultBranch
+		}
+
+		log.Debug().Msgf("Reading central config file %s from repo %s/%s", dashboardConfig.ConfigRepoFile, owner, repo)
+		branch, err := d.ScmService.GetRepoBranch(ctx, owner, repo, branchName)
+		if err != nil {
+			return nil, err
+		}
+		if branch == nil {
+			return nil, fmt.Errorf("Central config repo %s/%s does not have branch %s", owner, repo, branchName)
+		}
+
+		content, err = d.ScmService.GetRepoFile(ctx, owner, repo, branch.CurrentHash, dashboardConfig.ConfigRepoFile)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			return nil, fmt.Errorf("Central config repo %s/%s does not have file %s", owner, repo, dashboardConfig.ConfigRepoFile)
+		}
+	default:
+		return nil, nil
+	}
+
+	return NewDashboardCentralConfig(content)
+}
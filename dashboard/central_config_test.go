@@ -0,0 +1,432 @@
+package dashboard_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/config"
+	dashboard "github.com/lobsterdore/release-dash/dashboard"
+	mock_scm "github.com/lobsterdore/release-dash/mocks/scm"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+func TestNewDashboardCentralConfigBadMode(t *testing.T) {
+	content := []byte("---\nrepos:\n  - owner: o\n    repo: r\n    mode: replace\n")
+
+	centralConfig, err := dashboard.NewDashboardCentralConfig(content)
+
+	assert.Error(t, err)
+	assert.Nil(t, centralConfig)
+}
+
+func TestNewDashboardCentralConfigMissingRepo(t *testing.T) {
+	content := []byte("---\nrepos:\n  - owner: o\n    name: app\n")
+
+	centralConfig, err := dashboard.NewDashboardCentralConfig(content)
+
+	assert.Error(t, err)
+	assert.Nil(t, centralConfig)
+}
+
+func TestDashboardCentralRepoConfigApplyMerge(t *testing.T) {
+	content := []byte("---\nrepos:\n  - owner: O\n    repo: R\n    environment_tags: [dev, prd]\n")
+
+	centralConfig, err := dashboard.NewDashboardCentralConfig(content)
+	assert.NoError(t, err)
+
+	centralRepoConfig := centralConfig.GetRepoConfig("o", "r")
+	assert.NotNil(t, centralRepoConfig)
+	assert.False(t, centralRepoConfig.IsOverride())
+
+	repoConfig := dashboard.DashboardRepoConfig{
+		EnvironmentTags: []string{"dev"},
+		Name:            "app",
+	}
+	appliedConfig, err := centralRepoConfig.Apply(&repoConfig)
+
+	expectedConfig := dashboard.DashboardRepoConfig{
+		EnvironmentTags: []string{"dev", "prd"},
+		Name:            "app",
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, &expectedConfig, appliedConfig)
+	assert.Equal(t, []string{"dev"}, repoConfig.EnvironmentTags)
+}
+
+func TestDashboardCentralRepoConfigApplyOverride(t *testing.T) {
+	content := []byte("---\nrepos:\n  - owner: o\n    repo: r\n    mode: override\n    environment_branches: [main, prod]\n")
+
+	centralConfig, err := dashboard.NewDashboardCentralConfig(content)
+	assert.NoError(t, err)
+
+	centralRepoConfig := centralConfig.GetRepoConfig("o", "r")
+	assert.True(t, centralRepoConfig.IsOverride())
+
+	appliedConfig, err := centralRepoConfig.Apply(nil)
+
+	expectedConfig := dashboard.DashboardRepoConfig{
+		EnvironmentBranches: []string{"main", "prod"},
+		Name:                "r",
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, &expectedConfig, appliedConfig)
+}
+
+func TestGetDashboardCentralConfigNotConfigured(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	centralConfig, err := dashboardService.GetDashboardCentralConfig(context.Background())
+
+	assert.NoError(t, err)
+	assert.Nil(t, centralConfig)
+}
+
+func TestGetDashboardCentralConfigFromRepo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+	dashboardService.Config.Dashboard.ConfigRepo = "o/dash-config"
+	dashboardService.Config.Dashboard.ConfigRepoBranch = "main"
+	dashboardService.Config.Dashboard.ConfigRepoFile = "releasedash.yml"
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetRepoBranch(mockCtx, "o", "dash-config", "main").
+		Times(1).
+		Return(&scm.ScmRef{CurrentHash: "s", Name: "main"}, nil)
+	mockScm.
+		EXPECT().
+		GetRepoFile(mockCtx, "o", "dash-config", "s", "releasedash.yml").
+		Times(1).
+		Return([]byte("---\nrepos:\n  - owner: o\n    repo: r\n"), nil)
+
+	centralConfig, err := dashboardService.GetDashboardCentralConfig(mockCtx)
+
+	assert.NoError(t, err)
+	assert.Len(t, centralConfig.Repos, 1)
+	assert.NotNil(t, centralConfig.GetRepoConfig("o", "r"))
+}
+
+func TestGetDashboardCentralConfigFromRepoDefaultBranch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+	dashboardService.Config.Dashboard.ConfigRepo = "o/dash-config"
+	dashboardService.Config.Dashboard.ConfigRepoFile = "releasedash.yml"
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetRepo(mockCtx, "o", "dash-config").
+		Times(1).
+		Return(&scm.ScmRepository{DefaultBranch: "master", Name: "dash-config", OwnerName: "o"}, nil)
+	mockScm.
+		EXPECT().
+		GetRepoBranch(mockCtx, "o", "dash-config", "master").
+		Times(1).
+		Return(&scm.ScmRef{CurrentHash: "s", Name: "master"}, nil)
+	mockScm.
+		EXPECT().
+		GetRepoFile(mockCtx, "o", "dash-config", "s", "releasedash.yml").
+		Times(1).
+		Return([]byte("---\nrepos:\n  - owner: o\n    repo: r\n"), nil)
+
+	centralConfig, err := dashboardService.GetDashboardCentralConfig(mockCtx)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, centralConfig.GetRepoConfig("o", "r"))
+}
+
+func TestGetDashboardCentralConfigBadRepo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{
+		Config:     config.Config{},
+		ScmService: mockScm,
+	}
+	dashboardService.Config.Dashboard.ConfigRepo = "dash-config"
+
+	centralConfig, err := dashboardService.GetDashboardCentralConfig(context.Background())
+
+	assert.Error(t, err)
+	assert.Nil(t, centralConfig)
+}
+
+func TestGetDashboardReposCentralConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	centralConfigPath := filepath.Join(t.TempDir(), "releasedash.yml")
+	centralConfigContent := "---\nrepos:\n" +
+		"  - owner: o\n    repo: vendor\n    mode: override\n    environment_tags: [dev, prd]\n" +
+		"  - owner: o\n    repo: team\n    name: team-app\n"
+	err := ioutil.WriteFile(centralConfigPath, []byte(centralConfigContent), 0644)
+	assert.NoError(t, err)
+	dashboardService.Config.Dashboard.ConfigFile = centralConfigPath
+
+	mockCtx := context.Background()
+	mockOwner := "o"
+	mockSha := "s"
+
+	mockVendorRepo := scm.ScmRepository{
+		DefaultBranch: "main",
+		Name:          "vendor",
+		OwnerName:     mockOwner,
+	}
+	mockTeamRepo := scm.ScmRepository{
+		DefaultBranch: "main",
+		Name:          "team",
+		OwnerName:     mockOwner,
+	}
+
+	mockScm.
+		EXPECT().
+		GetUserRepos(mockCtx, "").
+		Times(1).
+		Return([]scm.ScmRepository{mockVendorRepo, mockTeamRepo}, nil)
+	mockScm.
+		EXPECT().
+		GetRepoBranch(mockCtx, mockOwner, "team", "main").
+		Times(1).
+		Return(&scm.ScmRef{CurrentHash: mockSha, Name: "main"}, nil)
+	mockScm.
+		EXPECT().
+		FindRepoFile(mockCtx, mockOwner, "team", mockSha, dashboard.DefaultRepoConfigPaths).
+		Times(1).
+		Return([]byte("---\nenvironment_branches: [main, prod]\nname: app\n"), ".releasedash.yml", nil)
+
+	repos, err := dashboardService.GetDashboardRepos(mockCtx)
+
+	expectedRepos := []dashboard.DashboardRepo{
+		{
+			Config: &dashboard.DashboardRepoConfig{
+				EnvironmentBranches: []string{"main", "prod"},
+				Name:                "team-app",
+			},
+			ConfigPath: ".releasedash.yml",
+			Repository: mockTeamRepo,
+		},
+		{
+			Config: &dashboard.DashboardRepoConfig{
+				EnvironmentTags: []string{"dev", "prd"},
+				Name:            "vendor",
+			},
+			Repository: mockVendorRepo,
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRepos, repos)
+}
+
+func TestGetDashboardReposCentralConfigDefaults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	centralConfigPath := filepath.Join(t.TempDir(), "releasedash.yml")
+	centralConfigContent := "---\ndefaults:\n  environment_tags: [dev, prd]\n" +
+		"  tickets:\n    - pattern: '[A-Z]+-[0-9]+'\n      url: 'https://jira/browse/{key}'\n" +
+		"repos:\n  - owner: o\n    repo: vendor\n    mode: override\n"
+	err := ioutil.WriteFile(centralConfigPath, []byte(centralConfigContent), 0644)
+	assert.NoError(t, err)
+	dashboardService.Config.Dashboard.ConfigFile = centralConfigPath
+
+	mockCtx := context.Background()
+	mockOwner := "o"
+	mockSha := "s"
+
+	mockVendorRepo := scm.ScmRepository{
+		DefaultBranch: "main",
+		Name:          "vendor",
+		OwnerName:     mockOwner,
+	}
+	mockTeamRepo := scm.ScmRepository{
+		DefaultBranch: "main",
+		Name:          "team",
+		OwnerName:     mockOwner,
+	}
+	mockOtherRepo := scm.ScmRepository{
+		DefaultBranch: "main",
+		Name:          "other",
+		OwnerName:     mockOwner,
+	}
+
+	mockScm.
+		EXPECT().
+		GetUserRepos(mockCtx, "").
+		Times(1).
+		Return([]scm.ScmRepository{mockVendorRepo, mockTeamRepo, mockOtherRepo}, nil)
+	mockScm.
+		EXPECT().
+		GetRepoBranch(mockCtx, mockOwner, gomock.Any(), "main").
+		Times(2).
+		Return(&scm.ScmRef{CurrentHash: mockSha, Name: "main"}, nil)
+	mockScm.
+		EXPECT().
+		FindRepoFile(mockCtx, mockOwner, "team", mockSha, dashboard.DefaultRepoConfigPaths).
+		Times(1).
+		Return([]byte("---\nenvironment_branches: [main, prod]\nname: team\n"), ".releasedash.yml", nil)
+	mockScm.
+		EXPECT().
+		FindRepoFile(mockCtx, mockOwner, "other", mockSha, dashboard.DefaultRepoConfigPaths).
+		Times(1).
+		Return(nil, "", nil)
+
+	repos, err := dashboardService.GetDashboardRepos(mockCtx)
+
+	mockTickets := []dashboard.DashboardTicketPattern{{Pattern: "[A-Z]+-[0-9]+", Url: "https://jira/browse/{key}"}}
+	// Patterns are compiled when the config is validated
+	assert.NoError(t, mockTickets[0].Validate())
+	expectedRepos := []dashboard.DashboardRepo{
+		{
+			Config: &dashboard.DashboardRepoConfig{
+				EnvironmentBranches: []string{"main", "prod"},
+				EnvironmentTags:     []string{"dev", "prd"},
+				Name:                "team",
+				Tickets:             mockTickets,
+			},
+			ConfigPath: ".releasedash.yml",
+			Repository: mockTeamRepo,
+		},
+		{
+			Config: &dashboard.DashboardRepoConfig{
+				EnvironmentTags: []string{"dev", "prd"},
+				Name:            "vendor",
+				Tickets:         mockTickets,
+			},
+			Repository: mockVendorRepo,
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRepos, repos)
+}
+
+func TestGetDashboardReposCentralConfigMergeInvalidRepoFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	centralConfigPath := filepath.Join(t.TempDir(), "releasedash.yml")
+	err := ioutil.WriteFile(centralConfigPath, []byte("---\nrepos:\n  - owner: o\n    repo: team\n    environment_tags: [dev, prd]\n"), 0644)
+	assert.NoError(t, err)
+	dashboardService.Config.Dashboard.ConfigFile = centralConfigPath
+
+	mockCtx := context.Background()
+	mockOwner := "o"
+	mockSha := "s"
+
+	mockTeamRepo := scm.ScmRepository{
+		DefaultBranch: "main",
+		Name:          "team",
+		OwnerName:     mockOwner,
+	}
+
+	mockScm.
+		EXPECT().
+		GetUserRepos(mockCtx, "").
+		Times(1).
+		Return([]scm.ScmRepository{mockTeamRepo}, nil)
+	mockScm.
+		EXPECT().
+		GetRepoBranch(mockCtx, mockOwner, "team", "main").
+		Times(1).
+		Return(&scm.ScmRef{CurrentHash: mockSha, Name: "main"}, nil)
+	mockScm.
+		EXPECT().
+		FindRepoFile(mockCtx, mockOwner, "team", mockSha, dashboard.DefaultRepoConfigPaths).
+		Times(1).
+		Return([]byte("---\ndisplay_mode: bad\n"), ".releasedash.yml", nil)
+
+	repos, err := dashboardService.GetDashboardRepos(mockCtx)
+
+	// The central settings still apply with the repo file error alongside
+	expectedRepos := []dashboard.DashboardRepo{
+		{
+			Config: &dashboard.DashboardRepoConfig{
+				EnvironmentTags: []string{"dev", "prd"},
+				Name:            "team",
+			},
+			ConfigError: "Unknown repo config display mode bad",
+			ConfigPath:  ".releasedash.yml",
+			Repository:  mockTeamRepo,
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRepos, repos)
+	assert.True(t, repos[0].HasConfig())
+}
+
+func TestGetDashboardReposCentralConfigInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	centralConfigPath := filepath.Join(t.TempDir(), "releasedash.yml")
+	err := ioutil.WriteFile(centralConfigPath, []byte("---\nrepos:\n  - owner: o\n    mode: bad\n"), 0644)
+	assert.NoError(t, err)
+	dashboardService.Config.Dashboard.ConfigFile = centralConfigPath
+
+	mockCtx := context.Background()
+	mockOwner := "o"
+	mockSha := "s"
+
+	mockTeamRepo := scm.ScmRepository{
+		DefaultBranch: "main",
+		Name:          "team",
+		OwnerName:     mockOwner,
+	}
+
+	mockScm.
+		EXPECT().
+		GetUserRepos(mockCtx, "").
+		Times(1).
+		Return([]scm.ScmRepository{mockTeamRepo}, nil)
+	mockScm.
+		EXPECT().
+		GetRepoBranch(mockCtx, mockOwner, "team", "main").
+		Times(1).
+		Return(&scm.ScmRef{CurrentHash: mockSha, Name: "main"}, nil)
+	mockScm.
+		EXPECT().
+		FindRepoFile(mockCtx, mockOwner, "team", mockSha, dashboard.DefaultRepoConfigPaths).
+		Times(1).
+		Return([]byte("---\nenvironment_branches: [main, prod]\nname: app\n"), ".releasedash.yml", nil)
+
+	repos, err := dashboardService.GetDashboardRepos(mockCtx)
+
+	expectedRepos := []dashboard.DashboardRepo{
+		{
+			Config: &dashboard.DashboardRepoConfig{
+				EnvironmentBranches: []string{"main", "prod"},
+				Name:                "app",
+			},
+			ConfigPath: ".releasedash.yml",
+			Repository: mockTeamRepo,
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRepos, repos)
+}
@@ -0,0 +1,101 @@
+package dashboard
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+const (
+	CommitGroupBreaking = "Breaking"
+	CommitGroupFeatures = "Features"
+	CommitGroupFixes    = "Fixes"
+	CommitGroupOther    = "Other"
+)
+
+var commitGroupOrder = []string{
+	CommitGroupBreaking,
+	CommitGroupFeatures,
+	CommitGroupFixes,
+	CommitGroupOther,
+}
+
+var conventionalCommitRegexp = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()]*)\))?(!)?: +(\S.*)$`)
+var breakingChangeFooterRegexp = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+
+type ConventionalCommit struct {
+	Breaking bool
+	Scope    string
+	Subject  string
+	Type     string
+}
+
+type DashboardCommitGroup struct {
+	Commits []scm.ScmCommit
+	Name    string
+}
+
+// ParseConventionalCommit pulls the parts out of a commit message that follows
+// https://www.conventionalcommits.org, false is returned for other messages.
+func ParseConventionalCommit(message string) (ConventionalCommit, bool) {
+	header := strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+	matches := conventionalCommitRegexp.FindStringSubmatch(header)
+	if matches == nil {
+		return ConventionalCommit{}, false
+	}
+
+	conventionalCommit := ConventionalCommit{
+		Breaking: matches[3] == "!" || breakingChangeFooterRegexp.MatchString(message),
+		Scope:    strings.TrimSpace(matches[2]),
+		Subject:  strings.TrimSpace(matches[4]),
+		Type:     strings.ToLower(matches[1]),
+	}
+	return conventionalCommit, true
+}
+
+func commitGroupName(commit scm.ScmCommit) string {
+	conventionalCommit, ok := ParseConventionalCommit(commit.Message)
+	switch {
+	case !ok:
+		return CommitGroupOther
+	case conventionalCommit.Breaking:
+		return CommitGroupBreaking
+	case conventionalCommit.Type == "feat":
+		return CommitGroupFeatures
+	case conventionalCommit.Type == "fix":
+		return CommitGroupFixes
+	default:
+		return CommitGroupOther
+	}
+}
+
+// CommitGroups sorts commits into Breaking, Features, Fixes and Other groups,
+// groups without any commits are left out.
+func (c DashboardChangelogCommits) CommitGroups() []DashboardCommitGroup {
+	groupedCommits := map[string][]scm.ScmCommit{}
+	for _, commit := range c.Commits {
+		groupName := commitGroupName(commit)
+		groupedCommits[groupName] = append(groupedCommits[groupName], commit)
+	}
+
+	var commitGroups []DashboardCommitGroup
+	for _, groupName := range commitGroupOrder {
+		if commits, found := groupedCommits[groupName]; found {
+			commitGroups = append(commitGroups, DashboardCommitGroup{
+				Commits: commits,
+				Name:    groupName,
+			})
+		}
+	}
+	return commitGroups
+}
+
+func (c DashboardChangelogCommits) HasBreakingChanges() bool {
+	for _, commit := range c.Commits {
+		if conventionalCommit, ok := ParseConventionalCommit(commit.Message); ok && conventionalCommit.Breaking {
+			return true
+		}
+	}
+	return false
+}
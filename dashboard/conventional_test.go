@@ -0,0 +1,81 @@
+package dashboard_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dashboard "github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	testCases := []struct {
+		message  string
+		expected dashboard.ConventionalCommit
+		ok       bool
+	}{
+		{
+			message:  "feat(api): add refunds",
+			expected: dashboard.ConventionalCommit{Scope: "api", Subject: "add refunds", Type: "feat"},
+			ok:       true,
+		},
+		{
+			message:  "Fix!: drop v1 endpoints\n\nRemoved",
+			expected: dashboard.ConventionalCommit{Breaking: true, Subject: "drop v1 endpoints", Type: "fix"},
+			ok:       true,
+		},
+		{
+			message:  "refactor: tidy config\n\nBREAKING CHANGE: config keys renamed",
+			expected: dashboard.ConventionalCommit{Breaking: true, Subject: "tidy config", Type: "refactor"},
+			ok:       true,
+		},
+		{
+			message: "Merge pull request #1 from o/branch",
+			ok:      false,
+		},
+		{
+			message: "feat:missing space",
+			ok:      false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		conventionalCommit, ok := dashboard.ParseConventionalCommit(testCase.message)
+		assert.Equal(t, testCase.ok, ok, testCase.message)
+		assert.Equal(t, testCase.expected, conventionalCommit, testCase.message)
+	}
+}
+
+func TestDashboardChangelogCommitsCommitGroups(t *testing.T) {
+	changelogCommits := dashboard.DashboardChangelogCommits{
+		Commits: []scm.ScmCommit{
+			{Message: "fix: handle nil"},
+			{Message: "update readme"},
+			{Message: "feat(ui): dark mode"},
+			{Message: "feat!: remove legacy api"},
+			{Message: "chore: bump deps"},
+		},
+	}
+
+	expectedGroups := []dashboard.DashboardCommitGroup{
+		{Commits: []scm.ScmCommit{{Message: "feat!: remove legacy api"}}, Name: dashboard.CommitGroupBreaking},
+		{Commits: []scm.ScmCommit{{Message: "feat(ui): dark mode"}}, Name: dashboard.CommitGroupFeatures},
+		{Commits: []scm.ScmCommit{{Message: "fix: handle nil"}}, Name: dashboard.CommitGroupFixes},
+		{Commits: []scm.ScmCommit{{Message: "update readme"}, {Message: "chore: bump deps"}}, Name: dashboard.CommitGroupOther},
+	}
+
+	assert.Equal(t, expectedGroups, changelogCommits.CommitGroups())
+	assert.True(t, changelogCommits.HasBreakingChanges())
+}
+
+func TestDashboardChangelogCommitsNoBreakingChanges(t *testing.T) {
+	changelogCommits := dashboard.DashboardChangelogCommits{
+		Commits: []scm.ScmCommit{
+			{Message: "fix: handle nil"},
+			{Message: "update readme"},
+		},
+	}
+
+	assert.False(t, changelogCommits.HasBreakingChanges())
+}
@@ -2,11 +2,15 @@ package dashboard
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"path"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/creasty/defaults"
+	"github.com/lobsterdore/release-dash/cache"
 	"github.com/lobsterdore/release-dash/config"
 	"github.com/lobsterdore/release-dash/scm"
 
@@ -16,30 +20,100 @@ import (
 
 //go:generate go run -mod=mod github.com/golang/mock/mockgen --build_flags=-mod=mod --source=dashboard.go --destination=../mocks/dashboard/dashboard.go
 type DashboardProvider interface {
+	CreateDashboardRelease(ctx context.Context, dashboardRepo DashboardRepo, ref string, tagName string, body string) (*scm.ScmRelease, error)
+	GetDashboardChangelogForRefs(ctx context.Context, dashboardRepo DashboardRepo, fromRef string, toRef string) (*DashboardChangelogCommits, error)
 	GetDashboardChangelogs(ctx context.Context, dashboardRepos []DashboardRepo) []DashboardRepoChangelog
+	GetDashboardCommitLocation(ctx context.Context, dashboardRepo DashboardRepo, ref string) (*DashboardCommitLocation, error)
+	GetDashboardPromotions(ctx context.Context, previous []DashboardRepoChangelog, current []DashboardRepoChangelog, promotedAt time.Time) []DashboardPromotion
 	GetDashboardRepos(ctx context.Context) ([]DashboardRepo, error)
-	GetDashboardRepoConfig(ctx context.Context, owner string, repo string, defaultBranch string) (*DashboardRepoConfig, error)
+	GetDashboardTagPromotions(ctx context.Context, dashboardRepo DashboardRepo, environment string, tagPattern string) ([]DashboardPromotion, error)
+	GetDashboardRepoConfig(ctx context.Context, owner string, repo string, defaultBranch string) (*DashboardRepoConfig, string, error)
 }
 
+var DefaultRepoConfigPaths = []string{
+	".releasedash.yml",
+	".releasedash.yaml",
+	".github/releasedash.yml",
+	".releasedash.json",
+}
+
+// commitCacheSeconds is how long details of a commit are kept for, they never
+// change for a given sha so this only bounds the size of the cache.
+const commitCacheSeconds = "86400"
+
 type DashboardService struct {
-	ScmService scm.ScmAdapter
+	// CacheService holds details of commits by sha, it is optional
+	CacheService cache.CacheAdapter
+	Config       config.Config
+	// ReleaseScmService uses a write scoped credential and is nil unless
+	// releases are enabled
+	ReleaseScmService scm.ScmAdapter
+	ScmService        scm.ScmAdapter
 }
 
+// DashboardRepo is a single service on the dashboard, ConfigError is set when
+// the repo config file is invalid. Central settings in merge mode are still
+// applied without the repo config file, so a repo can have both.
 type DashboardRepo struct {
-	Config     *DashboardRepoConfig
-	Repository scm.ScmRepository
+	Config      *DashboardRepoConfig
+	ConfigError string
+	ConfigPath  string
+	Repository  scm.ScmRepository
+}
+
+// HasConfig is false for repos that are only shown because of a config error.
+func (d DashboardRepo) HasConfig() bool {
+	return d.ConfigError == "" || len(d.Config.EnvironmentRefs()) > 0
+}
+
+func (d DashboardRepo) ConfigHtmlUrl() string {
+	if d.ConfigPath == "" {
+		return ""
+	}
+	return d.Repository.FileHtmlUrl(d.ConfigPath)
 }
 
 type DashboardRepoConfig struct {
-	EnvironmentBranches []string `yaml:"environment_branches"`
-	EnvironmentTags     []string `yaml:"environment_tags"`
-	Name                string   `yaml:"name"`
+	// Channel is where notifications for the repo are sent
+	Channel             string                       `json:"channel" yaml:"channel"`
+	CommitStatuses      bool                         `json:"commit_statuses" yaml:"commit_statuses"`
+	DisplayMode         string                       `json:"display_mode" yaml:"display_mode"`
+	EnvironmentBranches []string                     `json:"environment_branches" yaml:"environment_branches"`
+	EnvironmentTags     []string                     `json:"environment_tags" yaml:"environment_tags"`
+	Filters             DashboardCommitFilters       `json:"filters" yaml:"filters"`
+	Group               string                       `json:"group" yaml:"group"`
+	Labels              []string                     `json:"labels" yaml:"labels"`
+	Name                string                       `json:"name" yaml:"name"`
+	Paths               []string                     `json:"paths" yaml:"paths"`
+	Services            []DashboardRepoServiceConfig `json:"services" yaml:"services"`
+	Staleness           DashboardStalenessConfig     `json:"staleness" yaml:"staleness"`
+	Team                string                       `json:"team" yaml:"team"`
+	Tickets             []DashboardTicketPattern     `json:"tickets" yaml:"tickets"`
+}
+
+type DashboardRepoServiceConfig struct {
+	Name  string   `json:"name" yaml:"name"`
+	Paths []string `json:"paths" yaml:"paths"`
 }
 
 type DashboardRepoChangelog struct {
 	ChangelogCommits []DashboardChangelogCommits
 	Config           *DashboardRepoConfig
-	Repository       scm.ScmRepository
+	ConfigPath       string
+	// EnvironmentRefs is keyed by environment name, environments that could
+	// not be found are left out
+	EnvironmentRefs map[string]scm.ScmRef
+	// Environments are the head commits of the environment refs in pipeline
+	// order
+	Environments []DashboardEnvironment
+	Repository   scm.ScmRepository
+}
+
+func (d DashboardRepoChangelog) ConfigHtmlUrl() string {
+	if d.ConfigPath == "" {
+		return ""
+	}
+	return d.Repository.FileHtmlUrl(d.ConfigPath)
 }
 
 func (d DashboardRepoChangelog) HasChangelogCommits() bool {
@@ -52,32 +126,89 @@ func (d DashboardRepoChangelog) HasChangelogCommits() bool {
 }
 
 type DashboardChangelogCommits struct {
-	Commits []scm.ScmCommit
-	FromRef string
+	// CommitStatuses is keyed by commit sha and only set if enabled in the repo config
+	CommitStatuses map[string]scm.ScmCommitStatus
+	Commits        []scm.ScmCommit
+	FromRef        string
+	HiddenCommits  int
+	PullRequests   []DashboardPullRequest
+	// Staleness is how overdue the pending commits are for release, one of
+	// the Staleness constants
+	Staleness string
+	// Status is the CI status of the head of ToRef
+	Status  *scm.ScmCommitStatus
+	Tickets []DashboardTicket
 	ToRef   string
 }
 
-func NewDashboardService(ctx context.Context, config config.Config, scmService scm.ScmAdapter) *DashboardService {
+func NewDashboardService(ctx context.Context, config config.Config, scmService scm.ScmAdapter, releaseScmService scm.ScmAdapter, cacheService cache.CacheAdapter) *DashboardService {
 	service := DashboardService{
-		ScmService: scmService,
+		CacheService:      cacheService,
+		Config:            config,
+		ReleaseScmService: releaseScmService,
+		ScmService:        scmService,
 	}
 	return &service
 }
 
 func NewDashboardRepoConfig(content []byte) (*DashboardRepoConfig, error) {
+	return newDashboardRepoConfig(nil, content, yaml.Unmarshal)
+}
+
+func NewDashboardRepoConfigJson(content []byte) (*DashboardRepoConfig, error) {
+	return newDashboardRepoConfig(nil, content, json.Unmarshal)
+}
+
+// newDashboardRepoConfig unmarshals content over a copy of the base config,
+// defaults are set instead if there is no base config.
+func newDashboardRepoConfig(base *DashboardRepoConfig, content []byte, unmarshal func([]byte, interface{}) error) (*DashboardRepoConfig, error) {
 	repoConfig := &DashboardRepoConfig{}
-	if err := defaults.Set(repoConfig); err != nil {
+	if base != nil {
+		*repoConfig = *base
+	} else if err := defaults.Set(repoConfig); err != nil {
 		return nil, fmt.Errorf("Could not set repo config defaults: %s", err)
 	}
 
-	err := yaml.Unmarshal([]byte(string(content)), repoConfig)
+	err := unmarshal(content, repoConfig)
 	if err != nil {
-		return nil, fmt.Errorf("Could not set unmarshal repo config: %s", err)
+		return nil, fmt.Errorf("Could not unmarshal repo config: %s", err)
+	}
+
+	if err := repoConfig.Validate(); err != nil {
+		return nil, err
 	}
 
 	return repoConfig, nil
 }
 
+func (c *DashboardRepoConfig) Validate() error {
+	switch c.DisplayMode {
+	case "", DisplayModeCommits, DisplayModePullRequests:
+	default:
+		return fmt.Errorf("Unknown repo config display mode %s", c.DisplayMode)
+	}
+	if err := c.Filters.Validate(); err != nil {
+		return err
+	}
+	if err := c.Staleness.Validate(); err != nil {
+		return err
+	}
+	for index := range c.Tickets {
+		if err := c.Tickets[index].Validate(); err != nil {
+			return err
+		}
+	}
+	for _, service := range c.Services {
+		if service.Name == "" {
+			return fmt.Errorf("Repo config services must have a name")
+		}
+		if len(service.Paths) == 0 {
+			return fmt.Errorf("Repo config service %s must have at least one path", service.Name)
+		}
+	}
+	return nil
+}
+
 func (c *DashboardRepoConfig) HasEnvironmentBranches() bool {
 	if c.EnvironmentBranches == nil || len(c.EnvironmentBranches) == 0 {
 		return false
@@ -92,33 +223,118 @@ func (c *DashboardRepoConfig) HasEnvironmentTags() bool {
 	return true
 }
 
+func (c *DashboardRepoConfig) HasPaths() bool {
+	return len(c.Paths) > 0
+}
+
+// MatchesPaths checks if any of the files sit under one of the configured
+// path prefixes, a prefix matches either a file or a whole directory.
+func (c *DashboardRepoConfig) MatchesPaths(files []string) bool {
+	for _, file := range files {
+		for _, pathPrefix := range c.Paths {
+			pathPrefix = strings.Trim(pathPrefix, "/")
+			if pathPrefix == "" || file == pathPrefix || strings.HasPrefix(file, pathPrefix+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ServiceConfigs expands a monorepo config into one config per service, each
+// service inherits everything apart from the name and paths from the repo.
+func (c *DashboardRepoConfig) ServiceConfigs() []*DashboardRepoConfig {
+	if len(c.Services) == 0 {
+		return []*DashboardRepoConfig{c}
+	}
+
+	var serviceConfigs []*DashboardRepoConfig
+	for _, service := range c.Services {
+		serviceConfig := *c
+		serviceConfig.Name = service.Name
+		serviceConfig.Paths = service.Paths
+		serviceConfig.Services = nil
+		serviceConfigs = append(serviceConfigs, &serviceConfig)
+	}
+	return serviceConfigs
+}
+
 func (d *DashboardService) GetDashboardRepos(ctx context.Context) ([]DashboardRepo, error) {
 	allRepos, err := d.ScmService.GetUserRepos(ctx, "")
 	if err != nil {
 		return nil, err
 	}
 
+	// A broken central config shouldn't blank the dashboard, repos fall back
+	// to their own config files until it is fixed
+	centralConfig, err := d.GetDashboardCentralConfig(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get central config, using repo config files only")
+		centralConfig = nil
+	}
+	baseConfig, err := centralConfig.DefaultRepoConfig()
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get central config defaults, using repo config files only")
+		centralConfig = nil
+		baseConfig = nil
+	}
+	centralConfig.warnMissingRepos(allRepos)
+
 	var dashboardRepos []DashboardRepo
 
 	for _, repo := range allRepos {
-		log.Debug().Msgf("Checking repo %s/%s for config file", repo.OwnerName, repo.Name)
-		repoConfig, err := d.GetDashboardRepoConfig(ctx, repo.OwnerName, repo.Name, repo.DefaultBranch)
-		if err != nil {
-			log.Error().Err(err).Msgf("Could not get repo config file %s/%s", repo.OwnerName, repo.Name)
-			continue
+		var repoConfig *DashboardRepoConfig
+		var repoConfigPath string
+		var repoConfigError string
+		centralRepoConfig := centralConfig.GetRepoConfig(repo.OwnerName, repo.Name)
+
+		if centralRepoConfig == nil || !centralRepoConfig.IsOverride() {
+			log.Debug().Msgf("Checking repo %s/%s for config file", repo.OwnerName, repo.Name)
+			repoConfig, repoConfigPath, err = d.getDashboardRepoConfig(ctx, repo.OwnerName, repo.Name, repo.DefaultBranch, baseConfig)
+			if err != nil {
+				log.Error().Err(err).Msgf("Could not get repo config file %s/%s", repo.OwnerName, repo.Name)
+				// A path is only returned with an error if the file was found
+				// but is invalid, the repo is kept so that the error can be shown
+				if repoConfigPath == "" {
+					continue
+				}
+				// Central settings are merged over the defaults instead
+				if centralRepoConfig == nil {
+					dashboardRepos = append(dashboardRepos, newDashboardRepoWithConfigError(repo, repoConfigPath, err))
+					continue
+				}
+				repoConfigError = err.Error()
+			}
+		}
+
+		if centralRepoConfig != nil {
+			if repoConfig == nil {
+				repoConfig = baseConfig
+			}
+			log.Debug().Msgf("Applying central config to repo %s/%s in %s mode", repo.OwnerName, repo.Name, centralRepoConfig.Mode)
+			repoConfig, err = centralRepoConfig.Apply(repoConfig)
+			if err != nil {
+				log.Error().Err(err).Msgf("Could not apply central config to repo %s/%s", repo.OwnerName, repo.Name)
+				dashboardRepos = append(dashboardRepos, newDashboardRepoWithConfigError(repo, repoConfigPath, err))
+				continue
+			}
 		}
 		if repoConfig == nil {
 			log.Debug().Msgf("No config file for repo %s/%s", repo.OwnerName, repo.Name)
 			continue
 		}
 
-		dashboardRepo := DashboardRepo{
-			Config:     repoConfig,
-			Repository: repo,
-		}
+		for _, serviceConfig := range repoConfig.ServiceConfigs() {
+			dashboardRepo := DashboardRepo{
+				Config:      serviceConfig,
+				ConfigError: repoConfigError,
+				ConfigPath:  repoConfigPath,
+				Repository:  repo,
+			}
 
-		dashboardRepos = append(dashboardRepos, dashboardRepo)
-		log.Debug().Msgf("Repo %s/%s added to dashboard", repo.OwnerName, repo.Name)
+			dashboardRepos = append(dashboardRepos, dashboardRepo)
+			log.Debug().Msgf("Repo %s/%s added to dashboard as %s", repo.OwnerName, repo.Name, serviceConfig.Name)
+		}
 	}
 
 	sort.Slice(dashboardRepos, func(i, j int) bool {
@@ -129,36 +345,67 @@ func (d *DashboardService) GetDashboardRepos(ctx context.Context) ([]DashboardRe
 	return dashboardRepos, nil
 }
 
-func (d *DashboardService) GetDashboardRepoConfig(ctx context.Context, owner string, repo string, defaultBranch string) (*DashboardRepoConfig, error) {
-	configFilePath := ".releasedash.yml"
+// newDashboardRepoWithConfigError creates a dashboard repo without any
+// environments for a repo with an invalid config.
+func newDashboardRepoWithConfigError(repo scm.ScmRepository, configPath string, err error) DashboardRepo {
+	return DashboardRepo{
+		Config:      &DashboardRepoConfig{Name: repo.Name},
+		ConfigError: err.Error(),
+		ConfigPath:  configPath,
+		Repository:  repo,
+	}
+}
+
+func (d *DashboardService) GetDashboardRepoConfig(ctx context.Context, owner string, repo string, defaultBranch string) (*DashboardRepoConfig, string, error) {
+	return d.getDashboardRepoConfig(ctx, owner, repo, defaultBranch, nil)
+}
+
+func (d *DashboardService) getDashboardRepoConfig(ctx context.Context, owner string, repo string, defaultBranch string, baseConfig *DashboardRepoConfig) (*DashboardRepoConfig, string, error) {
 	branch, err := d.ScmService.GetRepoBranch(ctx, owner, repo, defaultBranch)
 	if err != nil {
 		log.Error().Err(err).Msgf("Could not get repo %s/%s branch %s", owner, repo, defaultBranch)
-		return nil, nil
+		return nil, "", nil
 	}
 	if branch == nil {
 		log.Debug().Msgf("Repo %s/%s does not have branch %s", owner, repo, defaultBranch)
-		return nil, nil
+		return nil, "", nil
 	}
 
-	repoConfigContent, err := d.ScmService.GetRepoFile(ctx, owner, repo, branch.CurrentHash, configFilePath)
+	repoConfigContent, configFilePath, err := d.ScmService.FindRepoFile(ctx, owner, repo, branch.CurrentHash, d.repoConfigPaths())
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	if repoConfigContent == nil {
-		log.Debug().Msgf("Repo %s/%s does not have file %s", owner, repo, configFilePath)
-		return nil, nil
+		log.Debug().Msgf("Repo %s/%s does not have any of the files %s", owner, repo, strings.Join(d.repoConfigPaths(), ", "))
+		return nil, "", nil
 	}
 
-	repoConfig, err := NewDashboardRepoConfig(repoConfigContent)
+	unmarshal := yaml.Unmarshal
+	if path.Ext(configFilePath) == ".json" {
+		unmarshal = json.Unmarshal
+	}
+	repoConfig, err := newDashboardRepoConfig(baseConfig, repoConfigContent, unmarshal)
 	if err != nil {
-		return nil, err
+		return nil, configFilePath, err
 	}
-	return repoConfig, nil
+	return repoConfig, configFilePath, nil
+}
+
+func (d *DashboardService) repoConfigPaths() []string {
+	if len(d.Config.Dashboard.RepoConfigPaths) == 0 {
+		return DefaultRepoConfigPaths
+	}
+	return d.Config.Dashboard.RepoConfigPaths
 }
 
 func (d *DashboardService) GetDashboardChangelogs(ctx context.Context, dashboardRepos []DashboardRepo) []DashboardRepoChangelog {
 
+	// Services of a monorepo share the same environments, the environment refs
+	// are looked up and each pair is compared once per refresh and the commits
+	// are filtered per service
+	repoEnvironments := map[string]dashboardRepoEnvironments{}
+	changelogs := map[string]*[]scm.ScmCommit{}
+
 	var repoChangelogs []DashboardRepoChangelog
 	for _, dashboardRepo := range dashboardRepos {
 		org := dashboardRepo.Repository.OwnerName
@@ -166,6 +413,7 @@ func (d *DashboardService) GetDashboardChangelogs(ctx context.Context, dashboard
 		repoChangelog := DashboardRepoChangelog{
 			ChangelogCommits: []DashboardChangelogCommits{},
 			Config:           dashboardRepo.Config,
+			ConfigPath:       dashboardRepo.ConfigPath,
 			Repository:       dashboardRepo.Repository,
 		}
 
@@ -180,6 +428,16 @@ func (d *DashboardService) GetDashboardChangelogs(ctx context.Context, dashboard
 		} else {
 			continue
 		}
+		environmentsKey := fmt.Sprintf("%s/%s|%t|%s", org, repo, repoConfig.HasEnvironmentBranches(), strings.Join(environmentRefs, ","))
+		environments, found := repoEnvironments[environmentsKey]
+		if !found {
+			environments.environmentRefs, environments.failedRefs = d.getEnvironmentRefs(ctx, dashboardRepo)
+			environments.environments = d.getDashboardEnvironments(ctx, dashboardRepo, environments.environmentRefs)
+			repoEnvironments[environmentsKey] = environments
+		}
+		repoChangelog.EnvironmentRefs = environments.environmentRefs
+		repoChangelog.Environments = environments.environments
+		failedRefs := environments.failedRefs
 
 		for index, toRef := range environmentRefs {
 			nextIndex := index + 1
@@ -187,23 +445,19 @@ func (d *DashboardService) GetDashboardChangelogs(ctx context.Context, dashboard
 				fromRef := environmentRefs[nextIndex]
 				log.Debug().Msgf("Getting changelog for tags %s - %s", fromRef, toRef)
 
-				var changelog *[]scm.ScmCommit
+				changelogKey := fmt.Sprintf("%s/%s|%t|%s|%s", org, repo, repoConfig.HasEnvironmentBranches(), fromRef, toRef)
+				changelog, found := changelogs[changelogKey]
 				var err error
-
-				if repoConfig.HasEnvironmentBranches() {
-					changelog, err = d.ScmService.GetChangelogForBranches(ctx, org, repo, fromRef, toRef)
-				} else {
-					changelog, err = d.ScmService.GetChangelogForTags(ctx, org, repo, fromRef, toRef)
+				if !found {
+					changelog, err = d.getChangelogForEnvironmentRefs(ctx, dashboardRepo, repoChangelog.EnvironmentRefs, failedRefs, fromRef, toRef)
+					if err == nil {
+						changelogs[changelogKey] = changelog
+					}
 				}
 
 				if err == nil {
-					changelogCommits := DashboardChangelogCommits{
-						FromRef: fromRef,
-						ToRef:   toRef,
-					}
-					if changelog != nil {
-						changelogCommits.Commits = *changelog
-					}
+					toSha := repoChangelog.EnvironmentRefs[toRef].CurrentHash
+					changelogCommits := d.newChangelogCommits(ctx, dashboardRepo, fromRef, toRef, toSha, changelog)
 					repoChangelog.ChangelogCommits = append(repoChangelog.ChangelogCommits, changelogCommits)
 				} else {
 					log.Error().Err(err).Msg("Could not get changelog")
@@ -215,3 +469,118 @@ func (d *DashboardService) GetDashboardChangelogs(ctx context.Context, dashboard
 
 	return repoChangelogs
 }
+
+// getChangelogForEnvironmentRefs compares two environment refs that have already
+// been looked up, nothing is pending if the to ref doesn't exist and every
+// commit up to the to ref is pending if the from ref doesn't exist.
+func (d *DashboardService) getChangelogForEnvironmentRefs(ctx context.Context, dashboardRepo DashboardRepo, environmentRefs map[string]scm.ScmRef, failedRefs map[string]bool, fromRef string, toRef string) (*[]scm.ScmCommit, error) {
+	if failedRefs[fromRef] || failedRefs[toRef] {
+		return nil, fmt.Errorf("Could not get refs %s - %s", fromRef, toRef)
+	}
+
+	refTo, found := environmentRefs[toRef]
+	if !found {
+		return nil, nil
+	}
+	var refFrom *scm.ScmRef
+	if ref, found := environmentRefs[fromRef]; found {
+		refFrom = &ref
+	}
+
+	return d.ScmService.GetChangelogForRefs(ctx, dashboardRepo.Repository.OwnerName, dashboardRepo.Repository.Name, refFrom, &refTo)
+}
+
+// GetDashboardChangelogForRefs builds a changelog on demand between any two
+// branches, tags or commit shas of a dashboard repo, fromRef is the base.
+func (d *DashboardService) GetDashboardChangelogForRefs(ctx context.Context, dashboardRepo DashboardRepo, fromRef string, toRef string) (*DashboardChangelogCommits, error) {
+	org := dashboardRepo.Repository.OwnerName
+	repo := dashboardRepo.Repository.Name
+
+	changelog, err := d.ScmService.GetChangelogForRefNames(ctx, org, repo, fromRef, toRef)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get changelog for refs %s - %s: %s", fromRef, toRef, err)
+	}
+
+	changelogCommits := d.newChangelogCommits(ctx, dashboardRepo, fromRef, toRef, "", changelog)
+	if d.Config.Dashboard.CiStatus {
+		// The to ref may be a branch or tag so its status isn't cached
+		changelogCommits.Status = d.getCommitStatus(ctx, org, repo, toRef)
+	}
+	return &changelogCommits, nil
+}
+
+// newChangelogCommits applies the repo config to the commits between two refs,
+// toSha is the commit the changelog was compared up to and is where the CI
+// status comes from, it is empty if the to ref doesn't exist.
+func (d *DashboardService) newChangelogCommits(ctx context.Context, dashboardRepo DashboardRepo, fromRef string, toRef string, toSha string, changelog *[]scm.ScmCommit) DashboardChangelogCommits {
+	org := dashboardRepo.Repository.OwnerName
+	repo := dashboardRepo.Repository.Name
+	repoConfig := dashboardRepo.Config
+
+	changelogCommits := DashboardChangelogCommits{
+		FromRef: fromRef,
+		ToRef:   toRef,
+	}
+	if changelog != nil {
+		changelogCommits.Commits = *changelog
+	}
+	if repoConfig.HasPaths() {
+		changelogCommits.Commits = d.filterCommitsByPaths(ctx, org, repo, repoConfig, changelogCommits.Commits)
+	}
+	changelogCommits.Commits, changelogCommits.HiddenCommits = repoConfig.Filters.Apply(changelogCommits.Commits)
+	changelogCommits.Tickets = repoConfig.ExtractTickets(dashboardRepo.Repository, changelogCommits.Commits)
+	changelogCommits.Staleness = repoConfig.Staleness.WithDefaults(NewDashboardStalenessConfig(d.Config)).Level(changelogCommits.Commits, time.Now())
+	if d.Config.Dashboard.CiStatus {
+		if toSha != "" {
+			changelogCommits.Status = d.getCommitStatusForSha(ctx, org, repo, toSha)
+		}
+		if repoConfig.CommitStatuses {
+			changelogCommits.CommitStatuses = d.getCommitStatuses(ctx, org, repo, changelogCommits.Commits)
+		}
+	}
+	if repoConfig.ShowPullRequests() {
+		changelogCommits.PullRequests = d.getPullRequests(ctx, org, repo, changelogCommits.Commits)
+	}
+	return changelogCommits
+}
+
+func (d *DashboardService) filterCommitsByPaths(ctx context.Context, owner string, repo string, repoConfig *DashboardRepoConfig, commits []scm.ScmCommit) []scm.ScmCommit {
+	var pathCommits []scm.ScmCommit
+	for _, commit := range commits {
+		files := commit.Files
+		if files == nil {
+			fullCommit, err := d.getCommit(ctx, owner, repo, commit.Sha)
+			if err != nil {
+				log.Error().Err(err).Msgf("Could not get files for commit %s in repo %s/%s", commit.Sha, owner, repo)
+				pathCommits = append(pathCommits, commit)
+				continue
+			}
+			files = fullCommit.Files
+		}
+		if repoConfig.MatchesPaths(files) {
+			pathCommits = append(pathCommits, commit)
+		}
+	}
+	return pathCommits
+}
+
+// getCommit fetches a commit with its files, commits are cached by sha as
+// every refresh would otherwise fetch each pending commit again.
+func (d *DashboardService) getCommit(ctx context.Context, owner string, repo string, sha string) (*scm.ScmCommit, error) {
+	cacheKey := fmt.Sprintf("commit_%s/%s/%s", owner, repo, sha)
+	if d.CacheService != nil {
+		if cachedCommit, found := d.CacheService.Get(cacheKey); found {
+			return cachedCommit.(*scm.ScmCommit), nil
+		}
+	}
+
+	commit, err := d.ScmService.GetCommit(ctx, owner, repo, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.CacheService != nil {
+		d.CacheService.Set(cacheKey, commit, commitCacheSeconds)
+	}
+	return commit, nil
+}
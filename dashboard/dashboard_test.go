@@ -9,6 +9,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lobsterdore/release-dash/cache"
 	dashboard "github.com/lobsterdore/release-dash/dashboard"
 	mock_scm "github.com/lobsterdore/release-dash/mocks/scm"
 	"github.com/lobsterdore/release-dash/scm"
@@ -91,9 +92,9 @@ func TestGetDashboardReposNoConfigFiles(t *testing.T) {
 
 	mockScm.
 		EXPECT().
-		GetRepoFile(mockCtx, mockOwner, mockRepoName, mockSha, ".releasedash.yml").
+		FindRepoFile(mockCtx, mockOwner, mockRepoName, mockSha, dashboard.DefaultRepoConfigPaths).
 		Times(1).
-		Return(nil, nil)
+		Return(nil, "", nil)
 
 	repos, err := dashboardService.GetDashboardRepos(mockCtx)
 
@@ -151,14 +152,14 @@ func TestGetDashboardReposHasRepos(t *testing.T) {
 	mockRepoContent, _ := base64.StdEncoding.DecodeString(mockConfigB64)
 	mockScm.
 		EXPECT().
-		GetRepoFile(mockCtx, mockOwner, mockRepoName+"a", mockSha, ".releasedash.yml").
+		FindRepoFile(mockCtx, mockOwner, mockRepoName+"a", mockSha, dashboard.DefaultRepoConfigPaths).
 		Times(1).
-		Return(mockRepoContent, nil)
+		Return(mockRepoContent, ".releasedash.yml", nil)
 	mockScm.
 		EXPECT().
-		GetRepoFile(mockCtx, mockOwner, mockRepoName+"b", mockSha, ".releasedash.yml").
+		FindRepoFile(mockCtx, mockOwner, mockRepoName+"b", mockSha, dashboard.DefaultRepoConfigPaths).
 		Times(1).
-		Return(mockRepoContent, nil)
+		Return(mockRepoContent, ".releasedash.yml", nil)
 
 	repos, err := dashboardService.GetDashboardRepos(mockCtx)
 
@@ -170,10 +171,12 @@ func TestGetDashboardReposHasRepos(t *testing.T) {
 	expectedRepos := []dashboard.DashboardRepo{
 		{
 			Config:     &mockConfig,
+			ConfigPath: ".releasedash.yml",
 			Repository: mockRepoA,
 		},
 		{
 			Config:     &mockConfig,
+			ConfigPath: ".releasedash.yml",
 			Repository: mockRepoB,
 		},
 	}
@@ -234,14 +237,14 @@ func TestGetDashboardReposBadConfigFile(t *testing.T) {
 
 	mockScm.
 		EXPECT().
-		GetRepoFile(mockCtx, mockOwner, mockRepoName+"a", mockSha, ".releasedash.yml").
+		FindRepoFile(mockCtx, mockOwner, mockRepoName+"a", mockSha, dashboard.DefaultRepoConfigPaths).
 		Times(1).
-		Return(mockGoodRepoContent, nil)
+		Return(mockGoodRepoContent, ".releasedash.yml", nil)
 	mockScm.
 		EXPECT().
-		GetRepoFile(mockCtx, mockOwner, mockRepoName+"b", mockSha, ".releasedash.yml").
+		FindRepoFile(mockCtx, mockOwner, mockRepoName+"b", mockSha, dashboard.DefaultRepoConfigPaths).
 		Times(1).
-		Return(mockBadRepoContent, nil)
+		Return(mockBadRepoContent, ".releasedash.yml", nil)
 
 	repos, err := dashboardService.GetDashboardRepos(mockCtx)
 
@@ -250,12 +253,23 @@ func TestGetDashboardReposBadConfigFile(t *testing.T) {
 		Name:            "app",
 	}
 
-	expectedRepos := []dashboard.DashboardRepo{{
-		Config:     &mockConfig,
-		Repository: mockRepoA,
-	}}
+	expectedRepos := []dashboard.DashboardRepo{
+		{
+			Config:     &mockConfig,
+			ConfigPath: ".releasedash.yml",
+			Repository: mockRepoA,
+		},
+		{
+			Config:     &dashboard.DashboardRepoConfig{Name: mockRepoName + "b"},
+			ConfigPath: ".releasedash.yml",
+			Repository: mockRepoB,
+		},
+	}
 
 	assert.NoError(t, err)
+	assert.Len(t, repos, 2)
+	assert.Contains(t, repos[1].ConfigError, "Could not unmarshal repo config")
+	repos[1].ConfigError = ""
 	assert.Equal(t, expectedRepos, repos)
 }
 
@@ -285,9 +299,9 @@ func TestGetDashboardRepoConfigWithAllOptions(t *testing.T) {
 	mockRepoContent, _ := base64.StdEncoding.DecodeString(mockConfigB64)
 	mockScm.
 		EXPECT().
-		GetRepoFile(mockCtx, mockOwner, mockRepo, mockSha, ".releasedash.yml").
+		FindRepoFile(mockCtx, mockOwner, mockRepo, mockSha, dashboard.DefaultRepoConfigPaths).
 		Times(1).
-		Return(mockRepoContent, nil)
+		Return(mockRepoContent, ".releasedash.yml", nil)
 
 	mockConfig := dashboard.DashboardRepoConfig{
 		EnvironmentBranches: []string{"preprod", "prod"},
@@ -295,9 +309,10 @@ func TestGetDashboardRepoConfigWithAllOptions(t *testing.T) {
 		Name:                "app",
 	}
 
-	config, err := dashboardService.GetDashboardRepoConfig(mockCtx, mockOwner, mockRepo, mockDefaultBranch)
+	config, configPath, err := dashboardService.GetDashboardRepoConfig(mockCtx, mockOwner, mockRepo, mockDefaultBranch)
 	assert.NoError(t, err)
 	assert.Equal(t, &mockConfig, config)
+	assert.Equal(t, ".releasedash.yml", configPath)
 }
 
 func TestGetDashboardRepoConfigNoBranch(t *testing.T) {
@@ -317,9 +332,64 @@ func TestGetDashboardRepoConfigNoBranch(t *testing.T) {
 		Times(1).
 		Return(nil, nil)
 
-	config, err := dashboardService.GetDashboardRepoConfig(mockCtx, mockOwner, mockRepo, mockDefaultBranch)
+	config, configPath, err := dashboardService.GetDashboardRepoConfig(mockCtx, mockOwner, mockRepo, mockDefaultBranch)
 	assert.NoError(t, err)
 	assert.Nil(t, config)
+	assert.Empty(t, configPath)
+}
+
+func TestGetDashboardRepoConfigAlternativePaths(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+	dashboardService.Config.Dashboard.RepoConfigPaths = []string{".releasedash.yml", ".github/releasedash.json"}
+
+	mockCtx := context.Background()
+	mockOwner := "o"
+	mockRepo := "r"
+	mockDefaultBranch := "main"
+	mockSha := "s"
+
+	mockRepoBranch := scm.ScmRef{
+		CurrentHash: mockSha,
+		Name:        "main",
+	}
+	mockScm.
+		EXPECT().
+		GetRepoBranch(mockCtx, mockOwner, mockRepo, mockDefaultBranch).
+		Times(1).
+		Return(&mockRepoBranch, nil)
+	mockScm.
+		EXPECT().
+		FindRepoFile(mockCtx, mockOwner, mockRepo, mockSha, []string{".releasedash.yml", ".github/releasedash.json"}).
+		Times(1).
+		Return([]byte(`{"environment_tags": ["dev", "prd"], "name": "app"}`), ".github/releasedash.json", nil)
+
+	mockConfig := dashboard.DashboardRepoConfig{
+		EnvironmentTags: []string{"dev", "prd"},
+		Name:            "app",
+	}
+
+	config, configPath, err := dashboardService.GetDashboardRepoConfig(mockCtx, mockOwner, mockRepo, mockDefaultBranch)
+	assert.NoError(t, err)
+	assert.Equal(t, &mockConfig, config)
+	assert.Equal(t, ".github/releasedash.json", configPath)
+}
+
+func TestDashboardRepoConfigHtmlUrl(t *testing.T) {
+	dashboardRepo := dashboard.DashboardRepo{
+		ConfigPath: ".github/releasedash.yml",
+		Repository: scm.ScmRepository{
+			DefaultBranch: "main",
+			HtmlUrl:       "https://github.com/o/r",
+		},
+	}
+
+	assert.Equal(t, "https://github.com/o/r/blob/main/.github/releasedash.yml", dashboardRepo.ConfigHtmlUrl())
+
+	dashboardRepo.ConfigPath = ""
+	assert.Empty(t, dashboardRepo.ConfigHtmlUrl())
 }
 
 func TestGetDashboardChangelogsHasChanges(t *testing.T) {
@@ -327,6 +397,7 @@ func TestGetDashboardChangelogsHasChanges(t *testing.T) {
 
 	mockScm := mock_scm.NewMockScmAdapter(ctrl)
 	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+	dashboardService.Config.Dashboard.CiStatus = true
 
 	mockOwner := "o"
 
@@ -345,6 +416,7 @@ func TestGetDashboardChangelogsHasChanges(t *testing.T) {
 		OwnerName:     mockOwner,
 	}
 	mockBranchCommitsCompare := []scm.ScmCommit{{Message: "m"}}
+	mockStatus := scm.ScmCommitStatus{State: scm.CommitStatusSuccess}
 
 	mockDashboardRepos := []dashboard.DashboardRepo{
 		{
@@ -366,14 +438,59 @@ func TestGetDashboardChangelogsHasChanges(t *testing.T) {
 	mockCtx := context.Background()
 	mockScm.
 		EXPECT().
-		GetChangelogForBranches(mockCtx, mockOwner, mockBranchRepoName, "prod", "pre-prod").
+		GetChangelogForRefs(mockCtx, mockOwner, mockBranchRepoName, &scm.ScmRef{CurrentHash: "b", Name: "prod"}, &scm.ScmRef{CurrentHash: "a", Name: "pre-prod"}).
 		Times(1).
 		Return(&mockBranchCommitsCompare, nil)
 	mockScm.
 		EXPECT().
-		GetChangelogForTags(mockCtx, mockOwner, mockTagRepoName, "stg", "dev").
+		GetChangelogForRefs(mockCtx, mockOwner, mockTagRepoName, nil, &scm.ScmRef{CurrentHash: "c", Name: "dev"}).
 		Times(1).
 		Return(&mockTagCommitsCompare, nil)
+	mockScm.
+		EXPECT().
+		GetCommitStatus(mockCtx, mockOwner, mockBranchRepoName, "a").
+		Times(1).
+		Return(&mockStatus, nil)
+	mockScm.
+		EXPECT().
+		GetCommitStatus(mockCtx, mockOwner, mockTagRepoName, "c").
+		Times(1).
+		Return(nil, errors.New("error"))
+	mockScm.
+		EXPECT().
+		GetRepoBranch(mockCtx, mockOwner, mockBranchRepoName, "pre-prod").
+		Times(1).
+		Return(&scm.ScmRef{CurrentHash: "a", Name: "pre-prod"}, nil)
+	mockScm.
+		EXPECT().
+		GetRepoBranch(mockCtx, mockOwner, mockBranchRepoName, "prod").
+		Times(1).
+		Return(&scm.ScmRef{CurrentHash: "b", Name: "prod"}, nil)
+	mockScm.
+		EXPECT().
+		GetRepoTag(mockCtx, mockOwner, mockTagRepoName, "dev").
+		Times(1).
+		Return(&scm.ScmRef{CurrentHash: "c", Name: "dev"}, nil)
+	mockScm.
+		EXPECT().
+		GetRepoTag(mockCtx, mockOwner, mockTagRepoName, "stg").
+		Times(1).
+		Return(nil, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, mockOwner, mockBranchRepoName, "a").
+		Times(1).
+		Return(&scm.ScmCommit{Sha: "a"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, mockOwner, mockBranchRepoName, "b").
+		Times(1).
+		Return(&scm.ScmCommit{Sha: "b"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, mockOwner, mockTagRepoName, "c").
+		Times(1).
+		Return(&scm.ScmCommit{Sha: "c"}, nil)
 
 	repoChangelogs := dashboardService.GetDashboardChangelogs(mockCtx, mockDashboardRepos)
 
@@ -382,12 +499,21 @@ func TestGetDashboardChangelogsHasChanges(t *testing.T) {
 			ChangelogCommits: []dashboard.DashboardChangelogCommits{{
 				Commits: mockBranchCommitsCompare,
 				FromRef: "prod",
+				Status:  &mockStatus,
 				ToRef:   "pre-prod",
 			}},
 			Config: &dashboard.DashboardRepoConfig{
 				EnvironmentBranches: []string{"pre-prod", "prod"},
 				Name:                "app",
 			},
+			EnvironmentRefs: map[string]scm.ScmRef{
+				"pre-prod": {CurrentHash: "a", Name: "pre-prod"},
+				"prod":     {CurrentHash: "b", Name: "prod"},
+			},
+			Environments: []dashboard.DashboardEnvironment{
+				{Commit: &scm.ScmCommit{Sha: "a"}, Name: "pre-prod"},
+				{Commit: &scm.ScmCommit{Sha: "b"}, Name: "prod"},
+			},
 			Repository: mockBranchRepo,
 		},
 		{
@@ -400,6 +526,13 @@ func TestGetDashboardChangelogsHasChanges(t *testing.T) {
 				EnvironmentTags: []string{"dev", "stg"},
 				Name:            "app",
 			},
+			EnvironmentRefs: map[string]scm.ScmRef{
+				"dev": {CurrentHash: "c", Name: "dev"},
+			},
+			Environments: []dashboard.DashboardEnvironment{
+				{Commit: &scm.ScmCommit{Sha: "c"}, Name: "dev"},
+				{Name: "stg"},
+			},
 			Repository: mockTagRepo,
 		},
 	}
@@ -433,9 +566,337 @@ func TestGetDashboardChangelogsNoChanges(t *testing.T) {
 
 	mockScm.
 		EXPECT().
-		GetChangelogForTags(mockCtx, mockOwner, mockRepoName, "stg", "dev").
+		GetChangelogForRefs(mockCtx, mockOwner, mockRepoName, &scm.ScmRef{CurrentHash: "h"}, &scm.ScmRef{CurrentHash: "h"}).
 		Times(1).
 		Return(nil, errors.New(""))
+	mockScm.
+		EXPECT().
+		GetRepoTag(mockCtx, mockOwner, mockRepoName, gomock.Any()).
+		Times(2).
+		Return(&scm.ScmRef{CurrentHash: "h"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, mockOwner, mockRepoName, "h").
+		AnyTimes().
+		Return(&scm.ScmCommit{Sha: "h"}, nil)
 
 	dashboardService.GetDashboardChangelogs(mockCtx, mockDashboardRepos)
 }
+
+func TestNewDashboardRepoConfigServiceMissingPaths(t *testing.T) {
+	content := []byte("---\nenvironment_tags: [dev, prd]\nservices:\n  - name: api\n")
+
+	repoConfig, err := dashboard.NewDashboardRepoConfig(content)
+
+	assert.Error(t, err)
+	assert.Nil(t, repoConfig)
+}
+
+func TestNewDashboardRepoConfigFilters(t *testing.T) {
+	content := []byte("---\nenvironment_tags: [dev, prd]\nfilters:\n  exclude:\n    authors: [dependabot]\n    merges: true\n    messages: ['^chore\\(deps\\)']\n")
+
+	repoConfig, err := dashboard.NewDashboardRepoConfig(content)
+
+	expectedFilters := dashboard.DashboardCommitFilters{
+		Exclude: dashboard.DashboardCommitFilter{
+			Authors:  []string{"dependabot"},
+			Merges:   true,
+			Messages: []string{`^chore\(deps\)`},
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.NoError(t, expectedFilters.Validate())
+	assert.Equal(t, expectedFilters, repoConfig.Filters)
+}
+
+func TestGetDashboardReposMonorepoServices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockCtx := context.Background()
+	mockOwner := "o"
+	mockRepoName := "r"
+	mockSha := "s"
+
+	mockRepo := scm.ScmRepository{
+		DefaultBranch: "main",
+		Name:          mockRepoName,
+		OwnerName:     mockOwner,
+	}
+
+	mockScm.
+		EXPECT().
+		GetUserRepos(mockCtx, "").
+		Times(1).
+		Return([]scm.ScmRepository{mockRepo}, nil)
+	mockScm.
+		EXPECT().
+		GetRepoBranch(mockCtx, mockOwner, mockRepoName, "main").
+		Times(1).
+		Return(&scm.ScmRef{CurrentHash: mockSha, Name: "main"}, nil)
+
+	mockRepoContent := "---\nenvironment_tags: [dev, prd]\nname: mono\nservices:\n" +
+		"  - name: web\n    paths: [services/web]\n" +
+		"  - name: api\n    paths: [services/api, lib]\n"
+	mockScm.
+		EXPECT().
+		FindRepoFile(mockCtx, mockOwner, mockRepoName, mockSha, dashboard.DefaultRepoConfigPaths).
+		Times(1).
+		Return([]byte(mockRepoContent), ".releasedash.yml", nil)
+
+	repos, err := dashboardService.GetDashboardRepos(mockCtx)
+
+	expectedRepos := []dashboard.DashboardRepo{
+		{
+			Config: &dashboard.DashboardRepoConfig{
+				EnvironmentTags: []string{"dev", "prd"},
+				Name:            "api",
+				Paths:           []string{"services/api", "lib"},
+			},
+			ConfigPath: ".releasedash.yml",
+			Repository: mockRepo,
+		},
+		{
+			Config: &dashboard.DashboardRepoConfig{
+				EnvironmentTags: []string{"dev", "prd"},
+				Name:            "web",
+				Paths:           []string{"services/web"},
+			},
+			ConfigPath: ".releasedash.yml",
+			Repository: mockRepo,
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRepos, repos)
+}
+
+func TestGetDashboardChangelogsFiltersByPaths(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockCtx := context.Background()
+	mockOwner := "o"
+	mockRepoName := "r"
+
+	mockConfig := dashboard.DashboardRepoConfig{
+		EnvironmentTags: []string{"dev", "stg"},
+		Name:            "api",
+		Paths:           []string{"services/api/"},
+	}
+	mockRepo := scm.ScmRepository{
+		DefaultBranch: "main",
+		Name:          mockRepoName,
+		OwnerName:     mockOwner,
+	}
+
+	mockDashboardRepos := []dashboard.DashboardRepo{{
+		Config:     &mockConfig,
+		Repository: mockRepo,
+	}}
+
+	mockCommitsCompare := []scm.ScmCommit{
+		{Message: "api change", Sha: "a"},
+		{Message: "web change", Sha: "w"},
+		{Message: "near miss", Sha: "n"},
+	}
+
+	mockScm.
+		EXPECT().
+		GetChangelogForRefs(mockCtx, mockOwner, mockRepoName, &scm.ScmRef{CurrentHash: "h"}, &scm.ScmRef{CurrentHash: "h"}).
+		Times(1).
+		Return(&mockCommitsCompare, nil)
+	mockScm.
+		EXPECT().
+		GetRepoTag(mockCtx, mockOwner, mockRepoName, gomock.Any()).
+		Times(2).
+		Return(&scm.ScmRef{CurrentHash: "h"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, mockOwner, mockRepoName, "h").
+		AnyTimes().
+		Return(&scm.ScmCommit{Sha: "h"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, mockOwner, mockRepoName, "a").
+		Times(1).
+		Return(&scm.ScmCommit{Sha: "a", Files: []string{"services/api/main.go"}}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, mockOwner, mockRepoName, "w").
+		Times(1).
+		Return(&scm.ScmCommit{Sha: "w", Files: []string{"services/web/main.go"}}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, mockOwner, mockRepoName, "n").
+		Times(1).
+		Return(&scm.ScmCommit{Sha: "n", Files: []string{"services/api-docs/README.md"}}, nil)
+
+	repoChangelogs := dashboardService.GetDashboardChangelogs(mockCtx, mockDashboardRepos)
+
+	expectedRepoChangelogs := []dashboard.DashboardRepoChangelog{{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{{
+			Commits: []scm.ScmCommit{{Message: "api change", Sha: "a"}},
+			FromRef: "stg",
+			ToRef:   "dev",
+		}},
+		Config: &mockConfig,
+		EnvironmentRefs: map[string]scm.ScmRef{
+			"dev": {CurrentHash: "h"},
+			"stg": {CurrentHash: "h"},
+		},
+		Environments: []dashboard.DashboardEnvironment{
+			{Commit: &scm.ScmCommit{Sha: "h"}, Name: "dev"},
+			{Commit: &scm.ScmCommit{Sha: "h"}, Name: "stg"},
+		},
+		Repository: mockRepo,
+	}}
+
+	assert.Equal(t, expectedRepoChangelogs, repoChangelogs)
+}
+
+func TestGetDashboardChangelogsSharesCompares(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{
+		CacheService: cache.NewLocalCacheAdapter(60, 60),
+		ScmService:   mockScm,
+	}
+
+	mockCtx := context.Background()
+	mockOwner := "o"
+	mockRepoName := "r"
+
+	mockRepo := scm.ScmRepository{
+		DefaultBranch: "main",
+		Name:          mockRepoName,
+		OwnerName:     mockOwner,
+	}
+	mockDashboardRepos := []dashboard.DashboardRepo{
+		{
+			Config:     &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"dev", "stg"}, Name: "api", Paths: []string{"services/api/"}},
+			Repository: mockRepo,
+		},
+		{
+			Config:     &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"dev", "stg"}, Name: "web", Paths: []string{"services/web/"}},
+			Repository: mockRepo,
+		},
+	}
+
+	mockCommitsCompare := []scm.ScmCommit{
+		{Message: "api change", Sha: "a"},
+		{Message: "web change", Sha: "w"},
+	}
+
+	// One lookup of each environment ref and one compare for both services on
+	// each refresh, files are only fetched once for each commit
+	mockScm.
+		EXPECT().
+		GetChangelogForRefs(mockCtx, mockOwner, mockRepoName, &scm.ScmRef{CurrentHash: "h"}, &scm.ScmRef{CurrentHash: "h"}).
+		Times(2).
+		Return(&mockCommitsCompare, nil)
+	mockScm.
+		EXPECT().
+		GetRepoTag(mockCtx, mockOwner, mockRepoName, gomock.Any()).
+		Times(4).
+		Return(&scm.ScmRef{CurrentHash: "h"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, mockOwner, mockRepoName, "h").
+		AnyTimes().
+		Return(&scm.ScmCommit{Sha: "h"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, mockOwner, mockRepoName, "a").
+		Times(1).
+		Return(&scm.ScmCommit{Sha: "a", Files: []string{"services/api/main.go"}}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, mockOwner, mockRepoName, "w").
+		Times(1).
+		Return(&scm.ScmCommit{Sha: "w", Files: []string{"services/web/main.go"}}, nil)
+
+	for refresh := 0; refresh < 2; refresh++ {
+		repoChangelogs := dashboardService.GetDashboardChangelogs(mockCtx, mockDashboardRepos)
+
+		assert.Len(t, repoChangelogs, 2)
+		assert.Equal(t, []scm.ScmCommit{{Message: "api change", Sha: "a"}}, repoChangelogs[0].ChangelogCommits[0].Commits)
+		assert.Equal(t, []scm.ScmCommit{{Message: "web change", Sha: "w"}}, repoChangelogs[1].ChangelogCommits[0].Commits)
+	}
+}
+
+func TestGetDashboardChangelogForRefs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockRepo := scm.ScmRepository{Name: "r", OwnerName: "o"}
+	mockCommitsCompare := []scm.ScmCommit{
+		{Message: "PAY-1 add refunds", Sha: "a"},
+		{AuthorLogin: "dependabot[bot]", Message: "bump", Sha: "b"},
+	}
+	mockDashboardRepo := dashboard.DashboardRepo{
+		Config: &dashboard.DashboardRepoConfig{
+			EnvironmentTags: []string{"dev", "stg"},
+			Filters: dashboard.DashboardCommitFilters{
+				Exclude: dashboard.DashboardCommitFilter{Authors: []string{"dependabot"}},
+			},
+			Name:    "app",
+			Tickets: []dashboard.DashboardTicketPattern{{Pattern: `PAY-[0-9]+`, Url: "https://jira/browse/{key}"}},
+		},
+		Repository: mockRepo,
+	}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetChangelogForRefNames(mockCtx, "o", "r", "v1.0.0", "main").
+		Times(1).
+		Return(&mockCommitsCompare, nil)
+
+	changelogCommits, err := dashboardService.GetDashboardChangelogForRefs(mockCtx, mockDashboardRepo, "v1.0.0", "main")
+
+	expectedChangelogCommits := &dashboard.DashboardChangelogCommits{
+		Commits:       mockCommitsCompare[:1],
+		FromRef:       "v1.0.0",
+		HiddenCommits: 1,
+		Tickets: []dashboard.DashboardTicket{
+			{CommitShas: []string{"a"}, Key: "PAY-1", Url: "https://jira/browse/PAY-1"},
+		},
+		ToRef: "main",
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedChangelogCommits, changelogCommits)
+}
+
+func TestGetDashboardChangelogForRefsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockDashboardRepo := dashboard.DashboardRepo{
+		Config:     &dashboard.DashboardRepoConfig{Name: "app"},
+		Repository: scm.ScmRepository{Name: "r", OwnerName: "o"},
+	}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetChangelogForRefNames(mockCtx, "o", "r", "v1.0.0", "main").
+		Times(1).
+		Return(nil, errors.New("error"))
+
+	changelogCommits, err := dashboardService.GetDashboardChangelogForRefs(mockCtx, mockDashboardRepo, "v1.0.0", "main")
+
+	assert.Error(t, err)
+	assert.Nil(t, changelogCommits)
+}
@@ -0,0 +1,83 @@
+package dashboard
+
+import (
+	"context"
+
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/rs/zerolog/log"
+)
+
+type DashboardEnvironment struct {
+	// Commit is the head of the environment ref, nil if the ref could not be found
+	Commit *scm.ScmCommit
+	Name   string
+}
+
+// dashboardRepoEnvironments holds the environment lookups of a repo so that
+// the services of a monorepo can share them within a refresh.
+type dashboardRepoEnvironments struct {
+	environmentRefs map[string]scm.ScmRef
+	environments    []DashboardEnvironment
+	failedRefs      map[string]bool
+}
+
+// EnvironmentRefs returns the environment branches or tags in pipeline order.
+func (c *DashboardRepoConfig) EnvironmentRefs() []string {
+	if c.HasEnvironmentBranches() {
+		return c.EnvironmentBranches
+	}
+	return c.EnvironmentTags
+}
+
+// getDashboardEnvironments looks up the commit at the head of each environment
+// ref, these are kept with the changelogs so that the repo page doesn't have to
+// call GitHub.
+func (d *DashboardService) getDashboardEnvironments(ctx context.Context, dashboardRepo DashboardRepo, environmentRefs map[string]scm.ScmRef) []DashboardEnvironment {
+	owner := dashboardRepo.Repository.OwnerName
+	repo := dashboardRepo.Repository.Name
+
+	var environments []DashboardEnvironment
+	for _, environmentRef := range dashboardRepo.Config.EnvironmentRefs() {
+		environment := DashboardEnvironment{Name: environmentRef}
+		if ref, found := environmentRefs[environmentRef]; found {
+			commit, err := d.getCommit(ctx, owner, repo, ref.CurrentHash)
+			if err != nil {
+				log.Error().Err(err).Msgf("Could not get commit for ref %s in repo %s/%s", environmentRef, owner, repo)
+			} else {
+				environment.Commit = commit
+			}
+		}
+		environments = append(environments, environment)
+	}
+	return environments
+}
+
+// getEnvironmentRefs looks up the head of every environment branch or tag once
+// per fetch, the changelogs are compared from these refs and promotions are
+// spotted by comparing them between fetches. Refs that could not be looked
+// up are returned separately from refs that don't exist.
+func (d *DashboardService) getEnvironmentRefs(ctx context.Context, dashboardRepo DashboardRepo) (map[string]scm.ScmRef, map[string]bool) {
+	owner := dashboardRepo.Repository.OwnerName
+	repo := dashboardRepo.Repository.Name
+
+	environmentRefs := map[string]scm.ScmRef{}
+	failedRefs := map[string]bool{}
+	for _, environmentRef := range dashboardRepo.Config.EnvironmentRefs() {
+		var ref *scm.ScmRef
+		var err error
+		if dashboardRepo.Config.HasEnvironmentBranches() {
+			ref, err = d.ScmService.GetRepoBranch(ctx, owner, repo, environmentRef)
+		} else {
+			ref, err = d.ScmService.GetRepoTag(ctx, owner, repo, environmentRef)
+		}
+		if err != nil {
+			log.Error().Err(err).Msgf("Could not get ref %s in repo %s/%s", environmentRef, owner, repo)
+			failedRefs[environmentRef] = true
+			continue
+		}
+		if ref != nil {
+			environmentRefs[environmentRef] = *ref
+		}
+	}
+	return environmentRefs, failedRefs
+}
@@ -0,0 +1,79 @@
+package dashboard_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	dashboard "github.com/lobsterdore/release-dash/dashboard"
+	mock_scm "github.com/lobsterdore/release-dash/mocks/scm"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+func TestGetDashboardChangelogsEnvironments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockDashboardRepo := dashboard.DashboardRepo{
+		Config: &dashboard.DashboardRepoConfig{
+			EnvironmentBranches: []string{"main", "prod", "dr"},
+			Name:                "app",
+		},
+		Repository: scm.ScmRepository{Name: "r", OwnerName: "o"},
+	}
+	mockCommit := scm.ScmCommit{Message: "m", Sha: "s1"}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetRepoBranch(mockCtx, "o", "r", "main").
+		Times(1).
+		Return(&scm.ScmRef{CurrentHash: "s1"}, nil)
+	mockScm.
+		EXPECT().
+		GetRepoBranch(mockCtx, "o", "r", "prod").
+		Times(1).
+		Return(&scm.ScmRef{CurrentHash: "s2"}, nil)
+	mockScm.
+		EXPECT().
+		GetRepoBranch(mockCtx, "o", "r", "dr").
+		Times(1).
+		Return(nil, errors.New("error"))
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, "o", "r", "s1").
+		Times(1).
+		Return(&mockCommit, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, "o", "r", "s2").
+		Times(1).
+		Return(nil, errors.New("error"))
+	mockScm.
+		EXPECT().
+		GetChangelogForRefs(mockCtx, "o", "r", &scm.ScmRef{CurrentHash: "s2"}, &scm.ScmRef{CurrentHash: "s1"}).
+		Times(1).
+		Return(nil, errors.New("error"))
+
+	repoChangelogs := dashboardService.GetDashboardChangelogs(mockCtx, []dashboard.DashboardRepo{mockDashboardRepo})
+
+	expectedEnvironments := []dashboard.DashboardEnvironment{
+		{Commit: &mockCommit, Name: "main"},
+		{Name: "prod"},
+		{Name: "dr"},
+	}
+
+	assert.Len(t, repoChangelogs, 1)
+	assert.Equal(t, expectedEnvironments, repoChangelogs[0].Environments)
+}
+
+func TestDashboardRepoConfigEnvironmentRefs(t *testing.T) {
+	assert.Equal(t, []string{"dev"}, (&dashboard.DashboardRepoConfig{EnvironmentTags: []string{"dev"}}).EnvironmentRefs())
+	assert.Equal(t, []string{"main"}, (&dashboard.DashboardRepoConfig{EnvironmentBranches: []string{"main"}, EnvironmentTags: []string{"dev"}}).EnvironmentRefs())
+	assert.Nil(t, (&dashboard.DashboardRepoConfig{}).EnvironmentRefs())
+}
@@ -0,0 +1,103 @@
+package dashboard
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+type DashboardCommitFilters struct {
+	Exclude DashboardCommitFilter `json:"exclude" yaml:"exclude"`
+	Include DashboardCommitFilter `json:"include" yaml:"include"`
+}
+
+type DashboardCommitFilter struct {
+	Authors  []string `json:"authors" yaml:"authors"`
+	Merges   bool     `json:"merges" yaml:"merges"`
+	Messages []string `json:"messages" yaml:"messages"`
+
+	// messageRegexps are compiled from Messages when the config is validated
+	messageRegexps []*regexp.Regexp
+}
+
+// Validate compiles the message patterns of both filters, so that they are
+// only compiled once when the repo config is loaded.
+func (f *DashboardCommitFilters) Validate() error {
+	for _, filter := range []*DashboardCommitFilter{&f.Exclude, &f.Include} {
+		filter.messageRegexps = nil
+		for _, message := range filter.Messages {
+			messageRegexp, err := regexp.Compile(message)
+			if err != nil {
+				return fmt.Errorf("Could not compile commit filter %s: %s", message, err)
+			}
+			filter.messageRegexps = append(filter.messageRegexps, messageRegexp)
+		}
+	}
+	return nil
+}
+
+// Apply keeps commits that match an include rule, if there are any, and
+// don't match an exclude rule, the number of commits dropped is returned.
+func (f DashboardCommitFilters) Apply(commits []scm.ScmCommit) ([]scm.ScmCommit, int) {
+	if f.Include.IsEmpty() && f.Exclude.IsEmpty() {
+		return commits, 0
+	}
+	// Filters built outside of a repo config haven't been validated yet
+	if !f.Include.isCompiled() || !f.Exclude.isCompiled() {
+		if err := f.Validate(); err != nil {
+			return commits, 0
+		}
+	}
+
+	var keptCommits []scm.ScmCommit
+	hiddenCommits := 0
+	for _, commit := range commits {
+		if !f.Include.IsEmpty() && !f.Include.Matches(commit) {
+			hiddenCommits++
+			continue
+		}
+		if f.Exclude.Matches(commit) {
+			hiddenCommits++
+			continue
+		}
+		keptCommits = append(keptCommits, commit)
+	}
+	return keptCommits, hiddenCommits
+}
+
+func (f DashboardCommitFilter) isCompiled() bool {
+	return len(f.messageRegexps) == len(f.Messages)
+}
+
+func (f DashboardCommitFilter) IsEmpty() bool {
+	return len(f.Authors) == 0 && !f.Merges && len(f.Messages) == 0
+}
+
+// Matches checks a commit against each rule, authors are compared with both
+// the login and name of the commit author ignoring case and any [bot] suffix.
+func (f DashboardCommitFilter) Matches(commit scm.ScmCommit) bool {
+	if f.Merges && commit.IsMerge {
+		return true
+	}
+
+	for _, author := range f.Authors {
+		author = normaliseAuthor(author)
+		if author != "" && (author == normaliseAuthor(commit.AuthorLogin) || author == normaliseAuthor(commit.AuthorName)) {
+			return true
+		}
+	}
+
+	for _, messageRegexp := range f.messageRegexps {
+		if messageRegexp.MatchString(commit.Message) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normaliseAuthor(author string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(author)), "[bot]")
+}
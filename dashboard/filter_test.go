@@ -0,0 +1,92 @@
+package dashboard_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dashboard "github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+var mockFilterCommits = []scm.ScmCommit{
+	{AuthorLogin: "dev", Message: "feat: add payments", Sha: "1"},
+	{AuthorLogin: "dependabot[bot]", Message: "chore(deps): bump yaml", Sha: "2"},
+	{AuthorLogin: "renovate[bot]", Message: "Update module", Sha: "3"},
+	{AuthorLogin: "dev", IsMerge: true, Message: "Merge branch 'main'", Sha: "4"},
+	{AuthorName: "Ops Person", Message: "chore(deps): pin base image", Sha: "5"},
+}
+
+func TestDashboardCommitFiltersApplyNoFilters(t *testing.T) {
+	filters := dashboard.DashboardCommitFilters{}
+
+	commits, hiddenCommits := filters.Apply(mockFilterCommits)
+
+	assert.Equal(t, mockFilterCommits, commits)
+	assert.Equal(t, 0, hiddenCommits)
+}
+
+func TestDashboardCommitFiltersApplyExclude(t *testing.T) {
+	filters := dashboard.DashboardCommitFilters{
+		Exclude: dashboard.DashboardCommitFilter{
+			Authors:  []string{"Renovate"},
+			Merges:   true,
+			Messages: []string{`^chore\(deps\)`},
+		},
+	}
+
+	commits, hiddenCommits := filters.Apply(mockFilterCommits)
+
+	assert.Equal(t, []scm.ScmCommit{mockFilterCommits[0]}, commits)
+	assert.Equal(t, 4, hiddenCommits)
+}
+
+func TestDashboardCommitFiltersApplyInclude(t *testing.T) {
+	filters := dashboard.DashboardCommitFilters{
+		Exclude: dashboard.DashboardCommitFilter{
+			Merges: true,
+		},
+		Include: dashboard.DashboardCommitFilter{
+			Authors: []string{"dev", "ops person"},
+		},
+	}
+
+	commits, hiddenCommits := filters.Apply(mockFilterCommits)
+
+	assert.Equal(t, []scm.ScmCommit{mockFilterCommits[0], mockFilterCommits[4]}, commits)
+	assert.Equal(t, 3, hiddenCommits)
+}
+
+func TestDashboardCommitFiltersValidate(t *testing.T) {
+	filters := dashboard.DashboardCommitFilters{
+		Include: dashboard.DashboardCommitFilter{
+			Messages: []string{"^feat("},
+		},
+	}
+
+	assert.Error(t, filters.Validate())
+}
+
+func TestDashboardCommitFiltersValidateCompiles(t *testing.T) {
+	filters := dashboard.DashboardCommitFilters{
+		Exclude: dashboard.DashboardCommitFilter{
+			Messages: []string{`^chore\(deps\)`},
+		},
+	}
+
+	assert.NoError(t, filters.Validate())
+
+	commits, hiddenCommits := filters.Apply(mockFilterCommits)
+
+	assert.Equal(t, []scm.ScmCommit{mockFilterCommits[0], mockFilterCommits[2], mockFilterCommits[3]}, commits)
+	assert.Equal(t, 2, hiddenCommits)
+}
+
+func TestNewDashboardRepoConfigBadFilter(t *testing.T) {
+	content := []byte("---\nenvironment_tags: [dev, prd]\nfilters:\n  exclude:\n    messages: ['^feat(']\n")
+
+	repoConfig, err := dashboard.NewDashboardRepoConfig(content)
+
+	assert.Error(t, err)
+	assert.Nil(t, repoConfig)
+}
@@ -0,0 +1,88 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	CommitLocationContained = "contained"
+	CommitLocationMissing   = "missing"
+	CommitLocationUnknown   = "unknown"
+)
+
+// DashboardCommitLocation reports which environments of a repo already
+// contain a commit.
+type DashboardCommitLocation struct {
+	Commit       *scm.ScmCommit
+	Environments []DashboardCommitEnvironment
+	// PullRequest is set when the commit was looked up via a pull request
+	PullRequest *scm.ScmPullRequest
+}
+
+type DashboardCommitEnvironment struct {
+	Name   string
+	Status string
+}
+
+// GetDashboardCommitLocation checks each environment ref of a dashboard repo
+// for a commit, the ref can be a commit sha or a pull request number such as
+// #123. Pull requests are located via their merge commit.
+func (d *DashboardService) GetDashboardCommitLocation(ctx context.Context, dashboardRepo DashboardRepo, ref string) (*DashboardCommitLocation, error) {
+	owner := dashboardRepo.Repository.OwnerName
+	repo := dashboardRepo.Repository.Name
+	location := DashboardCommitLocation{}
+
+	sha := strings.TrimSpace(ref)
+	if number, ok := parsePullRequestNumber(sha); ok {
+		pullRequest, err := d.ScmService.GetPullRequest(ctx, owner, repo, number)
+		if err != nil {
+			return nil, fmt.Errorf("Could not get pull request #%d: %s", number, err)
+		}
+		if !pullRequest.Merged {
+			return nil, fmt.Errorf("Pull request #%d has not been merged", number)
+		}
+		location.PullRequest = pullRequest
+		sha = pullRequest.MergeCommitSha
+	}
+
+	commit, err := d.ScmService.GetCommit(ctx, owner, repo, sha)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get commit %s: %s", sha, err)
+	}
+	location.Commit = commit
+
+	for _, environmentRef := range dashboardRepo.Config.EnvironmentRefs() {
+		environment := DashboardCommitEnvironment{Name: environmentRef, Status: CommitLocationUnknown}
+		compareStatus, err := d.ScmService.GetCompareStatus(ctx, owner, repo, commit.Sha, environmentRef)
+		if err != nil {
+			log.Error().Err(err).Msgf("Could not compare commit %s with ref %s in repo %s/%s", commit.Sha, environmentRef, owner, repo)
+		} else if compareStatus.HeadContainsBase() {
+			environment.Status = CommitLocationContained
+		} else {
+			environment.Status = CommitLocationMissing
+		}
+		location.Environments = append(location.Environments, environment)
+	}
+
+	return &location, nil
+}
+
+// parsePullRequestNumber treats #123 as a pull request number, bare numbers
+// are left as commit shas since a short sha can be all digits.
+func parsePullRequestNumber(ref string) (int, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return 0, false
+	}
+
+	number, err := strconv.Atoi(strings.TrimPrefix(ref, "#"))
+	if err != nil || number <= 0 {
+		return 0, false
+	}
+	return number, true
+}
@@ -0,0 +1,167 @@
+package dashboard_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	dashboard "github.com/lobsterdore/release-dash/dashboard"
+	mock_scm "github.com/lobsterdore/release-dash/mocks/scm"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+var mockLocateDashboardRepo = dashboard.DashboardRepo{
+	Config: &dashboard.DashboardRepoConfig{
+		EnvironmentTags: []string{"dev", "stg", "prod"},
+		Name:            "app",
+	},
+	Repository: scm.ScmRepository{Name: "r", OwnerName: "o"},
+}
+
+func TestGetDashboardCommitLocationSha(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockCommit := scm.ScmCommit{Message: "m", Sha: "3e0f3d8c432ca2a03a3222fb55de63934338022f"}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, "o", "r", "3e0f3d8").
+		Times(1).
+		Return(&mockCommit, nil)
+	mockScm.
+		EXPECT().
+		GetCompareStatus(mockCtx, "o", "r", mockCommit.Sha, "dev").
+		Times(1).
+		Return(&scm.ScmCompareStatus{AheadBy: 3, Status: scm.CompareStatusAhead}, nil)
+	mockScm.
+		EXPECT().
+		GetCompareStatus(mockCtx, "o", "r", mockCommit.Sha, "stg").
+		Times(1).
+		Return(&scm.ScmCompareStatus{BehindBy: 1, Status: scm.CompareStatusBehind}, nil)
+	mockScm.
+		EXPECT().
+		GetCompareStatus(mockCtx, "o", "r", mockCommit.Sha, "prod").
+		Times(1).
+		Return(nil, errors.New("error"))
+
+	location, err := dashboardService.GetDashboardCommitLocation(mockCtx, mockLocateDashboardRepo, " 3e0f3d8 ")
+
+	expectedLocation := dashboard.DashboardCommitLocation{
+		Commit: &mockCommit,
+		Environments: []dashboard.DashboardCommitEnvironment{
+			{Name: "dev", Status: dashboard.CommitLocationContained},
+			{Name: "stg", Status: dashboard.CommitLocationMissing},
+			{Name: "prod", Status: dashboard.CommitLocationUnknown},
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, &expectedLocation, location)
+}
+
+func TestGetDashboardCommitLocationPullRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockCommit := scm.ScmCommit{Message: "m", Sha: "3e0f3d8c432ca2a03a3222fb55de63934338022f"}
+	mockPullRequest := scm.ScmPullRequest{MergeCommitSha: mockCommit.Sha, Merged: true, Number: 12}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetPullRequest(mockCtx, "o", "r", 12).
+		Times(1).
+		Return(&mockPullRequest, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, "o", "r", mockCommit.Sha).
+		Times(1).
+		Return(&mockCommit, nil)
+	mockScm.
+		EXPECT().
+		GetCompareStatus(mockCtx, "o", "r", mockCommit.Sha, gomock.Any()).
+		Times(3).
+		Return(&scm.ScmCompareStatus{Status: scm.CompareStatusIdentical}, nil)
+
+	location, err := dashboardService.GetDashboardCommitLocation(mockCtx, mockLocateDashboardRepo, "#12")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &mockPullRequest, location.PullRequest)
+	assert.Equal(t, &mockCommit, location.Commit)
+	assert.Len(t, location.Environments, 3)
+	assert.Equal(t, dashboard.CommitLocationContained, location.Environments[2].Status)
+}
+
+func TestGetDashboardCommitLocationNumericSha(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockCommit := scm.ScmCommit{Message: "m", Sha: "1234560d8c432ca2a03a3222fb55de63934338022f"}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, "o", "r", "123456").
+		Times(1).
+		Return(&mockCommit, nil)
+	mockScm.
+		EXPECT().
+		GetCompareStatus(mockCtx, "o", "r", mockCommit.Sha, gomock.Any()).
+		Times(3).
+		Return(&scm.ScmCompareStatus{Status: scm.CompareStatusIdentical}, nil)
+
+	location, err := dashboardService.GetDashboardCommitLocation(mockCtx, mockLocateDashboardRepo, "123456")
+
+	assert.NoError(t, err)
+	assert.Nil(t, location.PullRequest)
+	assert.Equal(t, &mockCommit, location.Commit)
+}
+
+func TestGetDashboardCommitLocationUnmergedPullRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetPullRequest(mockCtx, "o", "r", 13).
+		Times(1).
+		Return(&scm.ScmPullRequest{Number: 13}, nil)
+
+	location, err := dashboardService.GetDashboardCommitLocation(mockCtx, mockLocateDashboardRepo, "#13")
+
+	assert.EqualError(t, err, "Pull request #13 has not been merged")
+	assert.Nil(t, location)
+}
+
+func TestGetDashboardCommitLocationError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, "o", "r", "missing").
+		Times(1).
+		Return(nil, errors.New("error"))
+
+	location, err := dashboardService.GetDashboardCommitLocation(mockCtx, mockLocateDashboardRepo, "missing")
+
+	assert.EqualError(t, err, "Could not get commit missing: error")
+	assert.Nil(t, location)
+}
@@ -0,0 +1,171 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/rs/zerolog/log"
+)
+
+// DashboardPromotion is an environment ref that moved between two changelog
+// fetches, Commits holds the commits that were promoted.
+type DashboardPromotion struct {
+	Commits     []scm.ScmCommit
+	Config      *DashboardRepoConfig
+	Environment string
+	FromSha     string
+	PromotedAt  time.Time
+	Repository  scm.ScmRepository
+	ToSha       string
+}
+
+func (p DashboardPromotion) CompareHtmlUrl() string {
+	return p.Repository.CompareHtmlUrl(p.FromSha, p.ToSha)
+}
+
+// Id is unique for each move of an environment ref.
+func (p DashboardPromotion) Id() string {
+	return PromotionId(p.Repository.OwnerName, p.Repository.Name, p.Config.Name, p.Environment, p.FromSha, p.ToSha)
+}
+
+// PromotionId joins the parts of a promotion with each part escaped, so that
+// service and environment names can't run into each other.
+func PromotionId(owner string, repo string, service string, environment string, fromSha string, toSha string) string {
+	parts := []string{owner, repo, service, environment, fromSha, toSha}
+	for index, part := range parts {
+		parts[index] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// GetDashboardPromotions compares the environment refs of two sets of repo
+// changelogs, every ref whose hash changed becomes a promotion. Repos and
+// environments that are missing from the previous set are skipped as there
+// is nothing to compare them to. Services of a monorepo share each compare.
+func (d *DashboardService) GetDashboardPromotions(ctx context.Context, previous []DashboardRepoChangelog, current []DashboardRepoChangelog, promotedAt time.Time) []DashboardPromotion {
+	var promotions []DashboardPromotion
+	changelogs := map[string]*[]scm.ScmCommit{}
+	for _, repoChangelog := range current {
+		previousChangelog := findRepoChangelog(previous, repoChangelog)
+		if previousChangelog == nil {
+			continue
+		}
+
+		owner := repoChangelog.Repository.OwnerName
+		repo := repoChangelog.Repository.Name
+		repoConfig := repoChangelog.Config
+
+		for _, environmentRef := range repoConfig.EnvironmentRefs() {
+			previousRef, found := previousChangelog.EnvironmentRefs[environmentRef]
+			if !found {
+				continue
+			}
+			currentRef, found := repoChangelog.EnvironmentRefs[environmentRef]
+			if !found || currentRef.CurrentHash == previousRef.CurrentHash {
+				continue
+			}
+
+			promotion, err := d.newDashboardPromotion(ctx, changelogs, repoChangelog.Repository, repoConfig, environmentRef, previousRef.CurrentHash, currentRef.CurrentHash, promotedAt)
+			if err != nil {
+				log.Error().Err(err).Msgf("Could not get promoted commits for ref %s in repo %s/%s", environmentRef, owner, repo)
+				continue
+			}
+			// The ref moved for another service of the monorepo
+			if promotion == nil {
+				continue
+			}
+			promotions = append(promotions, *promotion)
+		}
+	}
+	return promotions
+}
+
+// GetDashboardTagPromotions rebuilds the past promotions of an environment
+// from tags matching tagPattern, such as prod-*, each tag is a promotion from
+// the tag before it. The oldest tag is skipped as there is nothing to compare
+// it to.
+func (d *DashboardService) GetDashboardTagPromotions(ctx context.Context, dashboardRepo DashboardRepo, environment string, tagPattern string) ([]DashboardPromotion, error) {
+	owner := dashboardRepo.Repository.OwnerName
+	repo := dashboardRepo.Repository.Name
+
+	tags, err := d.ScmService.GetRepoTags(ctx, owner, repo, tagPattern)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get tags %s for repo %s/%s: %s", tagPattern, owner, repo, err)
+	}
+
+	var promotions []DashboardPromotion
+	changelogs := map[string]*[]scm.ScmCommit{}
+	for index := 1; index < len(tags); index++ {
+		fromTag := tags[index-1]
+		toTag := tags[index]
+		if fromTag.Sha == toTag.Sha {
+			continue
+		}
+
+		promotion, err := d.newDashboardPromotion(ctx, changelogs, dashboardRepo.Repository, dashboardRepo.Config, environment, fromTag.Sha, toTag.Sha, toTag.TaggedAt)
+		if err != nil {
+			return nil, fmt.Errorf("Could not get promoted commits for tag %s in repo %s/%s: %s", toTag.Name, owner, repo, err)
+		}
+		if promotion != nil {
+			promotions = append(promotions, *promotion)
+		}
+	}
+	return promotions, nil
+}
+
+// newDashboardPromotion fetches the commits between two shas, nil is returned
+// for monorepo services that none of the commits touch. Fetched commits are
+// kept in changelogs so that other services of the repo can reuse them.
+func (d *DashboardService) newDashboardPromotion(ctx context.Context, changelogs map[string]*[]scm.ScmCommit, repository scm.ScmRepository, repoConfig *DashboardRepoConfig, environment string, fromSha string, toSha string, promotedAt time.Time) (*DashboardPromotion, error) {
+	owner := repository.OwnerName
+	repo := repository.Name
+
+	changelogKey := fmt.Sprintf("%s/%s|%s|%s", owner, repo, fromSha, toSha)
+	changelog, found := changelogs[changelogKey]
+	if !found {
+		var err error
+		changelog, err = d.ScmService.GetChangelogForRefNames(ctx, owner, repo, fromSha, toSha)
+		if err != nil {
+			return nil, err
+		}
+		changelogs[changelogKey] = changelog
+	}
+
+	var commits []scm.ScmCommit
+	if changelog != nil {
+		commits = *changelog
+	}
+	if repoConfig.HasPaths() {
+		commits = d.filterCommitsByPaths(ctx, owner, repo, repoConfig, commits)
+		if len(commits) == 0 {
+			return nil, nil
+		}
+	}
+	commits, _ = repoConfig.Filters.Apply(commits)
+
+	return &DashboardPromotion{
+		Commits:     commits,
+		Config:      repoConfig,
+		Environment: environment,
+		FromSha:     fromSha,
+		PromotedAt:  promotedAt,
+		Repository:  repository,
+		ToSha:       toSha,
+	}, nil
+}
+
+func findRepoChangelog(repoChangelogs []DashboardRepoChangelog, repoChangelog DashboardRepoChangelog) *DashboardRepoChangelog {
+	for index := range repoChangelogs {
+		candidate := &repoChangelogs[index]
+		if candidate.Repository.OwnerName == repoChangelog.Repository.OwnerName &&
+			candidate.Repository.Name == repoChangelog.Repository.Name &&
+			candidate.Config.Name == repoChangelog.Config.Name {
+			return candidate
+		}
+	}
+	return nil
+}
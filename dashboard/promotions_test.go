@@ -0,0 +1,255 @@
+package dashboard_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	dashboard "github.com/lobsterdore/release-dash/dashboard"
+	mock_scm "github.com/lobsterdore/release-dash/mocks/scm"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+func TestGetDashboardPromotions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockRepo := scm.ScmRepository{HtmlUrl: "https://github.com/o/r", Name: "r", OwnerName: "o"}
+	mockConfig := &dashboard.DashboardRepoConfig{
+		EnvironmentTags: []string{"dev", "stg", "prd"},
+		Filters: dashboard.DashboardCommitFilters{
+			Exclude: dashboard.DashboardCommitFilter{Authors: []string{"dependabot"}},
+		},
+		Name: "app",
+	}
+	mockNewRepo := scm.ScmRepository{Name: "new", OwnerName: "o"}
+	mockNewConfig := &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"dev"}, Name: "new"}
+
+	previous := []dashboard.DashboardRepoChangelog{{
+		Config: mockConfig,
+		EnvironmentRefs: map[string]scm.ScmRef{
+			"dev": {CurrentHash: "d1", Name: "dev"},
+			"stg": {CurrentHash: "s1", Name: "stg"},
+			"prd": {CurrentHash: "p1", Name: "prd"},
+		},
+		Repository: mockRepo,
+	}}
+	current := []dashboard.DashboardRepoChangelog{
+		{
+			Config: mockConfig,
+			EnvironmentRefs: map[string]scm.ScmRef{
+				"dev": {CurrentHash: "d2", Name: "dev"},
+				"stg": {CurrentHash: "s1", Name: "stg"},
+				"prd": {CurrentHash: "p2", Name: "prd"},
+			},
+			Repository: mockRepo,
+		},
+		{
+			Config:          mockNewConfig,
+			EnvironmentRefs: map[string]scm.ScmRef{"dev": {CurrentHash: "n1", Name: "dev"}},
+			Repository:      mockNewRepo,
+		},
+	}
+	mockCommits := []scm.ScmCommit{
+		{Message: "add refunds", Sha: "d2"},
+		{AuthorLogin: "dependabot[bot]", Message: "bump", Sha: "b"},
+	}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetChangelogForRefNames(mockCtx, "o", "r", "d1", "d2").
+		Times(1).
+		Return(&mockCommits, nil)
+	mockScm.
+		EXPECT().
+		GetChangelogForRefNames(mockCtx, "o", "r", "p1", "p2").
+		Times(1).
+		Return(nil, errors.New("error"))
+
+	promotedAt := time.Date(2020, time.October, 1, 12, 0, 0, 0, time.UTC)
+	promotions := dashboardService.GetDashboardPromotions(mockCtx, previous, current, promotedAt)
+
+	expectedPromotions := []dashboard.DashboardPromotion{{
+		Commits:     mockCommits[:1],
+		Config:      mockConfig,
+		Environment: "dev",
+		FromSha:     "d1",
+		PromotedAt:  promotedAt,
+		Repository:  mockRepo,
+		ToSha:       "d2",
+	}}
+
+	assert.Equal(t, expectedPromotions, promotions)
+	assert.Equal(t, "o/r/app/dev/d1/d2", promotions[0].Id())
+	assert.Equal(t, "https://github.com/o/r/compare/d1...d2", promotions[0].CompareHtmlUrl())
+}
+
+func TestPromotionIdEscapesParts(t *testing.T) {
+	assert.Equal(t, "o/r/api%2Fv2/prod/a/b", dashboard.PromotionId("o", "r", "api/v2", "prod", "a", "b"))
+	assert.NotEqual(t, dashboard.PromotionId("o", "r", "a/b", "c", "d", "e"), dashboard.PromotionId("o", "r", "a", "b/c", "d", "e"))
+}
+
+func TestGetDashboardPromotionsFiltersByPaths(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockRepo := scm.ScmRepository{Name: "r", OwnerName: "o"}
+	mockConfig := &dashboard.DashboardRepoConfig{
+		EnvironmentTags: []string{"dev"},
+		Name:            "api",
+		Paths:           []string{"services/api/"},
+	}
+	mockWebConfig := &dashboard.DashboardRepoConfig{
+		EnvironmentTags: []string{"dev"},
+		Name:            "web",
+		Paths:           []string{"services/web/"},
+	}
+
+	var previous, current []dashboard.DashboardRepoChangelog
+	for _, config := range []*dashboard.DashboardRepoConfig{mockConfig, mockWebConfig} {
+		previous = append(previous, dashboard.DashboardRepoChangelog{
+			Config:          config,
+			EnvironmentRefs: map[string]scm.ScmRef{"dev": {CurrentHash: "d1", Name: "dev"}},
+			Repository:      mockRepo,
+		})
+		current = append(current, dashboard.DashboardRepoChangelog{
+			Config:          config,
+			EnvironmentRefs: map[string]scm.ScmRef{"dev": {CurrentHash: "d2", Name: "dev"}},
+			Repository:      mockRepo,
+		})
+	}
+	mockCommits := []scm.ScmCommit{{Files: []string{"services/web/main.go"}, Message: "web change", Sha: "d2"}}
+
+	// Both services share the compare
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetChangelogForRefNames(mockCtx, "o", "r", "d1", "d2").
+		Times(1).
+		Return(&mockCommits, nil)
+
+	promotions := dashboardService.GetDashboardPromotions(mockCtx, previous, current, time.Now())
+
+	assert.Len(t, promotions, 1)
+	assert.Equal(t, "web", promotions[0].Config.Name)
+	assert.Equal(t, mockCommits, promotions[0].Commits)
+}
+
+func TestGetDashboardPromotionsNoPrevious(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	current := []dashboard.DashboardRepoChangelog{{
+		Config:          &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"dev"}, Name: "app"},
+		EnvironmentRefs: map[string]scm.ScmRef{"dev": {CurrentHash: "d1", Name: "dev"}},
+		Repository:      scm.ScmRepository{Name: "r", OwnerName: "o"},
+	}}
+
+	promotions := dashboardService.GetDashboardPromotions(context.Background(), nil, current, time.Now())
+
+	assert.Empty(t, promotions)
+}
+
+func TestGetDashboardTagPromotions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockRepo := scm.ScmRepository{HtmlUrl: "https://github.com/o/r", Name: "r", OwnerName: "o"}
+	mockConfig := &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"stg", "prod"}, Name: "app"}
+	mockDashboardRepo := dashboard.DashboardRepo{Config: mockConfig, Repository: mockRepo}
+
+	taggedAt := time.Date(2020, time.October, 1, 12, 0, 0, 0, time.UTC)
+	mockTags := []scm.ScmTag{
+		{Name: "prod-1", Sha: "a", TaggedAt: taggedAt},
+		{Name: "prod-2", Sha: "b", TaggedAt: taggedAt.Add(24 * time.Hour)},
+		{Name: "prod-2-again", Sha: "b", TaggedAt: taggedAt.Add(25 * time.Hour)},
+		{Name: "prod-3", Sha: "c", TaggedAt: taggedAt.Add(48 * time.Hour)},
+	}
+	mockCommitsB := []scm.ScmCommit{{Message: "add refunds", Sha: "b"}}
+	mockCommitsC := []scm.ScmCommit{{Message: "fix refunds", Sha: "c"}}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetRepoTags(mockCtx, "o", "r", "prod-*").
+		Times(1).
+		Return(mockTags, nil)
+	mockScm.
+		EXPECT().
+		GetChangelogForRefNames(mockCtx, "o", "r", "a", "b").
+		Times(1).
+		Return(&mockCommitsB, nil)
+	mockScm.
+		EXPECT().
+		GetChangelogForRefNames(mockCtx, "o", "r", "b", "c").
+		Times(1).
+		Return(&mockCommitsC, nil)
+
+	promotions, err := dashboardService.GetDashboardTagPromotions(mockCtx, mockDashboardRepo, "prod", "prod-*")
+
+	expectedPromotions := []dashboard.DashboardPromotion{
+		{
+			Commits:     mockCommitsB,
+			Config:      mockConfig,
+			Environment: "prod",
+			FromSha:     "a",
+			PromotedAt:  taggedAt.Add(24 * time.Hour),
+			Repository:  mockRepo,
+			ToSha:       "b",
+		},
+		{
+			Commits:     mockCommitsC,
+			Config:      mockConfig,
+			Environment: "prod",
+			FromSha:     "b",
+			PromotedAt:  taggedAt.Add(48 * time.Hour),
+			Repository:  mockRepo,
+			ToSha:       "c",
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedPromotions, promotions)
+}
+
+func TestGetDashboardTagPromotionsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockConfig := &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"prod"}, Name: "app"}
+	mockDashboardRepo := dashboard.DashboardRepo{Config: mockConfig, Repository: scm.ScmRepository{Name: "r", OwnerName: "o"}}
+
+	mockTags := []scm.ScmTag{{Name: "prod-1", Sha: "a"}, {Name: "prod-2", Sha: "b"}}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetRepoTags(mockCtx, "o", "r", "prod-*").
+		Times(1).
+		Return(mockTags, nil)
+	mockScm.
+		EXPECT().
+		GetChangelogForRefNames(mockCtx, "o", "r", "a", "b").
+		Times(1).
+		Return(nil, errors.New("error"))
+
+	promotions, err := dashboardService.GetDashboardTagPromotions(mockCtx, mockDashboardRepo, "prod", "prod-*")
+
+	assert.Error(t, err)
+	assert.Nil(t, promotions)
+}
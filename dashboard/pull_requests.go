@@ -0,0 +1,94 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DisplayModeCommits      = "commits"
+	DisplayModePullRequests = "pull_requests"
+)
+
+type DashboardPullRequest struct {
+	scm.ScmPullRequest
+	CommitShas []string
+}
+
+func (c *DashboardRepoConfig) ShowPullRequests() bool {
+	return c.DisplayMode == DisplayModePullRequests
+}
+
+// DirectCommits returns commits that could not be matched to a merged pull
+// request, such as those pushed straight to a branch.
+func (c DashboardChangelogCommits) DirectCommits() []scm.ScmCommit {
+	pullRequestShas := map[string]bool{}
+	for _, pullRequest := range c.PullRequests {
+		for _, sha := range pullRequest.CommitShas {
+			pullRequestShas[sha] = true
+		}
+	}
+
+	var directCommits []scm.ScmCommit
+	for _, commit := range c.Commits {
+		if !pullRequestShas[commit.Sha] {
+			directCommits = append(directCommits, commit)
+		}
+	}
+	return directCommits
+}
+
+// getPullRequests finds the merged pull request behind each commit, pull
+// requests are deduplicated and kept in the order that they first appear.
+func (d *DashboardService) getPullRequests(ctx context.Context, owner string, repo string, commits []scm.ScmCommit) []DashboardPullRequest {
+	var pullRequests []DashboardPullRequest
+	pullRequestIndexes := map[int]int{}
+
+	for _, commit := range commits {
+		commitPullRequests, err := d.getCommitPullRequests(ctx, owner, repo, commit.Sha)
+		if err != nil {
+			log.Error().Err(err).Msgf("Could not get pull requests for commit %s in repo %s/%s", commit.Sha, owner, repo)
+			continue
+		}
+
+		for _, commitPullRequest := range commitPullRequests {
+			if !commitPullRequest.Merged {
+				continue
+			}
+
+			index, found := pullRequestIndexes[commitPullRequest.Number]
+			if !found {
+				pullRequests = append(pullRequests, DashboardPullRequest{ScmPullRequest: commitPullRequest})
+				index = len(pullRequests) - 1
+				pullRequestIndexes[commitPullRequest.Number] = index
+			}
+			pullRequests[index].CommitShas = append(pullRequests[index].CommitShas, commit.Sha)
+		}
+	}
+
+	return pullRequests
+}
+
+// getCommitPullRequests lists the pull requests behind a commit, these are
+// cached by sha as a commit that has been merged can't move to another one.
+func (d *DashboardService) getCommitPullRequests(ctx context.Context, owner string, repo string, sha string) ([]scm.ScmPullRequest, error) {
+	cacheKey := fmt.Sprintf("commit_pull_requests_%s/%s/%s", owner, repo, sha)
+	if d.CacheService != nil {
+		if cachedPullRequests, found := d.CacheService.Get(cacheKey); found {
+			return cachedPullRequests.([]scm.ScmPullRequest), nil
+		}
+	}
+
+	pullRequests, err := d.ScmService.GetCommitPullRequests(ctx, owner, repo, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.CacheService != nil {
+		d.CacheService.Set(cacheKey, pullRequests, commitCacheSeconds)
+	}
+	return pullRequests, nil
+}
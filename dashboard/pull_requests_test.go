@@ -0,0 +1,145 @@
+package dashboard_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/cache"
+	dashboard "github.com/lobsterdore/release-dash/dashboard"
+	mock_scm "github.com/lobsterdore/release-dash/mocks/scm"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+func TestGetDashboardChangelogsPullRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{
+		CacheService: cache.NewLocalCacheAdapter(60, 60),
+		ScmService:   mockScm,
+	}
+
+	mockOwner := "o"
+	mockRepoName := "r"
+	mockRepo := scm.ScmRepository{
+		DefaultBranch: "main",
+		Name:          mockRepoName,
+		OwnerName:     mockOwner,
+	}
+	mockCommitsCompare := []scm.ScmCommit{
+		{Message: "add refunds", Sha: "a"},
+		{Message: "fix refunds", Sha: "b"},
+		{Message: "hotfix", Sha: "c"},
+		{Message: "unknown", Sha: "d"},
+	}
+	mockPullRequest := scm.ScmPullRequest{Merged: true, Number: 12, Title: "Refunds"}
+	mockRepoConfig := &dashboard.DashboardRepoConfig{
+		DisplayMode:     dashboard.DisplayModePullRequests,
+		EnvironmentTags: []string{"dev", "stg"},
+		Name:            "app",
+	}
+
+	// Pull requests are cached by sha across refreshes, apart from errors
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetChangelogForRefs(mockCtx, mockOwner, mockRepoName, &scm.ScmRef{CurrentHash: "h"}, &scm.ScmRef{CurrentHash: "h"}).
+		Times(2).
+		Return(&mockCommitsCompare, nil)
+	mockScm.
+		EXPECT().
+		GetRepoTag(mockCtx, mockOwner, mockRepoName, gomock.Any()).
+		AnyTimes().
+		Return(&scm.ScmRef{CurrentHash: "h"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, mockOwner, mockRepoName, "h").
+		AnyTimes().
+		Return(&scm.ScmCommit{Sha: "h"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommitPullRequests(mockCtx, mockOwner, mockRepoName, "a").
+		Times(1).
+		Return([]scm.ScmPullRequest{mockPullRequest}, nil)
+	mockScm.
+		EXPECT().
+		GetCommitPullRequests(mockCtx, mockOwner, mockRepoName, "b").
+		Times(1).
+		Return([]scm.ScmPullRequest{mockPullRequest, {Merged: false, Number: 13}}, nil)
+	mockScm.
+		EXPECT().
+		GetCommitPullRequests(mockCtx, mockOwner, mockRepoName, "c").
+		Times(1).
+		Return(nil, nil)
+	mockScm.
+		EXPECT().
+		GetCommitPullRequests(mockCtx, mockOwner, mockRepoName, "d").
+		Times(2).
+		Return(nil, errors.New("error"))
+
+	expectedPullRequests := []dashboard.DashboardPullRequest{
+		{ScmPullRequest: mockPullRequest, CommitShas: []string{"a", "b"}},
+	}
+	for refresh := 0; refresh < 2; refresh++ {
+		repoChangelogs := dashboardService.GetDashboardChangelogs(mockCtx, []dashboard.DashboardRepo{{Config: mockRepoConfig, Repository: mockRepo}})
+
+		assert.Len(t, repoChangelogs, 1)
+		changelogCommits := repoChangelogs[0].ChangelogCommits[0]
+
+		assert.Equal(t, expectedPullRequests, changelogCommits.PullRequests)
+		assert.Equal(t, mockCommitsCompare[2:], changelogCommits.DirectCommits())
+	}
+}
+
+func TestGetDashboardChangelogsPullRequestsNotEnabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockRepo := scm.ScmRepository{Name: "r", OwnerName: "o"}
+	mockCommitsCompare := []scm.ScmCommit{{Message: "m", Sha: "a"}}
+	mockRepoConfig := &dashboard.DashboardRepoConfig{
+		EnvironmentTags: []string{"dev", "stg"},
+		Name:            "app",
+	}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetChangelogForRefs(mockCtx, "o", "r", &scm.ScmRef{CurrentHash: "h"}, &scm.ScmRef{CurrentHash: "h"}).
+		Times(1).
+		Return(&mockCommitsCompare, nil)
+	mockScm.
+		EXPECT().
+		GetRepoTag(mockCtx, "o", "r", gomock.Any()).
+		Times(2).
+		Return(&scm.ScmRef{CurrentHash: "h"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, "o", "r", "h").
+		AnyTimes().
+		Return(&scm.ScmCommit{Sha: "h"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommitPullRequests(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	repoChangelogs := dashboardService.GetDashboardChangelogs(mockCtx, []dashboard.DashboardRepo{{Config: mockRepoConfig, Repository: mockRepo}})
+
+	assert.Nil(t, repoChangelogs[0].ChangelogCommits[0].PullRequests)
+	assert.Equal(t, mockCommitsCompare, repoChangelogs[0].ChangelogCommits[0].DirectCommits())
+}
+
+func TestNewDashboardRepoConfigDisplayMode(t *testing.T) {
+	repoConfig, err := dashboard.NewDashboardRepoConfig([]byte("display_mode: pull_requests\nenvironment_tags: [dev, stg]\n"))
+	assert.NoError(t, err)
+	assert.True(t, repoConfig.ShowPullRequests())
+
+	_, err = dashboard.NewDashboardRepoConfig([]byte("display_mode: tickets\nenvironment_tags: [dev, stg]\n"))
+	assert.Error(t, err)
+}
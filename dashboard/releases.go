@@ -0,0 +1,37 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/rs/zerolog/log"
+)
+
+// CreateDashboardRelease creates a draft release for a new tag, the tag will
+// point at the commit that ref currently resolves to once the release is
+// published.
+func (d *DashboardService) CreateDashboardRelease(ctx context.Context, dashboardRepo DashboardRepo, ref string, tagName string, body string) (*scm.ScmRelease, error) {
+	if d.ReleaseScmService == nil {
+		return nil, fmt.Errorf("Releases are not enabled")
+	}
+
+	owner := dashboardRepo.Repository.OwnerName
+	repo := dashboardRepo.Repository.Name
+
+	commit, err := d.ScmService.GetCommit(ctx, owner, repo, ref)
+	if err != nil {
+		return nil, fmt.Errorf("Could not resolve ref %s: %s", ref, err)
+	}
+
+	log.Debug().Msgf("Creating draft release %s for repo %s/%s at %s", tagName, owner, repo, commit.Sha)
+
+	release := scm.ScmRelease{
+		Body:            body,
+		Draft:           true,
+		Name:            tagName,
+		TagName:         tagName,
+		TargetCommitish: commit.Sha,
+	}
+	return d.ReleaseScmService.CreateRelease(ctx, owner, repo, release)
+}
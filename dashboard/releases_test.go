@@ -0,0 +1,90 @@
+package dashboard_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	dashboard "github.com/lobsterdore/release-dash/dashboard"
+	mock_scm "github.com/lobsterdore/release-dash/mocks/scm"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+func TestCreateDashboardRelease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	mockReleaseScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ReleaseScmService: mockReleaseScm, ScmService: mockScm}
+
+	mockDashboardRepo := dashboard.DashboardRepo{
+		Config:     &dashboard.DashboardRepoConfig{Name: "app"},
+		Repository: scm.ScmRepository{Name: "r", OwnerName: "o"},
+	}
+	mockRelease := scm.ScmRelease{
+		Body:            "notes",
+		Draft:           true,
+		Name:            "v1.2.0",
+		TagName:         "v1.2.0",
+		TargetCommitish: "s",
+	}
+	mockCreatedRelease := mockRelease
+	mockCreatedRelease.HtmlUrl = "https://github.com/o/r/releases/tag/untagged-1"
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, "o", "r", "stg").
+		Times(1).
+		Return(&scm.ScmCommit{Sha: "s"}, nil)
+	mockReleaseScm.
+		EXPECT().
+		CreateRelease(mockCtx, "o", "r", mockRelease).
+		Times(1).
+		Return(&mockCreatedRelease, nil)
+
+	release, err := dashboardService.CreateDashboardRelease(mockCtx, mockDashboardRepo, "stg", "v1.2.0", "notes")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &mockCreatedRelease, release)
+}
+
+func TestCreateDashboardReleaseBadRef(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	mockReleaseScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ReleaseScmService: mockReleaseScm, ScmService: mockScm}
+
+	mockDashboardRepo := dashboard.DashboardRepo{
+		Config:     &dashboard.DashboardRepoConfig{Name: "app"},
+		Repository: scm.ScmRepository{Name: "r", OwnerName: "o"},
+	}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, "o", "r", "stg").
+		Times(1).
+		Return(nil, errors.New("error"))
+
+	release, err := dashboardService.CreateDashboardRelease(mockCtx, mockDashboardRepo, "stg", "v1.2.0", "notes")
+
+	assert.Error(t, err)
+	assert.Nil(t, release)
+}
+
+func TestCreateDashboardReleaseNotEnabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	release, err := dashboardService.CreateDashboardRelease(context.Background(), dashboard.DashboardRepo{}, "stg", "v1.2.0", "notes")
+
+	assert.Error(t, err)
+	assert.Nil(t, release)
+}
@@ -0,0 +1,105 @@
+package dashboard
+
+import (
+	"strings"
+
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+// DashboardSearchResult is a pending commit that matched a search, along with
+// each pair of environments it is still waiting in.
+type DashboardSearchResult struct {
+	Commit scm.ScmCommit
+	Config *DashboardRepoConfig
+	// Group is the commit group the commit is shown under on the dashboard
+	Group      string
+	Pending    []DashboardSearchPending
+	Repository scm.ScmRepository
+	Tickets    []DashboardTicket
+}
+
+type DashboardSearchPending struct {
+	FromRef string
+	ToRef   string
+}
+
+// SearchDashboardRepoChangelogs finds pending commits where every term of the
+// query matches the message, author, sha prefix or a ticket key of the commit,
+// terms are compared without case. Results keep the order of the changelogs.
+func SearchDashboardRepoChangelogs(repoChangelogs []DashboardRepoChangelog, query string) []DashboardSearchResult {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var results []DashboardSearchResult
+	for _, repoChangelog := range repoChangelogs {
+		resultIndexes := map[string]int{}
+		for _, changelogCommits := range repoChangelog.ChangelogCommits {
+			for _, commit := range changelogCommits.Commits {
+				pending := DashboardSearchPending{FromRef: changelogCommits.FromRef, ToRef: changelogCommits.ToRef}
+				if resultIndex, found := resultIndexes[commit.Sha]; found {
+					results[resultIndex].Pending = append(results[resultIndex].Pending, pending)
+					continue
+				}
+
+				tickets := commitTickets(commit, changelogCommits.Tickets)
+				if !matchesSearchTerms(terms, commit, tickets) {
+					continue
+				}
+
+				resultIndexes[commit.Sha] = len(results)
+				results = append(results, DashboardSearchResult{
+					Commit:     commit,
+					Config:     repoChangelog.Config,
+					Group:      commitGroupName(commit),
+					Pending:    []DashboardSearchPending{pending},
+					Repository: repoChangelog.Repository,
+					Tickets:    tickets,
+				})
+			}
+		}
+	}
+	return results
+}
+
+func commitTickets(commit scm.ScmCommit, tickets []DashboardTicket) []DashboardTicket {
+	var foundTickets []DashboardTicket
+	for _, ticket := range tickets {
+		for _, commitSha := range ticket.CommitShas {
+			if commitSha == commit.Sha {
+				foundTickets = append(foundTickets, ticket)
+				break
+			}
+		}
+	}
+	return foundTickets
+}
+
+func matchesSearchTerms(terms []string, commit scm.ScmCommit, tickets []DashboardTicket) bool {
+	message := strings.ToLower(commit.Message)
+	authorLogin := strings.ToLower(commit.AuthorLogin)
+	authorName := strings.ToLower(commit.AuthorName)
+	sha := strings.ToLower(commit.Sha)
+
+	for _, term := range terms {
+		if strings.Contains(message, term) ||
+			strings.Contains(authorLogin, term) ||
+			strings.Contains(authorName, term) ||
+			strings.HasPrefix(sha, term) ||
+			matchesTicketKey(term, tickets) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func matchesTicketKey(term string, tickets []DashboardTicket) bool {
+	for _, ticket := range tickets {
+		if strings.EqualFold(ticket.Key, term) {
+			return true
+		}
+	}
+	return false
+}
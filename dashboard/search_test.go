@@ -0,0 +1,98 @@
+package dashboard_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dashboard "github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+var mockSearchCommits = []scm.ScmCommit{
+	{AuthorLogin: "dev", Message: "fix: rounding of refunds", Sha: "3e0f3d8c432ca2a03a3222fb55de63934338022f"},
+	{AuthorName: "Ops Person", Message: "chore: bump base image", Sha: "812b303948b570247b727aeb8c1b187336ad4256"},
+	{AuthorLogin: "dev", Message: "feat: add invoices", Sha: "a7e2f18f4b0e4a2fd8a5b5fa6f4e5cd1e1b8c2d3"},
+}
+
+var mockSearchTickets = []dashboard.DashboardTicket{
+	{CommitShas: []string{mockSearchCommits[2].Sha}, Key: "PAY-12", Url: "https://jira/browse/PAY-12"},
+}
+
+var mockSearchChangelogs = []dashboard.DashboardRepoChangelog{
+	{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{
+			{Commits: mockSearchCommits, FromRef: "stg", Tickets: mockSearchTickets, ToRef: "dev"},
+			{Commits: mockSearchCommits[1:], FromRef: "prod", Tickets: mockSearchTickets, ToRef: "stg"},
+		},
+		Config:     &dashboard.DashboardRepoConfig{Name: "payments"},
+		Repository: scm.ScmRepository{Name: "payments", OwnerName: "acme"},
+	},
+	{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{
+			{Commits: mockSearchCommits[:1], FromRef: "prod", ToRef: "stg"},
+		},
+		Config:     &dashboard.DashboardRepoConfig{Name: "web"},
+		Repository: scm.ScmRepository{Name: "web", OwnerName: "acme"},
+	},
+}
+
+func TestSearchDashboardRepoChangelogsEmptyQuery(t *testing.T) {
+	assert.Empty(t, dashboard.SearchDashboardRepoChangelogs(mockSearchChangelogs, " "))
+}
+
+func TestSearchDashboardRepoChangelogsMessage(t *testing.T) {
+	results := dashboard.SearchDashboardRepoChangelogs(mockSearchChangelogs, "Refunds")
+
+	assert.Equal(t, []dashboard.DashboardSearchResult{
+		{
+			Commit:     mockSearchCommits[0],
+			Config:     mockSearchChangelogs[0].Config,
+			Group:      dashboard.CommitGroupFixes,
+			Pending:    []dashboard.DashboardSearchPending{{FromRef: "stg", ToRef: "dev"}},
+			Repository: mockSearchChangelogs[0].Repository,
+		},
+		{
+			Commit:     mockSearchCommits[0],
+			Config:     mockSearchChangelogs[1].Config,
+			Group:      dashboard.CommitGroupFixes,
+			Pending:    []dashboard.DashboardSearchPending{{FromRef: "prod", ToRef: "stg"}},
+			Repository: mockSearchChangelogs[1].Repository,
+		},
+	}, results)
+}
+
+func TestSearchDashboardRepoChangelogsTicket(t *testing.T) {
+	results := dashboard.SearchDashboardRepoChangelogs(mockSearchChangelogs, "pay-12")
+
+	assert.Equal(t, []dashboard.DashboardSearchResult{
+		{
+			Commit: mockSearchCommits[2],
+			Config: mockSearchChangelogs[0].Config,
+			Group:  dashboard.CommitGroupFeatures,
+			Pending: []dashboard.DashboardSearchPending{
+				{FromRef: "stg", ToRef: "dev"},
+				{FromRef: "prod", ToRef: "stg"},
+			},
+			Repository: mockSearchChangelogs[0].Repository,
+			Tickets:    mockSearchTickets,
+		},
+	}, results)
+}
+
+func TestSearchDashboardRepoChangelogsTerms(t *testing.T) {
+	results := dashboard.SearchDashboardRepoChangelogs(mockSearchChangelogs, "812b303 ops")
+	assert.Len(t, results, 1)
+	assert.Equal(t, mockSearchCommits[1], results[0].Commit)
+	assert.Len(t, results[0].Pending, 2)
+
+	results = dashboard.SearchDashboardRepoChangelogs(mockSearchChangelogs, "dev invoices")
+	assert.Len(t, results, 1)
+	assert.Equal(t, mockSearchCommits[2], results[0].Commit)
+
+	results = dashboard.SearchDashboardRepoChangelogs(mockSearchChangelogs, "ops invoices")
+	assert.Empty(t, results)
+
+	results = dashboard.SearchDashboardRepoChangelogs(mockSearchChangelogs, "2f")
+	assert.Empty(t, results)
+}
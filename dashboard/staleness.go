@@ -0,0 +1,133 @@
+package dashboard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lobsterdore/release-dash/config"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+const (
+	StalenessAmber = "amber"
+	StalenessNone  = ""
+	StalenessRed   = "red"
+)
+
+// DashboardStalenessConfig sets when pending changes are overdue for release,
+// ages are either durations such as 36h or a number of days such as 3d. Any
+// threshold left unset, an empty age or a nil commit count, falls back to the
+// global threshold and a threshold of zero is never reached, so a repo can
+// turn off a global threshold by setting it to 0.
+type DashboardStalenessConfig struct {
+	AmberAge     string `json:"amber_age" yaml:"amber_age"`
+	AmberCommits *int   `json:"amber_commits" yaml:"amber_commits"`
+	RedAge       string `json:"red_age" yaml:"red_age"`
+	RedCommits   *int   `json:"red_commits" yaml:"red_commits"`
+}
+
+func NewDashboardStalenessConfig(cfg config.Config) DashboardStalenessConfig {
+	amberCommits := cfg.Dashboard.StalenessAmberCommits
+	redCommits := cfg.Dashboard.StalenessRedCommits
+	return DashboardStalenessConfig{
+		AmberAge:     cfg.Dashboard.StalenessAmberAge,
+		AmberCommits: &amberCommits,
+		RedAge:       cfg.Dashboard.StalenessRedAge,
+		RedCommits:   &redCommits,
+	}
+}
+
+func (c DashboardStalenessConfig) Validate() error {
+	for _, age := range []string{c.AmberAge, c.RedAge} {
+		if _, err := parseStalenessAge(age); err != nil {
+			return err
+		}
+	}
+	if (c.AmberCommits != nil && *c.AmberCommits < 0) || (c.RedCommits != nil && *c.RedCommits < 0) {
+		return fmt.Errorf("Staleness commit thresholds can not be negative")
+	}
+	return nil
+}
+
+// WithDefaults fills any unset thresholds from the defaults.
+func (c DashboardStalenessConfig) WithDefaults(defaults DashboardStalenessConfig) DashboardStalenessConfig {
+	if c.AmberAge == "" {
+		c.AmberAge = defaults.AmberAge
+	}
+	if c.AmberCommits == nil {
+		c.AmberCommits = defaults.AmberCommits
+	}
+	if c.RedAge == "" {
+		c.RedAge = defaults.RedAge
+	}
+	if c.RedCommits == nil {
+		c.RedCommits = defaults.RedCommits
+	}
+	return c
+}
+
+// Level works out how overdue the pending commits are from the age of the
+// oldest commit and the number of commits.
+func (c DashboardStalenessConfig) Level(commits []scm.ScmCommit, now time.Time) string {
+	if len(commits) == 0 {
+		return StalenessNone
+	}
+
+	var age time.Duration
+	if oldest := oldestCommit(commits); oldest != nil {
+		age = now.Sub(oldest.AuthoredAt)
+	}
+
+	if stalenessReached(c.RedAge, c.RedCommits, age, len(commits)) {
+		return StalenessRed
+	}
+	if stalenessReached(c.AmberAge, c.AmberCommits, age, len(commits)) {
+		return StalenessAmber
+	}
+	return StalenessNone
+}
+
+// Staleness returns the most overdue level of the environment pairs.
+func (d DashboardRepoChangelog) Staleness() string {
+	staleness := StalenessNone
+	for _, changelogCommits := range d.ChangelogCommits {
+		switch changelogCommits.Staleness {
+		case StalenessRed:
+			return StalenessRed
+		case StalenessAmber:
+			staleness = StalenessAmber
+		}
+	}
+	return staleness
+}
+
+func stalenessReached(maxAge string, maxCommits *int, age time.Duration, commits int) bool {
+	if maxCommits != nil && *maxCommits > 0 && commits >= *maxCommits {
+		return true
+	}
+	maxAgeDuration, err := parseStalenessAge(maxAge)
+	if err != nil {
+		return false
+	}
+	return maxAgeDuration > 0 && age >= maxAgeDuration
+}
+
+func parseStalenessAge(age string) (time.Duration, error) {
+	if age == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(age, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("Could not parse staleness age %s", age)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	duration, err := time.ParseDuration(age)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("Could not parse staleness age %s", age)
+	}
+	return duration, nil
+}
@@ -0,0 +1,170 @@
+package dashboard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/config"
+	dashboard "github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/scm"
+
+	mock_scm "github.com/lobsterdore/release-dash/mocks/scm"
+)
+
+func newStalenessCommits(commits int) *int {
+	return &commits
+}
+
+func TestDashboardStalenessConfigLevel(t *testing.T) {
+	now := time.Date(2021, 6, 10, 10, 0, 0, 0, time.UTC)
+	stalenessConfig := dashboard.DashboardStalenessConfig{
+		AmberAge:     "3d",
+		AmberCommits: newStalenessCommits(5),
+		RedAge:       "168h",
+		RedCommits:   newStalenessCommits(10),
+	}
+
+	newCommits := func(count int, authoredAt time.Time) []scm.ScmCommit {
+		commits := make([]scm.ScmCommit, count)
+		for index := range commits {
+			commits[index].AuthoredAt = authoredAt
+		}
+		return commits
+	}
+
+	assert.Equal(t, dashboard.StalenessNone, stalenessConfig.Level(nil, now))
+	assert.Equal(t, dashboard.StalenessNone, stalenessConfig.Level(newCommits(1, now.Add(-48*time.Hour)), now))
+	assert.Equal(t, dashboard.StalenessAmber, stalenessConfig.Level(newCommits(1, now.Add(-72*time.Hour)), now))
+	assert.Equal(t, dashboard.StalenessAmber, stalenessConfig.Level(newCommits(5, now), now))
+	assert.Equal(t, dashboard.StalenessRed, stalenessConfig.Level(newCommits(1, now.Add(-8*24*time.Hour)), now))
+	assert.Equal(t, dashboard.StalenessRed, stalenessConfig.Level(newCommits(10, now), now))
+	// Commits without an author date only count towards the commit thresholds
+	assert.Equal(t, dashboard.StalenessNone, stalenessConfig.Level(newCommits(1, time.Time{}), now))
+	assert.Equal(t, dashboard.StalenessNone, dashboard.DashboardStalenessConfig{}.Level(newCommits(50, now.Add(-1000*time.Hour)), now))
+}
+
+func TestDashboardStalenessConfigValidate(t *testing.T) {
+	assert.NoError(t, dashboard.DashboardStalenessConfig{}.Validate())
+	assert.NoError(t, dashboard.DashboardStalenessConfig{AmberAge: "36h", RedAge: "5d"}.Validate())
+	assert.EqualError(t, dashboard.DashboardStalenessConfig{AmberAge: "three days"}.Validate(), "Could not parse staleness age three days")
+	assert.EqualError(t, dashboard.DashboardStalenessConfig{RedAge: "-1d"}.Validate(), "Could not parse staleness age -1d")
+	assert.Error(t, dashboard.DashboardStalenessConfig{RedCommits: newStalenessCommits(-1)}.Validate())
+}
+
+func TestDashboardStalenessConfigWithDefaults(t *testing.T) {
+	defaults := dashboard.DashboardStalenessConfig{AmberAge: "3d", AmberCommits: newStalenessCommits(5), RedAge: "7d"}
+
+	stalenessConfig := dashboard.DashboardStalenessConfig{AmberAge: "1d", RedCommits: newStalenessCommits(20)}.WithDefaults(defaults)
+
+	assert.Equal(t, dashboard.DashboardStalenessConfig{AmberAge: "1d", AmberCommits: newStalenessCommits(5), RedAge: "7d", RedCommits: newStalenessCommits(20)}, stalenessConfig)
+
+	// Thresholds set to 0 in the repo config turn off the global thresholds
+	stalenessConfig = dashboard.DashboardStalenessConfig{AmberAge: "0", AmberCommits: newStalenessCommits(0)}.WithDefaults(defaults)
+
+	assert.Equal(t, dashboard.DashboardStalenessConfig{AmberAge: "0", AmberCommits: newStalenessCommits(0), RedAge: "7d"}, stalenessConfig)
+	assert.Equal(t, dashboard.StalenessNone, stalenessConfig.Level([]scm.ScmCommit{{AuthoredAt: time.Now().Add(-5 * 24 * time.Hour)}}, time.Now()))
+}
+
+func TestDashboardRepoChangelogStaleness(t *testing.T) {
+	repoChangelog := dashboard.DashboardRepoChangelog{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{{}, {Staleness: dashboard.StalenessAmber}},
+	}
+	assert.Equal(t, dashboard.StalenessAmber, repoChangelog.Staleness())
+
+	repoChangelog.ChangelogCommits[0].Staleness = dashboard.StalenessRed
+	assert.Equal(t, dashboard.StalenessRed, repoChangelog.Staleness())
+
+	assert.Equal(t, dashboard.StalenessNone, dashboard.DashboardRepoChangelog{}.Staleness())
+}
+
+func TestNewDashboardRepoConfigStaleness(t *testing.T) {
+	content := []byte("---\nenvironment_tags: [dev, prd]\nstaleness:\n  amber_age: 1d\n  amber_commits: 0\n  red_commits: 20\n")
+
+	repoConfig, err := dashboard.NewDashboardRepoConfig(content)
+
+	assert.NoError(t, err)
+	assert.Equal(t, dashboard.DashboardStalenessConfig{AmberAge: "1d", AmberCommits: newStalenessCommits(0), RedCommits: newStalenessCommits(20)}, repoConfig.Staleness)
+
+	content = []byte("---\nenvironment_tags: [dev, prd]\nstaleness:\n  red_age: soon\n")
+
+	repoConfig, err = dashboard.NewDashboardRepoConfig(content)
+
+	assert.EqualError(t, err, "Could not parse staleness age soon")
+	assert.Nil(t, repoConfig)
+}
+
+func TestGetDashboardChangelogForRefsStaleness(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	mockConfig := config.Config{}
+	mockConfig.Dashboard.StalenessAmberAge = "3d"
+	mockConfig.Dashboard.StalenessRedAge = "7d"
+	dashboardService := dashboard.DashboardService{Config: mockConfig, ScmService: mockScm}
+
+	mockCommits := []scm.ScmCommit{
+		{AuthoredAt: time.Now().Add(-4 * 24 * time.Hour), Message: "feat: add refunds", Sha: "a"},
+		{AuthoredAt: time.Now(), Message: "fix: rounding", Sha: "b"},
+	}
+	mockDashboardRepo := dashboard.DashboardRepo{
+		Config: &dashboard.DashboardRepoConfig{
+			EnvironmentTags: []string{"dev", "stg"},
+			Name:            "app",
+		},
+		Repository: scm.ScmRepository{Name: "r", OwnerName: "o"},
+	}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetChangelogForRefNames(mockCtx, "o", "r", "stg", "dev").
+		Times(2).
+		Return(&mockCommits, nil)
+
+	changelogCommits, err := dashboardService.GetDashboardChangelogForRefs(mockCtx, mockDashboardRepo, "stg", "dev")
+
+	assert.NoError(t, err)
+	assert.Equal(t, dashboard.StalenessAmber, changelogCommits.Staleness)
+
+	// Repo thresholds take precedence over the global thresholds
+	mockDashboardRepo.Config.Staleness = dashboard.DashboardStalenessConfig{RedCommits: newStalenessCommits(2)}
+
+	changelogCommits, err = dashboardService.GetDashboardChangelogForRefs(mockCtx, mockDashboardRepo, "stg", "dev")
+
+	assert.NoError(t, err)
+	assert.Equal(t, dashboard.StalenessRed, changelogCommits.Staleness)
+}
+
+func TestGetDashboardChangelogForRefsStalenessDisabledByDefault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockCommits := []scm.ScmCommit{
+		{AuthoredAt: time.Now().Add(-100 * 24 * time.Hour), Message: "feat: add refunds", Sha: "a"},
+	}
+	mockDashboardRepo := dashboard.DashboardRepo{
+		Config: &dashboard.DashboardRepoConfig{
+			EnvironmentTags: []string{"dev", "stg"},
+			Name:            "app",
+		},
+		Repository: scm.ScmRepository{Name: "r", OwnerName: "o"},
+	}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetChangelogForRefNames(mockCtx, "o", "r", "stg", "dev").
+		Times(1).
+		Return(&mockCommits, nil)
+
+	changelogCommits, err := dashboardService.GetDashboardChangelogForRefs(mockCtx, mockDashboardRepo, "stg", "dev")
+
+	assert.NoError(t, err)
+	assert.Equal(t, dashboard.StalenessNone, changelogCommits.Staleness)
+}
@@ -0,0 +1,54 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/rs/zerolog/log"
+)
+
+func (d *DashboardService) getCommitStatus(ctx context.Context, owner string, repo string, ref string) *scm.ScmCommitStatus {
+	status, err := d.ScmService.GetCommitStatus(ctx, owner, repo, ref)
+	if err != nil {
+		log.Error().Err(err).Msgf("Could not get status for ref %s in repo %s/%s", ref, owner, repo)
+		return nil
+	}
+	return status
+}
+
+// failedStatusCacheSeconds is kept short as failed checks are often re-run
+// against the same commit.
+const failedStatusCacheSeconds = "600"
+
+// getCommitStatusForSha caches statuses that have finished by sha, pending
+// statuses and commits without any checks are fetched again on each refresh.
+func (d *DashboardService) getCommitStatusForSha(ctx context.Context, owner string, repo string, sha string) *scm.ScmCommitStatus {
+	cacheKey := fmt.Sprintf("commit_status_%s/%s/%s", owner, repo, sha)
+	if d.CacheService != nil {
+		if cachedStatus, found := d.CacheService.Get(cacheKey); found {
+			return cachedStatus.(*scm.ScmCommitStatus)
+		}
+	}
+
+	status := d.getCommitStatus(ctx, owner, repo, sha)
+	if status != nil && d.CacheService != nil {
+		switch status.State {
+		case scm.CommitStatusSuccess:
+			d.CacheService.Set(cacheKey, status, commitCacheSeconds)
+		case scm.CommitStatusFailure:
+			d.CacheService.Set(cacheKey, status, failedStatusCacheSeconds)
+		}
+	}
+	return status
+}
+
+func (d *DashboardService) getCommitStatuses(ctx context.Context, owner string, repo string, commits []scm.ScmCommit) map[string]scm.ScmCommitStatus {
+	commitStatuses := map[string]scm.ScmCommitStatus{}
+	for _, commit := range commits {
+		if status := d.getCommitStatusForSha(ctx, owner, repo, commit.Sha); status != nil {
+			commitStatuses[commit.Sha] = *status
+		}
+	}
+	return commitStatuses
+}
@@ -0,0 +1,193 @@
+package dashboard_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/cache"
+	dashboard "github.com/lobsterdore/release-dash/dashboard"
+	mock_scm "github.com/lobsterdore/release-dash/mocks/scm"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+func TestGetDashboardChangelogsCommitStatuses(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+	dashboardService.Config.Dashboard.CiStatus = true
+
+	mockOwner := "o"
+	mockRepoName := "r"
+	mockRepo := scm.ScmRepository{Name: mockRepoName, OwnerName: mockOwner}
+	mockCommitsCompare := []scm.ScmCommit{
+		{Message: "add refunds", Sha: "a"},
+		{Message: "fix refunds", Sha: "b"},
+		{Message: "unknown", Sha: "c"},
+	}
+	mockRefStatus := scm.ScmCommitStatus{State: scm.CommitStatusPending}
+	mockFailingStatus := scm.ScmCommitStatus{
+		FailureName: "lint",
+		FailureUrl:  "https://github.com/o/r/runs/1",
+		State:       scm.CommitStatusFailure,
+	}
+	mockPassingStatus := scm.ScmCommitStatus{State: scm.CommitStatusSuccess}
+	mockRepoConfig := &dashboard.DashboardRepoConfig{
+		CommitStatuses:  true,
+		EnvironmentTags: []string{"dev", "stg"},
+		Name:            "app",
+	}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetChangelogForRefs(mockCtx, mockOwner, mockRepoName, &scm.ScmRef{CurrentHash: "h"}, &scm.ScmRef{CurrentHash: "h"}).
+		Times(1).
+		Return(&mockCommitsCompare, nil)
+	mockScm.
+		EXPECT().
+		GetRepoTag(mockCtx, mockOwner, mockRepoName, gomock.Any()).
+		Times(2).
+		Return(&scm.ScmRef{CurrentHash: "h"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, mockOwner, mockRepoName, "h").
+		AnyTimes().
+		Return(&scm.ScmCommit{Sha: "h"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommitStatus(mockCtx, mockOwner, mockRepoName, "h").
+		Times(1).
+		Return(&mockRefStatus, nil)
+	mockScm.
+		EXPECT().
+		GetCommitStatus(mockCtx, mockOwner, mockRepoName, "a").
+		Times(1).
+		Return(&mockFailingStatus, nil)
+	mockScm.
+		EXPECT().
+		GetCommitStatus(mockCtx, mockOwner, mockRepoName, "b").
+		Times(1).
+		Return(&mockPassingStatus, nil)
+	mockScm.
+		EXPECT().
+		GetCommitStatus(mockCtx, mockOwner, mockRepoName, "c").
+		Times(1).
+		Return(nil, errors.New("error"))
+
+	repoChangelogs := dashboardService.GetDashboardChangelogs(mockCtx, []dashboard.DashboardRepo{{Config: mockRepoConfig, Repository: mockRepo}})
+
+	changelogCommits := repoChangelogs[0].ChangelogCommits[0]
+
+	expectedCommitStatuses := map[string]scm.ScmCommitStatus{
+		"a": mockFailingStatus,
+		"b": mockPassingStatus,
+	}
+	assert.Equal(t, &mockRefStatus, changelogCommits.Status)
+	assert.Equal(t, expectedCommitStatuses, changelogCommits.CommitStatuses)
+}
+
+func TestGetDashboardChangelogsCiStatusDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{ScmService: mockScm}
+
+	mockRepo := scm.ScmRepository{Name: "r", OwnerName: "o"}
+	mockCommitsCompare := []scm.ScmCommit{{Message: "m", Sha: "a"}}
+	mockRepoConfig := &dashboard.DashboardRepoConfig{
+		CommitStatuses:  true,
+		EnvironmentTags: []string{"dev", "stg"},
+		Name:            "app",
+	}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetChangelogForRefs(mockCtx, "o", "r", &scm.ScmRef{CurrentHash: "h"}, &scm.ScmRef{CurrentHash: "h"}).
+		Times(1).
+		Return(&mockCommitsCompare, nil)
+	mockScm.
+		EXPECT().
+		GetRepoTag(mockCtx, "o", "r", gomock.Any()).
+		Times(2).
+		Return(&scm.ScmRef{CurrentHash: "h"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, "o", "r", "h").
+		AnyTimes().
+		Return(&scm.ScmCommit{Sha: "h"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommitStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	repoChangelogs := dashboardService.GetDashboardChangelogs(mockCtx, []dashboard.DashboardRepo{{Config: mockRepoConfig, Repository: mockRepo}})
+
+	assert.Nil(t, repoChangelogs[0].ChangelogCommits[0].Status)
+	assert.Nil(t, repoChangelogs[0].ChangelogCommits[0].CommitStatuses)
+}
+
+func TestGetDashboardChangelogsCachesFinishedStatuses(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockScm := mock_scm.NewMockScmAdapter(ctrl)
+	dashboardService := dashboard.DashboardService{
+		CacheService: cache.NewLocalCacheAdapter(60, 60),
+		ScmService:   mockScm,
+	}
+	dashboardService.Config.Dashboard.CiStatus = true
+
+	mockRepo := scm.ScmRepository{Name: "r", OwnerName: "o"}
+	mockCommitsCompare := []scm.ScmCommit{{Message: "passed", Sha: "a"}, {Message: "running", Sha: "b"}}
+	mockRepoConfig := &dashboard.DashboardRepoConfig{
+		CommitStatuses:  true,
+		EnvironmentTags: []string{"dev", "stg"},
+		Name:            "app",
+	}
+
+	mockCtx := context.Background()
+	mockScm.
+		EXPECT().
+		GetChangelogForRefs(mockCtx, "o", "r", gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(&mockCommitsCompare, nil)
+	mockScm.
+		EXPECT().
+		GetRepoTag(mockCtx, "o", "r", "dev").
+		Times(2).
+		Return(&scm.ScmRef{CurrentHash: "b"}, nil)
+	mockScm.
+		EXPECT().
+		GetRepoTag(mockCtx, "o", "r", "stg").
+		Times(2).
+		Return(&scm.ScmRef{CurrentHash: "s"}, nil)
+	mockScm.
+		EXPECT().
+		GetCommit(mockCtx, "o", "r", gomock.Any()).
+		AnyTimes().
+		Return(&scm.ScmCommit{}, nil)
+	mockScm.
+		EXPECT().
+		GetCommitStatus(mockCtx, "o", "r", "a").
+		Times(1).
+		Return(&scm.ScmCommitStatus{State: scm.CommitStatusSuccess}, nil)
+	// Pending statuses are fetched again, once for the head of the pair
+	// and once for the pending commit on each refresh
+	mockScm.
+		EXPECT().
+		GetCommitStatus(mockCtx, "o", "r", "b").
+		Times(4).
+		Return(&scm.ScmCommitStatus{State: scm.CommitStatusPending}, nil)
+
+	for refresh := 0; refresh < 2; refresh++ {
+		repoChangelogs := dashboardService.GetDashboardChangelogs(mockCtx, []dashboard.DashboardRepo{{Config: mockRepoConfig, Repository: mockRepo}})
+
+		assert.Equal(t, scm.CommitStatusPending, repoChangelogs[0].ChangelogCommits[0].Status.State)
+		assert.Equal(t, scm.CommitStatusSuccess, repoChangelogs[0].ChangelogCommits[0].CommitStatuses["a"].State)
+	}
+}
@@ -0,0 +1,104 @@
+package dashboard
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+// DashboardTicketPattern finds issue tracker references in commit messages,
+// the url can use {key} for the first capture group of the pattern, or the
+// whole match if there are no groups, {match} and {repo_url}.
+type DashboardTicketPattern struct {
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Url     string `json:"url" yaml:"url"`
+
+	// patternRegexp is compiled from Pattern when the config is validated
+	patternRegexp *regexp.Regexp
+}
+
+type DashboardTicket struct {
+	CommitShas []string
+	Key        string
+	Url        string
+}
+
+// Validate compiles the pattern, so that it is only compiled once when the
+// repo config is loaded.
+func (p *DashboardTicketPattern) Validate() error {
+	patternRegexp, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return fmt.Errorf("Could not compile ticket pattern %s: %s", p.Pattern, err)
+	}
+	p.patternRegexp = patternRegexp
+	if p.Url == "" {
+		return fmt.Errorf("Ticket pattern %s must have a url", p.Pattern)
+	}
+	// {repo_url} is always the https url of the repo
+	if !strings.HasPrefix(p.Url, "{repo_url}") {
+		ticketUrl, err := url.Parse(p.Url)
+		if err != nil {
+			return fmt.Errorf("Could not parse ticket url %s: %s", p.Url, err)
+		}
+		if ticketUrl.Scheme != "http" && ticketUrl.Scheme != "https" {
+			return fmt.Errorf("Ticket url %s must be http or https", p.Url)
+		}
+	}
+	return nil
+}
+
+func (p DashboardTicketPattern) isCompiled() bool {
+	return p.patternRegexp != nil
+}
+
+// ExtractTickets finds all ticket references across the commits, tickets are
+// deduplicated and kept in the order that they first appear.
+func (c *DashboardRepoConfig) ExtractTickets(repository scm.ScmRepository, commits []scm.ScmCommit) []DashboardTicket {
+	var tickets []DashboardTicket
+	ticketIndexes := map[string]int{}
+
+	for _, ticketPattern := range c.Tickets {
+		patternRegexp := ticketPattern.patternRegexp
+		// Patterns built outside of a repo config haven't been validated yet
+		if !ticketPattern.isCompiled() {
+			if err := ticketPattern.Validate(); err != nil {
+				continue
+			}
+			patternRegexp = ticketPattern.patternRegexp
+		}
+
+		for _, commit := range commits {
+			for _, matches := range patternRegexp.FindAllStringSubmatch(commit.Message, -1) {
+				key := matches[0]
+				if len(matches) > 1 && matches[1] != "" {
+					key = matches[1]
+				}
+
+				index, found := ticketIndexes[matches[0]]
+				if !found {
+					urlReplacer := strings.NewReplacer(
+						"{key}", url.PathEscape(key),
+						"{match}", url.PathEscape(matches[0]),
+						"{repo_url}", strings.TrimSuffix(repository.HtmlUrl, "/"),
+					)
+					tickets = append(tickets, DashboardTicket{
+						Key: matches[0],
+						Url: urlReplacer.Replace(ticketPattern.Url),
+					})
+					index = len(tickets) - 1
+					ticketIndexes[matches[0]] = index
+				}
+
+				ticket := &tickets[index]
+				if len(ticket.CommitShas) == 0 || ticket.CommitShas[len(ticket.CommitShas)-1] != commit.Sha {
+					ticket.CommitShas = append(ticket.CommitShas, commit.Sha)
+				}
+			}
+		}
+	}
+
+	return tickets
+}
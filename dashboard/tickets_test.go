@@ -0,0 +1,81 @@
+package dashboard_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dashboard "github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+func TestDashboardRepoConfigExtractTickets(t *testing.T) {
+	repoConfig := dashboard.DashboardRepoConfig{
+		Tickets: []dashboard.DashboardTicketPattern{
+			{Pattern: `\b[A-Z][A-Z0-9]+-[0-9]+\b`, Url: "https://jira.example.com/browse/{key}"},
+			{Pattern: `#([0-9]+)`, Url: "{repo_url}/issues/{key}"},
+		},
+	}
+	repository := scm.ScmRepository{HtmlUrl: "https://github.com/o/r/"}
+
+	commits := []scm.ScmCommit{
+		{Message: "PAY-1234 add refunds (#456)", Sha: "a"},
+		{Message: "PAY-1234 fix refunds, PAY-1234 again", Sha: "b"},
+		{Message: "OPS-1 pin image", Sha: "c"},
+		{Message: "no tickets", Sha: "d"},
+	}
+
+	expectedTickets := []dashboard.DashboardTicket{
+		{CommitShas: []string{"a", "b"}, Key: "PAY-1234", Url: "https://jira.example.com/browse/PAY-1234"},
+		{CommitShas: []string{"c"}, Key: "OPS-1", Url: "https://jira.example.com/browse/OPS-1"},
+		{CommitShas: []string{"a"}, Key: "#456", Url: "https://github.com/o/r/issues/456"},
+	}
+
+	assert.Equal(t, expectedTickets, repoConfig.ExtractTickets(repository, commits))
+}
+
+func TestDashboardRepoConfigExtractTicketsNoPatterns(t *testing.T) {
+	repoConfig := dashboard.DashboardRepoConfig{}
+
+	commits := []scm.ScmCommit{{Message: "PAY-1234 add refunds", Sha: "a"}}
+
+	assert.Nil(t, repoConfig.ExtractTickets(scm.ScmRepository{}, commits))
+}
+
+func TestDashboardTicketPatternValidate(t *testing.T) {
+	for _, ticketPattern := range []dashboard.DashboardTicketPattern{
+		{Pattern: "[A-Z", Url: "https://jira/browse/{key}"},
+		{Pattern: "[A-Z]+"},
+		{Pattern: "[A-Z]+", Url: "javascript:alert('{key}')"},
+		{Pattern: "[A-Z]+", Url: "jira/browse/{key}"},
+	} {
+		assert.Error(t, ticketPattern.Validate())
+	}
+	for _, ticketPattern := range []dashboard.DashboardTicketPattern{
+		{Pattern: "[A-Z]+", Url: "https://jira/browse/{key}"},
+		{Pattern: "[A-Z]+", Url: "{repo_url}/issues/{key}"},
+	} {
+		assert.NoError(t, ticketPattern.Validate())
+	}
+}
+
+func TestDashboardRepoConfigInvalidTicketPattern(t *testing.T) {
+	_, err := dashboard.NewDashboardRepoConfig([]byte("tickets:\n  - pattern: \"[A-Z\"\n    url: https://jira/browse/{key}\n"))
+
+	assert.EqualError(t, err, "Could not compile ticket pattern [A-Z: error parsing regexp: missing closing ]: `[A-Z`")
+}
+
+func TestExtractTicketsEscapesKeys(t *testing.T) {
+	repoConfig := dashboard.DashboardRepoConfig{
+		Tickets: []dashboard.DashboardTicketPattern{
+			{Pattern: `TASK:[^ ]+`, Url: "https://tasks.example.com/{match}"},
+		},
+	}
+	commits := []scm.ScmCommit{{Message: "TASK:a/../b?c fix", Sha: "a"}}
+
+	tickets := repoConfig.ExtractTickets(scm.ScmRepository{}, commits)
+
+	assert.Equal(t, []dashboard.DashboardTicket{
+		{CommitShas: []string{"a"}, Key: "TASK:a/../b?c", Url: "https://tasks.example.com/TASK:a%2F..%2Fb%3Fc"},
+	}, tickets)
+}
@@ -0,0 +1,75 @@
+package history
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+)
+
+// Backfill rebuilds the movements of an environment from past tags matching
+// tagPattern, such as prod-*, for every repo whose pipeline includes the
+// environment. Movements to a commit that is already in the history are
+// skipped so a backfill can safely be run again. The environment refs are
+// left alone, they are only ever set by a refresh. The number of movements
+// added is returned.
+func (s *HistoryService) Backfill(ctx context.Context, dashboardService dashboard.DashboardProvider, dashboardRepos []dashboard.DashboardRepo, environment string, tagPattern string) (int, error) {
+	added := 0
+	for _, dashboardRepo := range dashboardRepos {
+		if dashboardRepo.Config == nil || !hasEnvironment(dashboardRepo.Config, environment) {
+			continue
+		}
+
+		owner := dashboardRepo.Repository.OwnerName
+		repo := dashboardRepo.Repository.Name
+
+		promotions, err := dashboardService.GetDashboardTagPromotions(ctx, dashboardRepo, environment, tagPattern)
+		if err != nil {
+			log.Error().Err(err).Msgf("Could not backfill %s for repo %s/%s", environment, owner, repo)
+			continue
+		}
+
+		existing, err := s.Adapter.GetMovements(HistoryFilter{
+			Environment: environment,
+			Owner:       owner,
+			Repo:        repo,
+			Service:     dashboardRepo.Config.Name,
+		})
+		if err != nil {
+			return added, fmt.Errorf("Could not get history for repo %s/%s: %s", owner, repo, err)
+		}
+		existingShas := map[string]bool{}
+		for _, movement := range existing {
+			existingShas[movement.ToSha] = true
+		}
+
+		var movements []HistoryMovement
+		for _, promotion := range promotions {
+			if existingShas[promotion.ToSha] {
+				continue
+			}
+			movements = append(movements, NewHistoryMovement(promotion))
+		}
+		if len(movements) == 0 {
+			continue
+		}
+
+		if err := s.Adapter.Record(nil, movements); err != nil {
+			return added, fmt.Errorf("Could not record history for repo %s/%s: %s", owner, repo, err)
+		}
+		log.Info().Msgf("Backfilled %d movements of %s for repo %s/%s", len(movements), environment, owner, repo)
+		added += len(movements)
+	}
+	return added, nil
+}
+
+func hasEnvironment(repoConfig *dashboard.DashboardRepoConfig, environment string) bool {
+	for _, environmentRef := range repoConfig.EnvironmentRefs() {
+		if environmentRef == environment {
+			return true
+		}
+	}
+	return false
+}
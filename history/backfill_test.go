@@ -0,0 +1,124 @@
+package history_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/scm"
+
+	mock_dashboard "github.com/lobsterdore/release-dash/mocks/dashboard"
+	mock_history "github.com/lobsterdore/release-dash/mocks/history"
+)
+
+func TestHistoryServiceBackfill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockAdapter := mock_history.NewMockHistoryAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+	historyService := history.NewHistoryService(mockAdapter)
+
+	mockConfig := &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"stg", "prod"}, Name: "app"}
+	mockRepo := scm.ScmRepository{HtmlUrl: "https://github.com/o/r", Name: "r", OwnerName: "o"}
+	mockBrokenRepo := scm.ScmRepository{Name: "broken", OwnerName: "o"}
+	dashboardRepos := []dashboard.DashboardRepo{
+		{Config: mockConfig, Repository: mockRepo},
+		{Config: &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"dev"}, Name: "dev-only"}, Repository: scm.ScmRepository{Name: "dev-only", OwnerName: "o"}},
+		{ConfigError: "broken", Repository: scm.ScmRepository{Name: "invalid", OwnerName: "o"}},
+		{Config: mockConfig, Repository: mockBrokenRepo},
+	}
+
+	promotedAt := time.Date(2020, time.October, 6, 10, 0, 0, 0, time.UTC)
+	promotions := []dashboard.DashboardPromotion{
+		{Config: mockConfig, Environment: "prod", FromSha: "a", PromotedAt: promotedAt, Repository: mockRepo, ToSha: "b"},
+		{
+			Commits:     []scm.ScmCommit{{AuthoredAt: promotedAt, Message: "m", Sha: "c"}},
+			Config:      mockConfig,
+			Environment: "prod",
+			FromSha:     "b",
+			PromotedAt:  promotedAt.Add(24 * time.Hour),
+			Repository:  mockRepo,
+			ToSha:       "c",
+		},
+	}
+
+	mockCtx := context.Background()
+	mockDashboardService.
+		EXPECT().
+		GetDashboardTagPromotions(mockCtx, dashboardRepos[0], "prod", "prod-*").
+		Times(1).
+		Return(promotions, nil)
+	mockDashboardService.
+		EXPECT().
+		GetDashboardTagPromotions(mockCtx, dashboardRepos[3], "prod", "prod-*").
+		Times(1).
+		Return(nil, errors.New("error"))
+	mockAdapter.
+		EXPECT().
+		GetMovements(history.HistoryFilter{Environment: "prod", Owner: "o", Repo: "r", Service: "app"}).
+		Times(1).
+		Return([]history.HistoryMovement{{Environment: "prod", Owner: "o", Repo: "r", Service: "app", ToSha: "b"}}, nil)
+
+	expectedMovements := []history.HistoryMovement{{
+		Commits:     []history.HistoryCommit{{AuthoredAt: promotedAt, Message: "m", Sha: "c"}},
+		Environment: "prod",
+		FromSha:     "b",
+		HtmlUrl:     "https://github.com/o/r/compare/b...c",
+		MovedAt:     promotedAt.Add(24 * time.Hour),
+		Owner:       "o",
+		Repo:        "r",
+		Service:     "app",
+		ToSha:       "c",
+	}}
+	mockAdapter.
+		EXPECT().
+		Record(nil, expectedMovements).
+		Times(1).
+		Return(nil)
+
+	added, err := historyService.Backfill(mockCtx, mockDashboardService, dashboardRepos, "prod", "prod-*")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, added)
+	ctrl.Finish()
+}
+
+func TestHistoryServiceBackfillRecordError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockAdapter := mock_history.NewMockHistoryAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+	historyService := history.NewHistoryService(mockAdapter)
+
+	mockConfig := &dashboard.DashboardRepoConfig{EnvironmentBranches: []string{"main", "live"}, Name: "app"}
+	mockRepo := scm.ScmRepository{Name: "r", OwnerName: "o"}
+	dashboardRepos := []dashboard.DashboardRepo{{Config: mockConfig, Repository: mockRepo}}
+
+	mockCtx := context.Background()
+	mockDashboardService.
+		EXPECT().
+		GetDashboardTagPromotions(mockCtx, dashboardRepos[0], "live", "live-*").
+		Times(1).
+		Return([]dashboard.DashboardPromotion{{Config: mockConfig, Environment: "live", FromSha: "a", Repository: mockRepo, ToSha: "b"}}, nil)
+	mockAdapter.
+		EXPECT().
+		GetMovements(gomock.Any()).
+		Times(1).
+		Return(nil, nil)
+	mockAdapter.
+		EXPECT().
+		Record(nil, gomock.Any()).
+		Times(1).
+		Return(errors.New("error"))
+
+	added, err := historyService.Backfill(mockCtx, mockDashboardService, dashboardRepos, "live", "live-*")
+
+	assert.Error(t, err)
+	assert.Equal(t, 0, added)
+}
@@ -0,0 +1,261 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltMovementsBucket = []byte("movements")
+	boltRefsBucket      = []byte("refs")
+	// boltSnapshotsBucket is keyed by repo, service and time so that the
+	// snapshots of a repo can be read without reading every other repo
+	boltSnapshotsBucket = []byte("repo_snapshots")
+	// boltTimeSnapshotsBucket keyed snapshots by time first, it is moved into
+	// boltSnapshotsBucket when the history is opened
+	boltTimeSnapshotsBucket = []byte("snapshots")
+)
+
+// boltTimeFormat has a fixed width so that movement and snapshot keys sort by
+// time.
+const boltTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// BoltHistoryAdapter keeps history in a single BoltDB file, movements are
+// keyed by time so that date ranges can be read without a full scan.
+type BoltHistoryAdapter struct {
+	Db *bolt.DB
+}
+
+func NewBoltHistoryAdapter(path string) (*BoltHistoryAdapter, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("Could not open history %s: %s", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{boltMovementsBucket, boltRefsBucket, boltSnapshotsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return migrateBoltTimeSnapshots(tx)
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("Could not create history buckets: %s", err)
+	}
+
+	adapter := BoltHistoryAdapter{
+		Db: db,
+	}
+
+	return &adapter, nil
+}
+
+// migrateBoltTimeSnapshots moves snapshots keyed by time first into the bucket
+// keyed by repo.
+func migrateBoltTimeSnapshots(tx *bolt.Tx) error {
+	timeSnapshotsBucket := tx.Bucket(boltTimeSnapshotsBucket)
+	if timeSnapshotsBucket == nil {
+		return nil
+	}
+
+	snapshotsBucket := tx.Bucket(boltSnapshotsBucket)
+	err := timeSnapshotsBucket.ForEach(func(key []byte, value []byte) error {
+		var snapshot HistorySnapshot
+		if err := json.Unmarshal(value, &snapshot); err != nil {
+			return fmt.Errorf("Could not read snapshot %s: %s", key, err)
+		}
+		return snapshotsBucket.Put(boltSnapshotKey(snapshot), value)
+	})
+	if err != nil {
+		return err
+	}
+	return tx.DeleteBucket(boltTimeSnapshotsBucket)
+}
+
+// boltSnapshotKey is owner/repo|service|time, owner and repo are lower cased
+// as they are matched without case.
+func boltSnapshotKey(snapshot HistorySnapshot) []byte {
+	return []byte(boltSnapshotPrefix(snapshot.Owner, snapshot.Repo) + snapshot.Service + "|" + snapshot.TakenAt.UTC().Format(boltTimeFormat))
+}
+
+// boltSnapshotPrefix narrows the snapshot keys down as far as the owner and
+// repo allow, an empty prefix covers every snapshot.
+func boltSnapshotPrefix(owner string, repo string) string {
+	if owner == "" {
+		return ""
+	}
+	prefix := strings.ToLower(owner) + "/"
+	if repo != "" {
+		prefix += strings.ToLower(repo) + "|"
+	}
+	return prefix
+}
+
+func (b *BoltHistoryAdapter) Close() error {
+	return b.Db.Close()
+}
+
+// GetMovements returns the matching movements newest first, the movements are
+// walked backwards from Until so that only as many as the limit are read.
+func (b *BoltHistoryAdapter) GetMovements(filter HistoryFilter) ([]HistoryMovement, error) {
+	var movements []HistoryMovement
+	err := b.Db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(boltMovementsBucket).Cursor()
+
+		var key, value []byte
+		if filter.Until.IsZero() {
+			key, value = cursor.Last()
+		} else {
+			// Seek finds the first movement at or after Until, which is
+			// excluded, so start from the one before it
+			key, _ = cursor.Seek([]byte(filter.Until.UTC().Format(boltTimeFormat)))
+			if key == nil {
+				key, value = cursor.Last()
+			} else {
+				key, value = cursor.Prev()
+			}
+		}
+		for ; key != nil; key, value = cursor.Prev() {
+			var movement HistoryMovement
+			if err := json.Unmarshal(value, &movement); err != nil {
+				return fmt.Errorf("Could not read movement %s: %s", key, err)
+			}
+			if !filter.Since.IsZero() && movement.MovedAt.Before(filter.Since) {
+				break
+			}
+			if !filter.Matches(movement) {
+				continue
+			}
+			movements = append(movements, movement)
+			if filter.Limit > 0 && len(movements) >= filter.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+func (b *BoltHistoryAdapter) GetRefs() ([]HistoryRef, error) {
+	var refs []HistoryRef
+	err := b.Db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltRefsBucket).ForEach(func(key []byte, value []byte) error {
+			var ref HistoryRef
+			if err := json.Unmarshal(value, &ref); err != nil {
+				return fmt.Errorf("Could not read ref %s: %s", key, err)
+			}
+			refs = append(refs, ref)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// GetSnapshots returns the matching snapshots oldest first. The snapshots of
+// each repo service are sorted by time, so each one is read from Since until
+// Until and then skipped over.
+func (b *BoltHistoryAdapter) GetSnapshots(filter HistoryFilter) ([]HistorySnapshot, error) {
+	var snapshots []HistorySnapshot
+	err := b.Db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(boltSnapshotsBucket).Cursor()
+
+		prefix := []byte(boltSnapshotPrefix(filter.Owner, filter.Repo))
+		var since []byte
+		if !filter.Since.IsZero() {
+			since = []byte(filter.Since.UTC().Format(boltTimeFormat))
+		}
+
+		key, _ := cursor.Seek(prefix)
+		for key != nil && bytes.HasPrefix(key, prefix) {
+			// Everything up to the time is the repo service
+			service := append([]byte{}, key[:bytes.LastIndexByte(key, '|')+1]...)
+
+			var value []byte
+			for key, value = cursor.Seek(append(append([]byte{}, service...), since...)); key != nil && bytes.HasPrefix(key, service); key, value = cursor.Next() {
+				var snapshot HistorySnapshot
+				if err := json.Unmarshal(value, &snapshot); err != nil {
+					return fmt.Errorf("Could not read snapshot %s: %s", key, err)
+				}
+				if !filter.Until.IsZero() && !snapshot.TakenAt.Before(filter.Until) {
+					break
+				}
+				if filter.MatchesSnapshot(snapshot) {
+					snapshots = append(snapshots, snapshot)
+				}
+			}
+
+			key, _ = cursor.Seek(append(service, 0xff))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(snapshots, func(left int, right int) bool {
+		return snapshots[left].TakenAt.Before(snapshots[right].TakenAt)
+	})
+	return snapshots, nil
+}
+
+func (b *BoltHistoryAdapter) Record(refs []HistoryRef, movements []HistoryMovement) error {
+	return b.Db.Update(func(tx *bolt.Tx) error {
+		refsBucket := tx.Bucket(boltRefsBucket)
+		for _, ref := range refs {
+			value, err := json.Marshal(ref)
+			if err != nil {
+				return fmt.Errorf("Could not encode ref %s: %s", ref.Key(), err)
+			}
+			if err := refsBucket.Put([]byte(ref.Key()), value); err != nil {
+				return fmt.Errorf("Could not store ref %s: %s", ref.Key(), err)
+			}
+		}
+
+		movementsBucket := tx.Bucket(boltMovementsBucket)
+		for _, movement := range movements {
+			// The sha keeps tags that are promoted at the same time apart
+			key := movement.MovedAt.UTC().Format(boltTimeFormat) + "|" + historyKey(movement.Owner, movement.Repo, movement.Service, movement.Environment) + "|" + movement.ToSha
+			value, err := json.Marshal(movement)
+			if err != nil {
+				return fmt.Errorf("Could not encode movement %s: %s", key, err)
+			}
+			if err := movementsBucket.Put([]byte(key), value); err != nil {
+				return fmt.Errorf("Could not store movement %s: %s", key, err)
+			}
+		}
+		return nil
+	})
+}
+
+// RecordSnapshots replaces any snapshot of the same repo taken at the same
+// time.
+func (b *BoltHistoryAdapter) RecordSnapshots(snapshots []HistorySnapshot) error {
+	return b.Db.Update(func(tx *bolt.Tx) error {
+		snapshotsBucket := tx.Bucket(boltSnapshotsBucket)
+		for _, snapshot := range snapshots {
+			key := boltSnapshotKey(snapshot)
+			value, err := json.Marshal(snapshot)
+			if err != nil {
+				return fmt.Errorf("Could not encode snapshot %s: %s", key, err)
+			}
+			if err := snapshotsBucket.Put([]byte(key), value); err != nil {
+				return fmt.Errorf("Could not store snapshot %s: %s", key, err)
+			}
+		}
+		return nil
+	})
+}
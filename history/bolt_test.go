@@ -0,0 +1,228 @@
+package history_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/lobsterdore/release-dash/history"
+)
+
+func newTestBoltHistoryAdapter(t *testing.T) *history.BoltHistoryAdapter {
+	adapter, err := history.NewBoltHistoryAdapter(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = adapter.Close() })
+	return adapter
+}
+
+func TestBoltHistoryAdapterRefs(t *testing.T) {
+	adapter := newTestBoltHistoryAdapter(t)
+	observedAt := time.Date(2020, time.October, 6, 10, 0, 0, 0, time.UTC)
+
+	refs, err := adapter.GetRefs()
+	assert.NoError(t, err)
+	assert.Empty(t, refs)
+
+	err = adapter.Record([]history.HistoryRef{
+		{Environment: "prod", ObservedAt: observedAt, Owner: "o", Repo: "r", Service: "app", Sha: "a"},
+		{Environment: "stg", ObservedAt: observedAt, Owner: "o", Repo: "r", Service: "app", Sha: "b"},
+	}, nil)
+	assert.NoError(t, err)
+
+	err = adapter.Record([]history.HistoryRef{
+		{Environment: "prod", ObservedAt: observedAt.Add(time.Hour), Owner: "o", Repo: "r", Service: "app", Sha: "c"},
+	}, nil)
+	assert.NoError(t, err)
+
+	refs, err = adapter.GetRefs()
+
+	expectedRefs := []history.HistoryRef{
+		{Environment: "prod", ObservedAt: observedAt.Add(time.Hour), Owner: "o", Repo: "r", Service: "app", Sha: "c"},
+		{Environment: "stg", ObservedAt: observedAt, Owner: "o", Repo: "r", Service: "app", Sha: "b"},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRefs, refs)
+}
+
+func TestBoltHistoryAdapterMovements(t *testing.T) {
+	adapter := newTestBoltHistoryAdapter(t)
+	monday := time.Date(2020, time.October, 5, 15, 0, 0, 0, time.UTC)
+	tuesday := monday.AddDate(0, 0, 1)
+	wednesday := monday.AddDate(0, 0, 2)
+
+	movements := []history.HistoryMovement{
+		{Commits: []history.HistoryCommit{}, Environment: "prod", MovedAt: tuesday, Owner: "o", Repo: "r", Service: "app", ToSha: "b"},
+		{Commits: []history.HistoryCommit{{Message: "m", Sha: "a"}}, Environment: "prod", MovedAt: monday, Owner: "o", Repo: "r", Service: "app", ToSha: "a"},
+		{Commits: []history.HistoryCommit{}, Environment: "stg", MovedAt: tuesday, Owner: "o", Repo: "other", Service: "other", ToSha: "c"},
+		{Commits: []history.HistoryCommit{}, Environment: "prod", MovedAt: wednesday, Owner: "o", Repo: "r", Service: "app", ToSha: "d"},
+	}
+	assert.NoError(t, adapter.Record(nil, movements))
+
+	allMovements, err := adapter.GetMovements(history.HistoryFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, allMovements, 4)
+	assert.Equal(t, "d", allMovements[0].ToSha)
+	assert.Equal(t, "a", allMovements[3].ToSha)
+	assert.Equal(t, movements[1].Commits, allMovements[3].Commits)
+
+	tuesdayMovements, err := adapter.GetMovements(history.HistoryFilter{
+		Environment: "prod",
+		Since:       time.Date(2020, time.October, 6, 0, 0, 0, 0, time.UTC),
+		Until:       time.Date(2020, time.October, 7, 0, 0, 0, 0, time.UTC),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, tuesdayMovements, 1)
+	assert.Equal(t, "b", tuesdayMovements[0].ToSha)
+
+	limitedMovements, err := adapter.GetMovements(history.HistoryFilter{Limit: 2, Repo: "R"})
+	assert.NoError(t, err)
+	assert.Len(t, limitedMovements, 2)
+	assert.Equal(t, "d", limitedMovements[0].ToSha)
+	assert.Equal(t, "b", limitedMovements[1].ToSha)
+
+	untilMovements, err := adapter.GetMovements(history.HistoryFilter{Limit: 1, Until: wednesday})
+	assert.NoError(t, err)
+	assert.Len(t, untilMovements, 1)
+	assert.Equal(t, tuesday, untilMovements[0].MovedAt)
+
+	untilMovements, err = adapter.GetMovements(history.HistoryFilter{Until: wednesday.Add(time.Hour)})
+	assert.NoError(t, err)
+	assert.Len(t, untilMovements, 4)
+}
+
+func TestBoltHistoryAdapterMovementsSameTime(t *testing.T) {
+	adapter := newTestBoltHistoryAdapter(t)
+	movedAt := time.Date(2020, time.October, 5, 15, 0, 0, 0, time.UTC)
+
+	// Two tags for the same environment promoted between refreshes
+	movements := []history.HistoryMovement{
+		{Commits: []history.HistoryCommit{}, Environment: "prod", FromSha: "a", MovedAt: movedAt, Owner: "o", Repo: "r", Service: "app", ToSha: "b"},
+		{Commits: []history.HistoryCommit{}, Environment: "prod", FromSha: "b", MovedAt: movedAt, Owner: "o", Repo: "r", Service: "app", ToSha: "c"},
+	}
+	assert.NoError(t, adapter.Record(nil, movements))
+
+	allMovements, err := adapter.GetMovements(history.HistoryFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, allMovements, 2)
+}
+
+func TestBoltHistoryAdapterSnapshots(t *testing.T) {
+	adapter := newTestBoltHistoryAdapter(t)
+	takenAt := time.Date(2020, time.October, 5, 15, 0, 0, 0, time.UTC)
+	pairs := func(commits int) []history.HistorySnapshotPair {
+		return []history.HistorySnapshotPair{{Commits: commits, FromRef: "prod", ToRef: "stg"}}
+	}
+
+	assert.NoError(t, adapter.RecordSnapshots([]history.HistorySnapshot{
+		{Owner: "o", Pairs: pairs(1), Repo: "r", Service: "app", TakenAt: takenAt.Add(time.Hour)},
+		{Owner: "o", Pairs: pairs(2), Repo: "r", Service: "app", TakenAt: takenAt},
+		{Owner: "o", Pairs: pairs(5), Repo: "other", Service: "other", TakenAt: takenAt},
+	}))
+	// A later snapshot at the same time replaces the first
+	assert.NoError(t, adapter.RecordSnapshots([]history.HistorySnapshot{
+		{Owner: "o", Pairs: pairs(3), Repo: "r", Service: "app", TakenAt: takenAt},
+	}))
+
+	allSnapshots, err := adapter.GetSnapshots(history.HistoryFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, allSnapshots, 3)
+
+	repoSnapshots, err := adapter.GetSnapshots(history.HistoryFilter{Owner: "O", Repo: "r"})
+
+	expectedSnapshots := []history.HistorySnapshot{
+		{Owner: "o", Pairs: pairs(3), Repo: "r", Service: "app", TakenAt: takenAt},
+		{Owner: "o", Pairs: pairs(1), Repo: "r", Service: "app", TakenAt: takenAt.Add(time.Hour)},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSnapshots, repoSnapshots)
+
+	laterSnapshots, err := adapter.GetSnapshots(history.HistoryFilter{Since: takenAt.Add(time.Minute), Until: takenAt.Add(2 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Len(t, laterSnapshots, 1)
+	assert.Equal(t, 1, laterSnapshots[0].Pairs[0].Commits)
+}
+
+func TestBoltHistoryAdapterSnapshotsForRepo(t *testing.T) {
+	adapter := newTestBoltHistoryAdapter(t)
+	takenAt := time.Date(2020, time.October, 5, 15, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, adapter.RecordSnapshots([]history.HistorySnapshot{
+		{Owner: "o", Repo: "r", Service: "web", TakenAt: takenAt.Add(2 * time.Hour)},
+		{Owner: "o", Repo: "r", Service: "api", TakenAt: takenAt.Add(time.Hour)},
+		{Owner: "o", Repo: "r", Service: "api", TakenAt: takenAt},
+		{Owner: "o", Repo: "r2", Service: "api", TakenAt: takenAt.Add(time.Hour)},
+		{Owner: "other", Repo: "r", Service: "api", TakenAt: takenAt.Add(time.Hour)},
+	}))
+
+	repoSnapshots, err := adapter.GetSnapshots(history.HistoryFilter{Owner: "o", Repo: "R", Since: takenAt.Add(time.Minute)})
+	assert.NoError(t, err)
+	assert.Equal(t, []history.HistorySnapshot{
+		{Owner: "o", Repo: "r", Service: "api", TakenAt: takenAt.Add(time.Hour)},
+		{Owner: "o", Repo: "r", Service: "web", TakenAt: takenAt.Add(2 * time.Hour)},
+	}, repoSnapshots)
+
+	ownerSnapshots, err := adapter.GetSnapshots(history.HistoryFilter{Owner: "o", Until: takenAt.Add(2 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Len(t, ownerSnapshots, 3)
+}
+
+func TestBoltHistoryAdapterMigratesTimeSnapshots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	takenAt := time.Date(2020, time.October, 5, 15, 0, 0, 0, time.UTC)
+	snapshot := history.HistorySnapshot{Owner: "o", Repo: "r", Service: "app", TakenAt: takenAt}
+
+	db, err := bolt.Open(path, 0600, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucket([]byte("snapshots"))
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(takenAt.Format("2006-01-02T15:04:05.000000000Z")+"|o/r/app"), value)
+	}))
+	assert.NoError(t, db.Close())
+
+	adapter, err := history.NewBoltHistoryAdapter(path)
+	assert.NoError(t, err)
+	defer adapter.Close()
+
+	snapshots, err := adapter.GetSnapshots(history.HistoryFilter{Owner: "o", Repo: "r"})
+	assert.NoError(t, err)
+	assert.Equal(t, []history.HistorySnapshot{snapshot}, snapshots)
+}
+
+func TestBoltHistoryAdapterReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+
+	adapter, err := history.NewBoltHistoryAdapter(path)
+	assert.NoError(t, err)
+	assert.NoError(t, adapter.Record(
+		[]history.HistoryRef{{Environment: "prod", Owner: "o", Repo: "r", Service: "app", Sha: "a"}},
+		[]history.HistoryMovement{{Environment: "prod", MovedAt: time.Now(), Owner: "o", Repo: "r", Service: "app", ToSha: "a"}},
+	))
+	assert.NoError(t, adapter.Close())
+
+	adapter, err = history.NewBoltHistoryAdapter(path)
+	assert.NoError(t, err)
+	defer adapter.Close()
+
+	refs, err := adapter.GetRefs()
+	assert.NoError(t, err)
+	assert.Len(t, refs, 1)
+
+	movements, err := adapter.GetMovements(history.HistoryFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, movements, 1)
+}
@@ -0,0 +1,226 @@
+package history
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+)
+
+// DoraReport holds the DORA deployment frequency and lead time for changes
+// between Since and Until.
+type DoraReport struct {
+	Environments []DoraEnvironment
+	// Repos and Teams only count deployments to production
+	Repos []DoraGroup
+	Since time.Time
+	Teams []DoraGroup
+	Until time.Time
+}
+
+// DoraEnvironment is the deployment frequency of an environment across every
+// repo.
+type DoraEnvironment struct {
+	Deployments int
+	Name        string
+	PerWeek     float64
+	Repos       int
+}
+
+// DoraGroup is a repo or a team, Owner, Repo and Service are empty for teams.
+type DoraGroup struct {
+	// Commits is the number of commits that reached production
+	Commits     int
+	Deployments int
+	LeadTime    DoraLeadTime
+	Name        string
+	Owner       string
+	PerWeek     float64
+	Repo        string
+	Service     string
+}
+
+// DoraLeadTime is the time from a commit being authored to it reaching
+// production.
+type DoraLeadTime struct {
+	// Commits is the number of commits measured, commits without an authored
+	// time are left out
+	Commits int
+	Mean    time.Duration
+	Median  time.Duration
+	P90     time.Duration
+}
+
+type doraGroupTotals struct {
+	group     DoraGroup
+	leadTimes []time.Duration
+}
+
+// NewDoraReport works out DORA metrics from the movements between since and
+// until. The production environment of a repo is the last environment in its
+// pipeline unless productionEnvironments is given, movements of repos that
+// are no longer on the dashboard only count towards deployment frequency.
+func NewDoraReport(movements []HistoryMovement, dashboardRepos []dashboard.DashboardRepo, productionEnvironments []string, since time.Time, until time.Time) DoraReport {
+	report := DoraReport{
+		Environments: []DoraEnvironment{},
+		Repos:        []DoraGroup{},
+		Since:        since,
+		Teams:        []DoraGroup{},
+		Until:        until,
+	}
+	weeks := until.Sub(since).Hours() / (24 * 7)
+
+	environments := map[string]*DoraEnvironment{}
+	environmentRepos := map[string]map[string]bool{}
+	repos := map[string]*doraGroupTotals{}
+	teams := map[string]*doraGroupTotals{}
+
+	for _, movement := range movements {
+		if movement.MovedAt.Before(since) || !movement.MovedAt.Before(until) {
+			continue
+		}
+
+		environment, found := environments[movement.Environment]
+		if !found {
+			environment = &DoraEnvironment{Name: movement.Environment}
+			environments[movement.Environment] = environment
+			environmentRepos[movement.Environment] = map[string]bool{}
+		}
+		environment.Deployments++
+		environmentRepos[movement.Environment][strings.ToLower(historyKey(movement.Owner, movement.Repo, movement.Service, ""))] = true
+
+		dashboardRepo := findHistoryDashboardRepo(dashboardRepos, movement)
+		if dashboardRepo == nil || !isProductionEnvironment(dashboardRepo.Config, productionEnvironments, movement.Environment) {
+			continue
+		}
+
+		var leadTimes []time.Duration
+		for _, commit := range movement.Commits {
+			if commit.AuthoredAt.IsZero() {
+				continue
+			}
+			leadTime := movement.MovedAt.Sub(commit.AuthoredAt)
+			if leadTime < 0 {
+				leadTime = 0
+			}
+			leadTimes = append(leadTimes, leadTime)
+		}
+
+		repoKey := historyKey(dashboardRepo.Repository.OwnerName, dashboardRepo.Repository.Name, dashboardRepo.Config.Name, "")
+		if _, found := repos[repoKey]; !found {
+			repos[repoKey] = &doraGroupTotals{group: DoraGroup{
+				Name:    dashboardRepo.Config.Name,
+				Owner:   dashboardRepo.Repository.OwnerName,
+				Repo:    dashboardRepo.Repository.Name,
+				Service: dashboardRepo.Config.Name,
+			}}
+		}
+		repos[repoKey].add(movement, leadTimes)
+
+		if team := dashboardRepo.Config.Team; team != "" {
+			if _, found := teams[team]; !found {
+				teams[team] = &doraGroupTotals{group: DoraGroup{Name: team}}
+			}
+			teams[team].add(movement, leadTimes)
+		}
+	}
+
+	for name, environment := range environments {
+		environment.Repos = len(environmentRepos[name])
+		environment.PerWeek = perWeek(environment.Deployments, weeks)
+		report.Environments = append(report.Environments, *environment)
+	}
+	sort.Slice(report.Environments, func(i, j int) bool {
+		if report.Environments[i].Deployments != report.Environments[j].Deployments {
+			return report.Environments[i].Deployments > report.Environments[j].Deployments
+		}
+		return report.Environments[i].Name < report.Environments[j].Name
+	})
+
+	report.Repos = newDoraGroups(repos, weeks)
+	report.Teams = newDoraGroups(teams, weeks)
+	return report
+}
+
+func (t *doraGroupTotals) add(movement HistoryMovement, leadTimes []time.Duration) {
+	t.group.Commits += len(movement.Commits)
+	t.group.Deployments++
+	t.leadTimes = append(t.leadTimes, leadTimes...)
+}
+
+func newDoraGroups(totals map[string]*doraGroupTotals, weeks float64) []DoraGroup {
+	groups := []DoraGroup{}
+	for _, total := range totals {
+		group := total.group
+		group.LeadTime = newDoraLeadTime(total.leadTimes)
+		group.PerWeek = perWeek(group.Deployments, weeks)
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return historyKey(groups[i].Owner, groups[i].Repo, groups[i].Name, "") < historyKey(groups[j].Owner, groups[j].Repo, groups[j].Name, "")
+	})
+	return groups
+}
+
+func newDoraLeadTime(leadTimes []time.Duration) DoraLeadTime {
+	if len(leadTimes) == 0 {
+		return DoraLeadTime{}
+	}
+
+	sorted := append([]time.Duration{}, leadTimes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, leadTime := range sorted {
+		total += leadTime
+	}
+
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	}
+	// Nearest rank, the smallest lead time that 90% of commits are within
+	p90Index := (len(sorted)*9+9)/10 - 1
+
+	return DoraLeadTime{
+		Commits: len(sorted),
+		Mean:    total / time.Duration(len(sorted)),
+		Median:  median,
+		P90:     sorted[p90Index],
+	}
+}
+
+func findHistoryDashboardRepo(dashboardRepos []dashboard.DashboardRepo, movement HistoryMovement) *dashboard.DashboardRepo {
+	for index := range dashboardRepos {
+		dashboardRepo := &dashboardRepos[index]
+		if dashboardRepo.Config != nil &&
+			strings.EqualFold(dashboardRepo.Repository.OwnerName, movement.Owner) &&
+			strings.EqualFold(dashboardRepo.Repository.Name, movement.Repo) &&
+			dashboardRepo.Config.Name == movement.Service {
+			return dashboardRepo
+		}
+	}
+	return nil
+}
+
+func isProductionEnvironment(repoConfig *dashboard.DashboardRepoConfig, productionEnvironments []string, environment string) bool {
+	if len(productionEnvironments) > 0 {
+		for _, productionEnvironment := range productionEnvironments {
+			if productionEnvironment == environment {
+				return true
+			}
+		}
+		return false
+	}
+
+	environmentRefs := repoConfig.EnvironmentRefs()
+	return len(environmentRefs) > 0 && environmentRefs[len(environmentRefs)-1] == environment
+}
+
+func perWeek(count int, weeks float64) float64 {
+	if weeks <= 0 {
+		return 0
+	}
+	return float64(count) / weeks
+}
@@ -0,0 +1,123 @@
+package history_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+func newDoraDashboardRepos() []dashboard.DashboardRepo {
+	return []dashboard.DashboardRepo{
+		{
+			Config:     &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"stg", "prod"}, Name: "api", Team: "payments"},
+			Repository: scm.ScmRepository{Name: "api", OwnerName: "o"},
+		},
+		{
+			Config:     &dashboard.DashboardRepoConfig{EnvironmentBranches: []string{"main", "live"}, Name: "web", Team: "payments"},
+			Repository: scm.ScmRepository{Name: "web", OwnerName: "o"},
+		},
+		{
+			ConfigError: "broken",
+			Repository:  scm.ScmRepository{Name: "broken", OwnerName: "o"},
+		},
+	}
+}
+
+func newDoraCommits(movedAt time.Time, ages ...time.Duration) []history.HistoryCommit {
+	var commits []history.HistoryCommit
+	for _, age := range ages {
+		commits = append(commits, history.HistoryCommit{AuthoredAt: movedAt.Add(-age)})
+	}
+	return commits
+}
+
+func TestNewDoraReport(t *testing.T) {
+	until := time.Date(2020, time.October, 15, 0, 0, 0, 0, time.UTC)
+	since := until.AddDate(0, 0, -14)
+	day := func(offset int) time.Time { return since.AddDate(0, 0, offset).Add(12 * time.Hour) }
+
+	movements := []history.HistoryMovement{
+		{Commits: newDoraCommits(day(1), time.Hour, 3*time.Hour), Environment: "stg", MovedAt: day(1), Owner: "o", Repo: "api", Service: "api"},
+		{Commits: newDoraCommits(day(2), 2*time.Hour, 4*time.Hour), Environment: "prod", MovedAt: day(2), Owner: "o", Repo: "api", Service: "api"},
+		{Commits: append(newDoraCommits(day(5), 10*time.Hour), history.HistoryCommit{}), Environment: "prod", MovedAt: day(5), Owner: "O", Repo: "API", Service: "api"},
+		{Commits: newDoraCommits(day(3), 6*time.Hour), Environment: "live", MovedAt: day(3), Owner: "o", Repo: "web", Service: "web"},
+		{Commits: newDoraCommits(day(4), time.Hour), Environment: "prod", MovedAt: day(4), Owner: "o", Repo: "gone", Service: "gone"},
+		{Commits: newDoraCommits(day(-1), time.Hour), Environment: "prod", MovedAt: day(-1), Owner: "o", Repo: "api", Service: "api"},
+	}
+
+	report := history.NewDoraReport(movements, newDoraDashboardRepos(), nil, since, until)
+
+	expectedEnvironments := []history.DoraEnvironment{
+		{Deployments: 3, Name: "prod", PerWeek: 1.5, Repos: 2},
+		{Deployments: 1, Name: "live", PerWeek: 0.5, Repos: 1},
+		{Deployments: 1, Name: "stg", PerWeek: 0.5, Repos: 1},
+	}
+	expectedRepos := []history.DoraGroup{
+		{
+			Commits:     4,
+			Deployments: 2,
+			LeadTime:    history.DoraLeadTime{Commits: 3, Mean: 16 * time.Hour / 3, Median: 4 * time.Hour, P90: 10 * time.Hour},
+			Name:        "api",
+			Owner:       "o",
+			PerWeek:     1,
+			Repo:        "api",
+			Service:     "api",
+		},
+		{
+			Commits:     1,
+			Deployments: 1,
+			LeadTime:    history.DoraLeadTime{Commits: 1, Mean: 6 * time.Hour, Median: 6 * time.Hour, P90: 6 * time.Hour},
+			Name:        "web",
+			Owner:       "o",
+			PerWeek:     0.5,
+			Repo:        "web",
+			Service:     "web",
+		},
+	}
+	expectedTeams := []history.DoraGroup{{
+		Commits:     5,
+		Deployments: 3,
+		LeadTime:    history.DoraLeadTime{Commits: 4, Mean: 22 * time.Hour / 4, Median: 5 * time.Hour, P90: 10 * time.Hour},
+		Name:        "payments",
+		PerWeek:     1.5,
+	}}
+
+	assert.Equal(t, expectedEnvironments, report.Environments)
+	assert.Equal(t, expectedRepos, report.Repos)
+	assert.Equal(t, expectedTeams, report.Teams)
+	assert.Equal(t, since, report.Since)
+	assert.Equal(t, until, report.Until)
+}
+
+func TestNewDoraReportProductionEnvironments(t *testing.T) {
+	until := time.Date(2020, time.October, 15, 0, 0, 0, 0, time.UTC)
+	since := until.AddDate(0, 0, -7)
+	movedAt := since.Add(time.Hour)
+
+	movements := []history.HistoryMovement{
+		{Commits: newDoraCommits(movedAt, time.Hour), Environment: "stg", MovedAt: movedAt, Owner: "o", Repo: "api", Service: "api"},
+		{Commits: newDoraCommits(movedAt, time.Hour), Environment: "live", MovedAt: movedAt, Owner: "o", Repo: "web", Service: "web"},
+	}
+
+	report := history.NewDoraReport(movements, newDoraDashboardRepos(), []string{"stg"}, since, until)
+
+	assert.Len(t, report.Environments, 2)
+	assert.Len(t, report.Repos, 1)
+	assert.Equal(t, "api", report.Repos[0].Name)
+	assert.Equal(t, time.Hour, report.Repos[0].LeadTime.Median)
+}
+
+func TestNewDoraReportEmpty(t *testing.T) {
+	until := time.Date(2020, time.October, 15, 0, 0, 0, 0, time.UTC)
+
+	report := history.NewDoraReport(nil, nil, nil, until.AddDate(0, 0, -7), until)
+
+	assert.Equal(t, []history.DoraEnvironment{}, report.Environments)
+	assert.Equal(t, []history.DoraGroup{}, report.Repos)
+	assert.Equal(t, []history.DoraGroup{}, report.Teams)
+}
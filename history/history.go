@@ -0,0 +1,131 @@
+package history
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+)
+
+//go:generate go run -mod=mod github.com/golang/mock/mockgen --build_flags=-mod=mod --source=history.go --destination=../mocks/history/history.go
+type HistoryAdapter interface {
+	Close() error
+	GetMovements(filter HistoryFilter) ([]HistoryMovement, error)
+	GetRefs() ([]HistoryRef, error)
+	GetSnapshots(filter HistoryFilter) ([]HistorySnapshot, error)
+	// Record stores the latest observed refs and any movements in one go
+	Record(refs []HistoryRef, movements []HistoryMovement) error
+	RecordSnapshots(snapshots []HistorySnapshot) error
+}
+
+type HistoryProvider interface {
+	GetMovements(filter HistoryFilter) ([]HistoryMovement, error)
+	GetPreviousChangelogs() ([]dashboard.DashboardRepoChangelog, error)
+	GetSnapshots(filter HistoryFilter) ([]HistorySnapshot, error)
+	Record(repoChangelogs []dashboard.DashboardRepoChangelog, promotions []dashboard.DashboardPromotion, observedAt time.Time) error
+	RecordSnapshots(repoChangelogs []dashboard.DashboardRepoChangelog, takenAt time.Time) error
+}
+
+// HistoryMovement is an environment ref that moved from one commit to another,
+// the fields are stored as JSON so should only ever be added to.
+type HistoryMovement struct {
+	Commits     []HistoryCommit `json:"commits"`
+	Environment string          `json:"environment"`
+	FromSha     string          `json:"from_sha"`
+	HtmlUrl     string          `json:"html_url"`
+	MovedAt     time.Time       `json:"moved_at"`
+	Owner       string          `json:"owner"`
+	Repo        string          `json:"repo"`
+	Service     string          `json:"service"`
+	ToSha       string          `json:"to_sha"`
+}
+
+type HistoryCommit struct {
+	AuthorLogin string    `json:"author_login"`
+	AuthoredAt  time.Time `json:"authored_at"`
+	HtmlUrl     string    `json:"html_url"`
+	Message     string    `json:"message"`
+	Sha         string    `json:"sha"`
+}
+
+// HistoryRef is the last commit an environment ref was seen at.
+type HistoryRef struct {
+	Environment string    `json:"environment"`
+	ObservedAt  time.Time `json:"observed_at"`
+	Owner       string    `json:"owner"`
+	Repo        string    `json:"repo"`
+	Service     string    `json:"service"`
+	Sha         string    `json:"sha"`
+}
+
+func (r HistoryRef) Key() string {
+	return historyKey(r.Owner, r.Repo, r.Service, r.Environment)
+}
+
+// HistorySnapshot is the number of pending commits between each pair of
+// environments of a repo at a point in time.
+type HistorySnapshot struct {
+	Owner   string                `json:"owner"`
+	Pairs   []HistorySnapshotPair `json:"pairs"`
+	Repo    string                `json:"repo"`
+	Service string                `json:"service"`
+	TakenAt time.Time             `json:"taken_at"`
+}
+
+type HistorySnapshotPair struct {
+	Commits int    `json:"commits"`
+	FromRef string `json:"from_ref"`
+	ToRef   string `json:"to_ref"`
+}
+
+// HistoryFilter narrows down movements and snapshots, empty fields match
+// everything and a zero Limit returns every match. Environment and Limit only
+// apply to movements.
+type HistoryFilter struct {
+	Environment string
+	Limit       int
+	Owner       string
+	Repo        string
+	Service     string
+	// Since is inclusive and Until is exclusive
+	Since time.Time
+	Until time.Time
+}
+
+func (f HistoryFilter) Matches(movement HistoryMovement) bool {
+	if f.Environment != "" && f.Environment != movement.Environment {
+		return false
+	}
+	return f.matchesRepo(movement.Owner, movement.Repo, movement.Service) && f.matchesTime(movement.MovedAt)
+}
+
+func (f HistoryFilter) MatchesSnapshot(snapshot HistorySnapshot) bool {
+	return f.matchesRepo(snapshot.Owner, snapshot.Repo, snapshot.Service) && f.matchesTime(snapshot.TakenAt)
+}
+
+func (f HistoryFilter) matchesRepo(owner string, repo string, service string) bool {
+	if f.Owner != "" && !strings.EqualFold(f.Owner, owner) {
+		return false
+	}
+	if f.Repo != "" && !strings.EqualFold(f.Repo, repo) {
+		return false
+	}
+	if f.Service != "" && f.Service != service {
+		return false
+	}
+	return true
+}
+
+func (f HistoryFilter) matchesTime(at time.Time) bool {
+	if !f.Since.IsZero() && at.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !at.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+func historyKey(owner string, repo string, service string, environment string) string {
+	return strings.Join([]string{owner, repo, service, environment}, "/")
+}
@@ -0,0 +1,140 @@
+package history
+
+import (
+	"time"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+type HistoryService struct {
+	Adapter HistoryAdapter
+}
+
+// snapshotInterval is how often pending commits are kept, a snapshot is taken
+// after every refresh but only the last one in each interval is kept.
+const snapshotInterval = time.Hour
+
+func NewHistoryService(adapter HistoryAdapter) *HistoryService {
+	historyService := HistoryService{
+		Adapter: adapter,
+	}
+
+	return &historyService
+}
+
+func (s *HistoryService) GetMovements(filter HistoryFilter) ([]HistoryMovement, error) {
+	return s.Adapter.GetMovements(filter)
+}
+
+func (s *HistoryService) GetSnapshots(filter HistoryFilter) ([]HistorySnapshot, error) {
+	return s.Adapter.GetSnapshots(filter)
+}
+
+// GetPreviousChangelogs rebuilds the environment refs seen on the last
+// refresh, only the repository, config name and refs are set which is enough
+// to detect promotions after a restart.
+func (s *HistoryService) GetPreviousChangelogs() ([]dashboard.DashboardRepoChangelog, error) {
+	refs, err := s.Adapter.GetRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	var repoChangelogs []dashboard.DashboardRepoChangelog
+	changelogIndexes := map[string]int{}
+	for _, ref := range refs {
+		key := historyKey(ref.Owner, ref.Repo, ref.Service, "")
+		index, found := changelogIndexes[key]
+		if !found {
+			index = len(repoChangelogs)
+			changelogIndexes[key] = index
+			repoChangelogs = append(repoChangelogs, dashboard.DashboardRepoChangelog{
+				Config:          &dashboard.DashboardRepoConfig{Name: ref.Service},
+				EnvironmentRefs: map[string]scm.ScmRef{},
+				Repository:      scm.ScmRepository{Name: ref.Repo, OwnerName: ref.Owner},
+			})
+		}
+		repoChangelogs[index].EnvironmentRefs[ref.Environment] = scm.ScmRef{CurrentHash: ref.Sha, Name: ref.Environment}
+	}
+	return repoChangelogs, nil
+}
+
+// Record stores where every environment ref currently points and the
+// promotions found since the last refresh.
+func (s *HistoryService) Record(repoChangelogs []dashboard.DashboardRepoChangelog, promotions []dashboard.DashboardPromotion, observedAt time.Time) error {
+	var refs []HistoryRef
+	for _, repoChangelog := range repoChangelogs {
+		for _, environment := range repoChangelog.Config.EnvironmentRefs() {
+			environmentRef, found := repoChangelog.EnvironmentRefs[environment]
+			if !found {
+				continue
+			}
+			refs = append(refs, HistoryRef{
+				Environment: environment,
+				ObservedAt:  observedAt,
+				Owner:       repoChangelog.Repository.OwnerName,
+				Repo:        repoChangelog.Repository.Name,
+				Service:     repoChangelog.Config.Name,
+				Sha:         environmentRef.CurrentHash,
+			})
+		}
+	}
+
+	var movements []HistoryMovement
+	for _, promotion := range promotions {
+		movements = append(movements, NewHistoryMovement(promotion))
+	}
+
+	return s.Adapter.Record(refs, movements)
+}
+
+// RecordSnapshots stores the number of pending commits between each pair of
+// environments for every repo.
+func (s *HistoryService) RecordSnapshots(repoChangelogs []dashboard.DashboardRepoChangelog, takenAt time.Time) error {
+	takenAt = takenAt.Truncate(snapshotInterval)
+
+	var snapshots []HistorySnapshot
+	for _, repoChangelog := range repoChangelogs {
+		snapshot := HistorySnapshot{
+			Owner:   repoChangelog.Repository.OwnerName,
+			Pairs:   []HistorySnapshotPair{},
+			Repo:    repoChangelog.Repository.Name,
+			Service: repoChangelog.Config.Name,
+			TakenAt: takenAt,
+		}
+		for _, changelogCommits := range repoChangelog.ChangelogCommits {
+			snapshot.Pairs = append(snapshot.Pairs, HistorySnapshotPair{
+				Commits: len(changelogCommits.Commits),
+				FromRef: changelogCommits.FromRef,
+				ToRef:   changelogCommits.ToRef,
+			})
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return s.Adapter.RecordSnapshots(snapshots)
+}
+
+func NewHistoryMovement(promotion dashboard.DashboardPromotion) HistoryMovement {
+	movement := HistoryMovement{
+		Commits:     []HistoryCommit{},
+		Environment: promotion.Environment,
+		FromSha:     promotion.FromSha,
+		HtmlUrl:     promotion.CompareHtmlUrl(),
+		MovedAt:     promotion.PromotedAt,
+		Owner:       promotion.Repository.OwnerName,
+		Repo:        promotion.Repository.Name,
+		Service:     promotion.Config.Name,
+		ToSha:       promotion.ToSha,
+	}
+	for _, commit := range promotion.Commits {
+		movement.Commits = append(movement.Commits, HistoryCommit{
+			AuthorLogin: commit.AuthorLogin,
+			AuthoredAt:  commit.AuthoredAt,
+			HtmlUrl:     commit.HtmlUrl,
+			Message:     commit.Message,
+			Sha:         commit.Sha,
+		})
+	}
+	return movement
+}
@@ -0,0 +1,191 @@
+package history_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/scm"
+
+	mock_history "github.com/lobsterdore/release-dash/mocks/history"
+)
+
+func TestHistoryServiceGetPreviousChangelogs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockAdapter := mock_history.NewMockHistoryAdapter(ctrl)
+	historyService := history.NewHistoryService(mockAdapter)
+
+	mockAdapter.
+		EXPECT().
+		GetRefs().
+		Times(1).
+		Return([]history.HistoryRef{
+			{Environment: "prod", Owner: "o", Repo: "r", Service: "api", Sha: "a"},
+			{Environment: "prod", Owner: "o", Repo: "r", Service: "web", Sha: "b"},
+			{Environment: "stg", Owner: "o", Repo: "r", Service: "api", Sha: "c"},
+		}, nil)
+
+	repoChangelogs, err := historyService.GetPreviousChangelogs()
+
+	expectedRepoChangelogs := []dashboard.DashboardRepoChangelog{
+		{
+			Config: &dashboard.DashboardRepoConfig{Name: "api"},
+			EnvironmentRefs: map[string]scm.ScmRef{
+				"prod": {CurrentHash: "a", Name: "prod"},
+				"stg":  {CurrentHash: "c", Name: "stg"},
+			},
+			Repository: scm.ScmRepository{Name: "r", OwnerName: "o"},
+		},
+		{
+			Config:          &dashboard.DashboardRepoConfig{Name: "web"},
+			EnvironmentRefs: map[string]scm.ScmRef{"prod": {CurrentHash: "b", Name: "prod"}},
+			Repository:      scm.ScmRepository{Name: "r", OwnerName: "o"},
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRepoChangelogs, repoChangelogs)
+}
+
+func TestHistoryServiceGetPreviousChangelogsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockAdapter := mock_history.NewMockHistoryAdapter(ctrl)
+	historyService := history.NewHistoryService(mockAdapter)
+
+	mockAdapter.
+		EXPECT().
+		GetRefs().
+		Times(1).
+		Return(nil, errors.New("error"))
+
+	repoChangelogs, err := historyService.GetPreviousChangelogs()
+
+	assert.Error(t, err)
+	assert.Nil(t, repoChangelogs)
+}
+
+func TestHistoryServiceRecord(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockAdapter := mock_history.NewMockHistoryAdapter(ctrl)
+	historyService := history.NewHistoryService(mockAdapter)
+
+	observedAt := time.Date(2020, time.October, 6, 10, 0, 0, 0, time.UTC)
+	authoredAt := observedAt.Add(-48 * time.Hour)
+	mockConfig := &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"prod"}, Name: "app"}
+	mockRepo := scm.ScmRepository{HtmlUrl: "https://github.com/o/r", Name: "r", OwnerName: "o"}
+
+	repoChangelogs := []dashboard.DashboardRepoChangelog{{
+		Config:          mockConfig,
+		EnvironmentRefs: map[string]scm.ScmRef{"prod": {CurrentHash: "b", Name: "prod"}},
+		Repository:      mockRepo,
+	}}
+	promotions := []dashboard.DashboardPromotion{{
+		Commits: []scm.ScmCommit{
+			{AuthorLogin: "dev1", AuthoredAt: authoredAt, Files: []string{"main.go"}, HtmlUrl: "https://github.com/o/r/commit/b", Message: "m", Sha: "b"},
+		},
+		Config:      mockConfig,
+		Environment: "prod",
+		FromSha:     "a",
+		PromotedAt:  observedAt,
+		Repository:  mockRepo,
+		ToSha:       "b",
+	}}
+
+	expectedRefs := []history.HistoryRef{
+		{Environment: "prod", ObservedAt: observedAt, Owner: "o", Repo: "r", Service: "app", Sha: "b"},
+	}
+	expectedMovements := []history.HistoryMovement{{
+		Commits: []history.HistoryCommit{
+			{AuthorLogin: "dev1", AuthoredAt: authoredAt, HtmlUrl: "https://github.com/o/r/commit/b", Message: "m", Sha: "b"},
+		},
+		Environment: "prod",
+		FromSha:     "a",
+		HtmlUrl:     "https://github.com/o/r/compare/a...b",
+		MovedAt:     observedAt,
+		Owner:       "o",
+		Repo:        "r",
+		Service:     "app",
+		ToSha:       "b",
+	}}
+
+	mockAdapter.
+		EXPECT().
+		Record(expectedRefs, expectedMovements).
+		Times(1).
+		Return(nil)
+
+	err := historyService.Record(repoChangelogs, promotions, observedAt)
+
+	assert.NoError(t, err)
+	ctrl.Finish()
+}
+
+func TestHistoryServiceRecordSnapshots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockAdapter := mock_history.NewMockHistoryAdapter(ctrl)
+	historyService := history.NewHistoryService(mockAdapter)
+
+	takenAt := time.Date(2020, time.October, 6, 10, 42, 12, 0, time.UTC)
+	repoChangelogs := []dashboard.DashboardRepoChangelog{{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{
+			{Commits: []scm.ScmCommit{{Sha: "a"}, {Sha: "b"}}, FromRef: "stg", ToRef: "dev"},
+			{FromRef: "prod", ToRef: "stg"},
+		},
+		Config:     &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"dev", "stg", "prod"}, Name: "app"},
+		Repository: scm.ScmRepository{Name: "r", OwnerName: "o"},
+	}}
+
+	expectedSnapshots := []history.HistorySnapshot{{
+		Owner: "o",
+		Pairs: []history.HistorySnapshotPair{
+			{Commits: 2, FromRef: "stg", ToRef: "dev"},
+			{Commits: 0, FromRef: "prod", ToRef: "stg"},
+		},
+		Repo:    "r",
+		Service: "app",
+		TakenAt: time.Date(2020, time.October, 6, 10, 0, 0, 0, time.UTC),
+	}}
+
+	mockAdapter.
+		EXPECT().
+		RecordSnapshots(expectedSnapshots).
+		Times(1).
+		Return(nil)
+
+	err := historyService.RecordSnapshots(repoChangelogs, takenAt)
+
+	assert.NoError(t, err)
+	ctrl.Finish()
+}
+
+func TestHistoryFilterMatches(t *testing.T) {
+	movedAt := time.Date(2020, time.October, 6, 10, 0, 0, 0, time.UTC)
+	movement := history.HistoryMovement{Environment: "prod", MovedAt: movedAt, Owner: "Acme", Repo: "Payments", Service: "api"}
+
+	assert.True(t, history.HistoryFilter{}.Matches(movement))
+	assert.True(t, history.HistoryFilter{Owner: "acme", Repo: "payments", Environment: "prod", Service: "api"}.Matches(movement))
+	assert.True(t, history.HistoryFilter{Since: movedAt, Until: movedAt.Add(time.Second)}.Matches(movement))
+	assert.False(t, history.HistoryFilter{Environment: "stg"}.Matches(movement))
+	assert.False(t, history.HistoryFilter{Service: "web"}.Matches(movement))
+	assert.False(t, history.HistoryFilter{Since: movedAt.Add(time.Second)}.Matches(movement))
+	assert.False(t, history.HistoryFilter{Until: movedAt}.Matches(movement))
+}
+
+func TestHistoryFilterMatchesSnapshot(t *testing.T) {
+	takenAt := time.Date(2020, time.October, 6, 10, 0, 0, 0, time.UTC)
+	snapshot := history.HistorySnapshot{Owner: "Acme", Repo: "Payments", Service: "api", TakenAt: takenAt}
+
+	assert.True(t, history.HistoryFilter{}.MatchesSnapshot(snapshot))
+	assert.True(t, history.HistoryFilter{Environment: "stg", Owner: "acme", Repo: "payments"}.MatchesSnapshot(snapshot))
+	assert.False(t, history.HistoryFilter{Repo: "web"}.MatchesSnapshot(snapshot))
+	assert.False(t, history.HistoryFilter{Until: takenAt}.MatchesSnapshot(snapshot))
+}
@@ -11,6 +11,9 @@ import (
 
 	"github.com/lobsterdore/release-dash/cache"
 	"github.com/lobsterdore/release-dash/config"
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/notify"
 	"github.com/lobsterdore/release-dash/scm"
 	"github.com/lobsterdore/release-dash/web"
 )
@@ -33,6 +36,26 @@ func main() {
 		log.Error().Err(err).Msgf("Could not get and set log level %s, using default", cfg.Logging.Level)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		err = runBackfill(cfg, os.Args[2:])
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to backfill history")
+			os.Exit(3)
+		}
+		return
+	}
+
+	err = dashboard.NewDashboardStalenessConfig(cfg).Validate()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid DASHBOARD_STALENESS config")
+		os.Exit(3)
+	}
+
+	if cfg.Notify.RepeatHours <= 0 {
+		log.Fatal().Msg("NOTIFY_REPEAT_HOURS must be more than 0")
+		os.Exit(3)
+	}
+
 	log.Log().Msg("Starting server")
 	ctx, cancel := context.WithTimeout(
 		context.Background(),
@@ -46,10 +69,59 @@ func main() {
 		os.Exit(3)
 	}
 
+	var releaseGithubAdapter scm.ScmAdapter
+	if cfg.Github.ReleasesEnabled {
+		if cfg.Github.WritePat == "" {
+			log.Fatal().Msg("GITHUB_WRITE_PAT must be set when releases are enabled")
+			os.Exit(3)
+		}
+		if cfg.Github.ReleasesSecret == "" {
+			log.Fatal().Msg("GITHUB_RELEASES_SECRET must be set when releases are enabled")
+			os.Exit(3)
+		}
+		releaseGithubAdapter, err = scm.NewGithubAdapter(ctx, cfg.Github.WritePat, cfg.Github.UrlDefault, cfg.Github.UrlUpload)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to setup Github release client")
+			os.Exit(3)
+		}
+	}
+
 	localCacheAdapter := cache.NewLocalCacheAdapter(
 		cfg.Cache.DefaultExpirationSeconds,
 		cfg.Cache.CleanupIntervalSeconds,
 	)
 
-	web.NewWeb(cfg, ctx, githubAdapter, localCacheAdapter).Run(ctx)
+	notifyService, err := notify.NewNotifyService(cfg, localCacheAdapter)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to setup notifications")
+		os.Exit(3)
+	}
+
+	smtpAdapter := notify.NewSmtpAdapter(cfg.Smtp.Host, cfg.Smtp.Port, cfg.Smtp.Username, cfg.Smtp.Password, cfg.Smtp.From)
+	digestService, err := notify.NewDigestService(cfg, localCacheAdapter, smtpAdapter)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to setup digests")
+		os.Exit(3)
+	}
+	digestCtx, digestCancel := context.WithCancel(context.Background())
+	defer digestCancel()
+	digestService.ScheduleTicker(digestCtx)
+
+	var historyService history.HistoryProvider
+	if cfg.History.Path != "" {
+		historyAdapter, err := history.NewBoltHistoryAdapter(cfg.History.Path)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to setup history")
+			os.Exit(3)
+		}
+		defer historyAdapter.Close()
+		historyService = history.NewHistoryService(historyAdapter)
+	}
+
+	webProvider, err := web.NewWeb(cfg, ctx, githubAdapter, releaseGithubAdapter, localCacheAdapter, historyService, notifyService)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to setup web server")
+		os.Exit(3)
+	}
+	webProvider.Run(ctx)
 }
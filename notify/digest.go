@@ -0,0 +1,311 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/lobsterdore/release-dash/asset"
+	"github.com/lobsterdore/release-dash/cache"
+	"github.com/lobsterdore/release-dash/config"
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/web/templatefns"
+)
+
+// DigestService emails a summary of unreleased changes on a schedule, each
+// recipient gets every repo or only the repos of their teams.
+type DigestService struct {
+	CacheService cache.CacheAdapter
+	DashboardUrl string
+	Location     *time.Location
+	MailService  MailAdapter
+	Recipients   []DigestRecipient
+	// Schedule is nil when digests are disabled
+	Schedule *Schedule
+}
+
+type DigestRecipient struct {
+	Email string
+	// Teams is empty for recipients that get every repo
+	Teams []string
+}
+
+type DigestData struct {
+	DashboardUrl string
+	// Environments are ranked by their oldest unreleased commit
+	Environments []DigestEnvironment
+	GeneratedAt  time.Time
+	Repos        int
+	Teams        []string
+}
+
+// DigestEnvironment holds the repos with changes waiting to reach an
+// environment, ranked by the age of their oldest unreleased commit.
+type DigestEnvironment struct {
+	Entries []DigestEntry
+	Name    string
+}
+
+type DigestEntry struct {
+	Commits        int
+	FromRef        string
+	Name           string
+	OldestCommitAt time.Time
+	Owner          string
+	Repo           string
+	Staleness      string
+	Team           string
+	ToRef          string
+	Url            string
+}
+
+func NewDigestService(cfg config.Config, cacheService cache.CacheAdapter, mailService MailAdapter) (*DigestService, error) {
+	service := DigestService{
+		CacheService: cacheService,
+		DashboardUrl: strings.TrimSuffix(cfg.Notify.DashboardUrl, "/"),
+		MailService:  mailService,
+	}
+	if cfg.Digest.Schedule == "" {
+		return &service, nil
+	}
+
+	schedule, err := NewSchedule(cfg.Digest.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	service.Schedule = schedule
+
+	service.Location, err = time.LoadLocation(cfg.Digest.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("Could not load digest timezone %s: %s", cfg.Digest.Timezone, err)
+	}
+
+	service.Recipients = newDigestRecipients(cfg.Digest.Recipients)
+	if len(service.Recipients) == 0 {
+		return nil, fmt.Errorf("DIGEST_RECIPIENTS must be set when DIGEST_SCHEDULE is set")
+	}
+	if cfg.Smtp.From == "" {
+		return nil, fmt.Errorf("SMTP_FROM must be set when DIGEST_SCHEDULE is set")
+	}
+
+	return &service, nil
+}
+
+// newDigestRecipients reads entries of either an email address, for every
+// repo, or team=email, for the repos of a team. An address that is given for
+// every repo ignores any teams.
+func newDigestRecipients(entries []string) []DigestRecipient {
+	var recipients []DigestRecipient
+	recipientIndexes := map[string]int{}
+	everyRepo := map[string]bool{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		team := ""
+		email := entry
+		if separator := strings.Index(entry, "="); separator != -1 {
+			team = strings.TrimSpace(entry[:separator])
+			email = strings.TrimSpace(entry[separator+1:])
+		}
+
+		index, found := recipientIndexes[strings.ToLower(email)]
+		if !found {
+			index = len(recipients)
+			recipientIndexes[strings.ToLower(email)] = index
+			recipients = append(recipients, DigestRecipient{Email: email})
+		}
+		if team == "" {
+			everyRepo[strings.ToLower(email)] = true
+			continue
+		}
+		recipients[index].Teams = append(recipients[index].Teams, team)
+	}
+
+	for index := range recipients {
+		if everyRepo[strings.ToLower(recipients[index].Email)] {
+			recipients[index].Teams = nil
+		}
+	}
+	return recipients
+}
+
+// ScheduleTicker sends the digests each time the schedule comes round until
+// the context is cancelled.
+func (s *DigestService) ScheduleTicker(ctx context.Context) {
+	if s.Schedule == nil {
+		return
+	}
+	go func() {
+		for {
+			next := s.Schedule.Next(time.Now().In(s.Location))
+			if next.IsZero() {
+				log.Error().Msg("Digest schedule does not have a next run")
+				return
+			}
+			log.Debug().Msgf("Next digest due at %s", next)
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				s.SendDigests(ctx)
+			}
+		}
+	}()
+}
+
+// SendDigests emails every recipient a digest of the cached changelogs.
+func (s *DigestService) SendDigests(ctx context.Context) {
+	cachedData, found := s.CacheService.Get("homepage_changelog_data")
+	if !found {
+		log.Error().Msg("Could not send digests, changelogs have not been fetched yet")
+		return
+	}
+	repoChangelogs := cachedData.([]dashboard.DashboardRepoChangelog)
+
+	for _, recipient := range s.Recipients {
+		if ctx.Err() != nil {
+			return
+		}
+
+		data := NewDigestData(repoChangelogs, recipient.Teams, s.DashboardUrl, time.Now())
+		subject, textBody, htmlBody, err := renderDigest(data)
+		if err != nil {
+			log.Error().Err(err).Msgf("Could not render digest for %s", recipient.Email)
+			continue
+		}
+
+		err = s.MailService.SendMail([]string{recipient.Email}, subject, textBody, htmlBody)
+		if err != nil {
+			log.Error().Err(err).Msgf("Could not send digest to %s", recipient.Email)
+			continue
+		}
+		log.Info().Msgf("Digest sent to %s", recipient.Email)
+	}
+}
+
+// NewDigestData lists every environment pair with unreleased changes in the
+// repos of the teams, or every repo if no teams are given.
+func NewDigestData(repoChangelogs []dashboard.DashboardRepoChangelog, teams []string, dashboardUrl string, now time.Time) DigestData {
+	data := DigestData{
+		DashboardUrl: dashboardUrl,
+		GeneratedAt:  now,
+		Teams:        teams,
+	}
+
+	environmentIndexes := map[string]int{}
+	for _, repoChangelog := range repoChangelogs {
+		if len(teams) > 0 && !digestTeamMatches(teams, repoChangelog.Config.Team) {
+			continue
+		}
+
+		hasEntries := false
+		for _, changelogCommits := range repoChangelog.ChangelogCommits {
+			if len(changelogCommits.Commits) == 0 {
+				continue
+			}
+			hasEntries = true
+
+			entry := DigestEntry{
+				Commits:   len(changelogCommits.Commits),
+				FromRef:   changelogCommits.FromRef,
+				Name:      repoChangelog.Config.Name,
+				Owner:     repoChangelog.Repository.OwnerName,
+				Repo:      repoChangelog.Repository.Name,
+				Staleness: changelogCommits.Staleness,
+				Team:      repoChangelog.Config.Team,
+				ToRef:     changelogCommits.ToRef,
+				Url:       repoChangelog.Repository.HtmlUrl,
+			}
+			if oldestCommit := changelogCommits.OldestCommit(); oldestCommit != nil {
+				entry.OldestCommitAt = oldestCommit.AuthoredAt
+			}
+			if dashboardUrl != "" {
+				entry.Url = fmt.Sprintf("%s/repos/%s/%s", dashboardUrl, entry.Owner, entry.Repo)
+			}
+
+			index, found := environmentIndexes[entry.FromRef]
+			if !found {
+				index = len(data.Environments)
+				environmentIndexes[entry.FromRef] = index
+				data.Environments = append(data.Environments, DigestEnvironment{Name: entry.FromRef})
+			}
+			data.Environments[index].Entries = append(data.Environments[index].Entries, entry)
+		}
+		if hasEntries {
+			data.Repos++
+		}
+	}
+
+	for _, environment := range data.Environments {
+		entries := environment.Entries
+		sort.SliceStable(entries, func(i, j int) bool {
+			return digestEntryOlder(entries[i], entries[j])
+		})
+	}
+	sort.SliceStable(data.Environments, func(i, j int) bool {
+		return digestEntryOlder(data.Environments[i].Entries[0], data.Environments[j].Entries[0])
+	})
+	return data
+}
+
+// digestEntryOlder ranks entries by the age of the oldest commit, entries
+// without an author date come last.
+func digestEntryOlder(a DigestEntry, b DigestEntry) bool {
+	if a.OldestCommitAt.IsZero() != b.OldestCommitAt.IsZero() {
+		return b.OldestCommitAt.IsZero()
+	}
+	return a.OldestCommitAt.Before(b.OldestCommitAt)
+}
+
+func digestTeamMatches(teams []string, team string) bool {
+	for _, digestTeam := range teams {
+		if strings.EqualFold(digestTeam, team) {
+			return true
+		}
+	}
+	return false
+}
+
+func renderDigest(data DigestData) (string, string, string, error) {
+	subject := "Release digest: everything has been released"
+	if data.Repos > 0 {
+		subject = fmt.Sprintf("Release digest: %d service", data.Repos)
+		if data.Repos != 1 {
+			subject += "s"
+		}
+		subject += " with unreleased changes"
+	}
+
+	textTmpl, err := template.New("digest").Funcs(template.FuncMap(templatefns.TemplateFnsMap)).Parse(asset.ReadTemplateFile("text/digest.txt"))
+	if err != nil {
+		return "", "", "", fmt.Errorf("Could not parse text/digest.txt: %s", err)
+	}
+	var textBody bytes.Buffer
+	if err := textTmpl.Execute(&textBody, data); err != nil {
+		return "", "", "", fmt.Errorf("Could not render text/digest.txt: %s", err)
+	}
+
+	htmlTmpl, err := htmltemplate.New("digest").Funcs(templatefns.TemplateFnsMap).Parse(asset.ReadTemplateFile("html/digest.html"))
+	if err != nil {
+		return "", "", "", fmt.Errorf("Could not parse html/digest.html: %s", err)
+	}
+	var htmlBody bytes.Buffer
+	if err := htmlTmpl.Execute(&htmlBody, data); err != nil {
+		return "", "", "", fmt.Errorf("Could not render html/digest.html: %s", err)
+	}
+
+	return subject, textBody.String(), htmlBody.String(), nil
+}
@@ -0,0 +1,228 @@
+package notify_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/cache"
+	"github.com/lobsterdore/release-dash/config"
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/notify"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+func newMockDigestChangelog(name string, team string, pairs ...dashboard.DashboardChangelogCommits) dashboard.DashboardRepoChangelog {
+	return dashboard.DashboardRepoChangelog{
+		ChangelogCommits: pairs,
+		Config:           &dashboard.DashboardRepoConfig{Name: name, Team: team},
+		Repository:       scm.ScmRepository{HtmlUrl: "https://github.com/o/" + name, Name: name, OwnerName: "o"},
+	}
+}
+
+func newMockDigestPair(toRef string, fromRef string, age time.Duration, commits int) dashboard.DashboardChangelogCommits {
+	pair := dashboard.DashboardChangelogCommits{FromRef: fromRef, ToRef: toRef}
+	for index := 0; index < commits; index++ {
+		pair.Commits = append(pair.Commits, scm.ScmCommit{AuthoredAt: time.Now().Add(-age), Sha: toRef + fromRef})
+	}
+	return pair
+}
+
+func newMockDigestChangelogs() []dashboard.DashboardRepoChangelog {
+	day := 24 * time.Hour
+	return []dashboard.DashboardRepoChangelog{
+		newMockDigestChangelog("billing", "payments",
+			newMockDigestPair("dev", "stg", 1*day, 2),
+			newMockDigestPair("stg", "prd", 4*day, 3),
+		),
+		newMockDigestChangelog("search", "discovery",
+			newMockDigestPair("dev", "stg", 0, 0),
+			newMockDigestPair("stg", "prd", 9*day, 12),
+		),
+		newMockDigestChangelog("web", "discovery",
+			newMockDigestPair("dev", "stg", 3*day, 1),
+		),
+		newMockDigestChangelog("released", "payments",
+			newMockDigestPair("dev", "stg", 0, 0),
+		),
+	}
+}
+
+func TestNewDigestData(t *testing.T) {
+	now := time.Now()
+	data := notify.NewDigestData(newMockDigestChangelogs(), nil, "https://dash", now)
+
+	assert.Equal(t, 3, data.Repos)
+	assert.Equal(t, now, data.GeneratedAt)
+	assert.Len(t, data.Environments, 2)
+
+	assert.Equal(t, "prd", data.Environments[0].Name)
+	assert.Equal(t, []string{"search", "billing"}, digestEntryNames(data.Environments[0]))
+	assert.Equal(t, 12, data.Environments[0].Entries[0].Commits)
+	assert.Equal(t, "https://dash/repos/o/search", data.Environments[0].Entries[0].Url)
+
+	assert.Equal(t, "stg", data.Environments[1].Name)
+	assert.Equal(t, []string{"web", "billing"}, digestEntryNames(data.Environments[1]))
+}
+
+func TestNewDigestDataTeams(t *testing.T) {
+	data := notify.NewDigestData(newMockDigestChangelogs(), []string{"Payments"}, "", time.Now())
+
+	assert.Equal(t, 1, data.Repos)
+	assert.Len(t, data.Environments, 2)
+	assert.Equal(t, []string{"billing"}, digestEntryNames(data.Environments[0]))
+	assert.Equal(t, "https://github.com/o/billing", data.Environments[0].Entries[0].Url)
+
+	data = notify.NewDigestData(newMockDigestChangelogs(), []string{"platform"}, "", time.Now())
+
+	assert.Equal(t, 0, data.Repos)
+	assert.Empty(t, data.Environments)
+}
+
+func digestEntryNames(environment notify.DigestEnvironment) []string {
+	var names []string
+	for _, entry := range environment.Entries {
+		names = append(names, entry.Name)
+	}
+	return names
+}
+
+func newMockDigestConfig() config.Config {
+	cfg := config.Config{}
+	cfg.Digest.Recipients = []string{"cto@example.com", "payments=pay-lead@example.com", "discovery=cto@example.com", "discovery=disco@example.com", "payments=disco@example.com"}
+	cfg.Digest.Schedule = "0 9 * * 1"
+	cfg.Digest.Timezone = "UTC"
+	cfg.Smtp.From = "dash@example.com"
+	return cfg
+}
+
+func TestNewDigestService(t *testing.T) {
+	digestService, err := notify.NewDigestService(newMockDigestConfig(), nil, nil)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, digestService.Schedule)
+	assert.Equal(t, []notify.DigestRecipient{
+		{Email: "cto@example.com"},
+		{Email: "pay-lead@example.com", Teams: []string{"payments"}},
+		{Email: "disco@example.com", Teams: []string{"discovery", "payments"}},
+	}, digestService.Recipients)
+}
+
+func TestNewDigestServiceDisabled(t *testing.T) {
+	digestService, err := notify.NewDigestService(config.Config{}, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Nil(t, digestService.Schedule)
+	// Does nothing without a schedule
+	digestService.ScheduleTicker(context.Background())
+}
+
+func TestNewDigestServiceBadConfig(t *testing.T) {
+	cfg := newMockDigestConfig()
+	cfg.Digest.Schedule = "every monday"
+	_, err := notify.NewDigestService(cfg, nil, nil)
+	assert.EqualError(t, err, "Could not parse schedule every monday: expected 5 fields")
+
+	cfg = newMockDigestConfig()
+	cfg.Digest.Recipients = nil
+	_, err = notify.NewDigestService(cfg, nil, nil)
+	assert.EqualError(t, err, "DIGEST_RECIPIENTS must be set when DIGEST_SCHEDULE is set")
+
+	cfg = newMockDigestConfig()
+	cfg.Smtp.From = ""
+	_, err = notify.NewDigestService(cfg, nil, nil)
+	assert.EqualError(t, err, "SMTP_FROM must be set when DIGEST_SCHEDULE is set")
+}
+
+func TestSendDigests(t *testing.T) {
+	sink := newSmtpSink(t)
+	defer sink.Close()
+
+	cfg := newMockDigestConfig()
+	cfg.Notify.DashboardUrl = "https://dash"
+	cfg.Smtp.Host, cfg.Smtp.Port = sink.HostPort()
+
+	cacheService := cache.NewLocalCacheAdapter(60, 60)
+	cacheService.Set("homepage_changelog_data", newMockDigestChangelogs(), "")
+	smtpAdapter := notify.NewSmtpAdapter(cfg.Smtp.Host, cfg.Smtp.Port, "", "", cfg.Smtp.From)
+	digestService, err := notify.NewDigestService(cfg, cacheService, smtpAdapter)
+	assert.NoError(t, err)
+
+	digestService.SendDigests(context.Background())
+
+	assert.Len(t, sink.Messages, 3)
+
+	assert.Equal(t, []string{"cto@example.com"}, sink.Messages[0].Recipients)
+	_, subject, textBody, htmlBody := readSinkMessage(t, sink.Messages[0])
+	assert.Equal(t, "Release digest: 3 services with unreleased changes", subject)
+	assert.Equal(t, `Release digest
+
+Waiting for prd
+- search stg > prd: 12 commits, oldest 9 days ago
+  https://dash/repos/o/search
+- billing stg > prd: 3 commits, oldest 4 days ago
+  https://dash/repos/o/billing
+
+Waiting for stg
+- web dev > stg: 1 commit, oldest 3 days ago
+  https://dash/repos/o/web
+- billing dev > stg: 2 commits, oldest 1 day ago
+  https://dash/repos/o/billing
+
+https://dash
+`, textBody)
+	assert.Contains(t, htmlBody, `<a href="https://dash/repos/o/search">search</a>`)
+	assert.Contains(t, htmlBody, "<td>stg &gt; prd</td>")
+	assert.Contains(t, htmlBody, "<td>9 days ago</td>")
+
+	assert.Equal(t, []string{"pay-lead@example.com"}, sink.Messages[1].Recipients)
+	_, subject, textBody, _ = readSinkMessage(t, sink.Messages[1])
+	assert.Equal(t, "Release digest: 1 service with unreleased changes", subject)
+	assert.Contains(t, textBody, "Release digest for payments\n")
+	assert.NotContains(t, textBody, "search")
+
+	assert.Equal(t, []string{"disco@example.com"}, sink.Messages[2].Recipients)
+	_, _, textBody, _ = readSinkMessage(t, sink.Messages[2])
+	assert.Contains(t, textBody, "Release digest for discovery, payments\n")
+}
+
+func TestSendDigestsEverythingReleased(t *testing.T) {
+	sink := newSmtpSink(t)
+	defer sink.Close()
+
+	cfg := newMockDigestConfig()
+	cfg.Digest.Recipients = []string{"platform=ops@example.com"}
+	cfg.Smtp.Host, cfg.Smtp.Port = sink.HostPort()
+
+	cacheService := cache.NewLocalCacheAdapter(60, 60)
+	cacheService.Set("homepage_changelog_data", newMockDigestChangelogs(), "")
+	smtpAdapter := notify.NewSmtpAdapter(cfg.Smtp.Host, cfg.Smtp.Port, "", "", cfg.Smtp.From)
+	digestService, err := notify.NewDigestService(cfg, cacheService, smtpAdapter)
+	assert.NoError(t, err)
+
+	digestService.SendDigests(context.Background())
+
+	assert.Len(t, sink.Messages, 1)
+	_, subject, textBody, htmlBody := readSinkMessage(t, sink.Messages[0])
+	assert.Equal(t, "Release digest: everything has been released", subject)
+	assert.Equal(t, "Release digest for platform\n\nEverything has been released.\n", textBody)
+	assert.Contains(t, htmlBody, "<p>Everything has been released.</p>")
+}
+
+func TestSendDigestsNoData(t *testing.T) {
+	sink := newSmtpSink(t)
+	defer sink.Close()
+
+	cfg := newMockDigestConfig()
+	cfg.Smtp.Host, cfg.Smtp.Port = sink.HostPort()
+
+	smtpAdapter := notify.NewSmtpAdapter(cfg.Smtp.Host, cfg.Smtp.Port, "", "", cfg.Smtp.From)
+	digestService, err := notify.NewDigestService(cfg, cache.NewLocalCacheAdapter(60, 60), smtpAdapter)
+	assert.NoError(t, err)
+
+	digestService.SendDigests(context.Background())
+
+	assert.Empty(t, sink.Messages)
+}
@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/web/templatefns"
+)
+
+//go:generate go run -mod=mod github.com/golang/mock/mockgen --build_flags=-mod=mod --source=notify.go --destination=../mocks/notify/notify.go
+type NotifyAdapter interface {
+	Send(ctx context.Context, notification Notification) error
+}
+
+type NotifyProvider interface {
+	Notify(ctx context.Context, repoChangelogs []dashboard.DashboardRepoChangelog)
+}
+
+// Notification describes an environment pair that has been waiting too long
+// to be released, Channel and Team come from the repo config.
+type Notification struct {
+	Channel        string     `json:"channel"`
+	Commits        int        `json:"commits"`
+	FromRef        string     `json:"from_ref"`
+	Name           string     `json:"name"`
+	OldestCommitAt *time.Time `json:"oldest_commit_at"`
+	Owner          string     `json:"owner"`
+	Repo           string     `json:"repo"`
+	Staleness      string     `json:"staleness"`
+	Team           string     `json:"team"`
+	ToRef          string     `json:"to_ref"`
+	Url            string     `json:"url"`
+}
+
+func (n Notification) Title() string {
+	return fmt.Sprintf("%s %s > %s is overdue for release", n.Name, n.ToRef, n.FromRef)
+}
+
+func (n Notification) Summary() string {
+	summary := fmt.Sprintf("%d pending commit", n.Commits)
+	if n.Commits != 1 {
+		summary += "s"
+	}
+	if n.OldestCommitAt != nil {
+		summary += fmt.Sprintf(", the oldest was authored %s", templatefns.TimeAgo(*n.OldestCommitAt, time.Now()))
+	}
+	if n.Team != "" {
+		summary += fmt.Sprintf(", owned by %s", n.Team)
+	}
+	return summary
+}
+
+// errNoWebhookUrl is returned by adapters that have no webhook for the team
+// or channel of a notification and no default webhook, it isn't a failure.
+var errNoWebhookUrl = errors.New("No webhook url for notification")
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// newTeamWebhookUrls reads entries of team=url, the key can be either the team
+// or the channel of a repo.
+func newTeamWebhookUrls(entries []string) (map[string]string, error) {
+	teamWebhookUrls := map[string]string{}
+	for index, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		separator := strings.Index(entry, "=")
+		if separator == -1 {
+			return nil, fmt.Errorf("Could not parse team webhook url %d, expected team=url", index+1)
+		}
+		team := strings.TrimSpace(entry[:separator])
+		webhookUrl := strings.TrimSpace(entry[separator+1:])
+		if team == "" || webhookUrl == "" {
+			return nil, fmt.Errorf("Could not parse team webhook url %d, expected team=url", index+1)
+		}
+		teamWebhookUrls[team] = webhookUrl
+	}
+	return teamWebhookUrls, nil
+}
+
+// teamWebhookUrl picks the webhook of the team of a notification, then the
+// webhook of its channel, falling back to the default webhook.
+func teamWebhookUrl(defaultUrl string, teamWebhookUrls map[string]string, notification Notification) string {
+	for _, key := range []string{notification.Team, notification.Channel} {
+		if key == "" {
+			continue
+		}
+		if webhookUrl, found := teamWebhookUrls[key]; found {
+			return webhookUrl
+		}
+	}
+	return defaultUrl
+}
+
+func postJson(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("Could not marshal notification: %s", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("Could not create notification request: %s", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("Could not send notification: %s", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("Could not send notification, webhook returned status %d", response.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/notify"
+)
+
+type webhookStub struct {
+	Bodies []map[string]interface{}
+	Server *httptest.Server
+	Status int
+}
+
+func newWebhookStub() *webhookStub {
+	stub := &webhookStub{Status: http.StatusOK}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(respWriter http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		payload := map[string]interface{}{}
+		_ = json.Unmarshal(body, &payload)
+		stub.Bodies = append(stub.Bodies, payload)
+		respWriter.WriteHeader(stub.Status)
+	}))
+	return stub
+}
+
+func newMockNotification() notify.Notification {
+	oldestCommitAt := time.Now().Add(-6 * 24 * time.Hour)
+	return notify.Notification{
+		Channel:        "#payments",
+		Commits:        21,
+		FromRef:        "prd",
+		Name:           "payments-api",
+		OldestCommitAt: &oldestCommitAt,
+		Owner:          "o",
+		Repo:           "r",
+		Staleness:      dashboard.StalenessRed,
+		Team:           "payments",
+		ToRef:          "stg",
+		Url:            "https://dash/repos/o/r",
+	}
+}
+
+func TestNotificationText(t *testing.T) {
+	notification := newMockNotification()
+
+	assert.Equal(t, "payments-api stg > prd is overdue for release", notification.Title())
+	assert.Equal(t, "21 pending commits, the oldest was authored 6 days ago, owned by payments", notification.Summary())
+
+	notification.Commits = 1
+	notification.OldestCommitAt = nil
+	notification.Team = ""
+	assert.Equal(t, "1 pending commit", notification.Summary())
+}
+
+func TestSlackAdapterSend(t *testing.T) {
+	stub := newWebhookStub()
+	defer stub.Server.Close()
+
+	err := notify.NewSlackAdapter(stub.Server.URL, nil).Send(context.Background(), newMockNotification())
+
+	assert.NoError(t, err)
+	assert.Len(t, stub.Bodies, 1)
+	assert.Equal(t, "#payments", stub.Bodies[0]["channel"])
+	assert.Equal(t, ":hourglass: *<https://dash/repos/o/r|payments-api stg > prd is overdue for release>*\n21 pending commits, the oldest was authored 6 days ago, owned by payments", stub.Bodies[0]["text"])
+}
+
+func TestTeamsAdapterSend(t *testing.T) {
+	stub := newWebhookStub()
+	defer stub.Server.Close()
+
+	err := notify.NewTeamsAdapter(stub.Server.URL, nil).Send(context.Background(), newMockNotification())
+
+	assert.NoError(t, err)
+	assert.Len(t, stub.Bodies, 1)
+	assert.Equal(t, "MessageCard", stub.Bodies[0]["@type"])
+	assert.Equal(t, "E53935", stub.Bodies[0]["themeColor"])
+	assert.Equal(t, "payments-api stg > prd is overdue for release", stub.Bodies[0]["title"])
+	section := stub.Bodies[0]["sections"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "Team", "value": "payments"},
+		map[string]interface{}{"name": "Channel", "value": "#payments"},
+		map[string]interface{}{"name": "Repo", "value": "o/r"},
+	}, section["facts"])
+	action := stub.Bodies[0]["potentialAction"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "OpenUri", action["@type"])
+}
+
+func TestSlackAdapterSendTeamWebhook(t *testing.T) {
+	stub := newWebhookStub()
+	defer stub.Server.Close()
+	teamStub := newWebhookStub()
+	defer teamStub.Server.Close()
+
+	adapter := notify.NewSlackAdapter(stub.Server.URL, map[string]string{"payments": teamStub.Server.URL})
+	err := adapter.Send(context.Background(), newMockNotification())
+
+	assert.NoError(t, err)
+	assert.Len(t, stub.Bodies, 0)
+	assert.Len(t, teamStub.Bodies, 1)
+
+	// Notifications for other teams use the default webhook
+	notification := newMockNotification()
+	notification.Team = "discovery"
+	notification.Channel = ""
+	err = adapter.Send(context.Background(), notification)
+
+	assert.NoError(t, err)
+	assert.Len(t, stub.Bodies, 1)
+	assert.Len(t, teamStub.Bodies, 1)
+}
+
+func TestTeamsAdapterSendChannelWebhook(t *testing.T) {
+	teamStub := newWebhookStub()
+	defer teamStub.Server.Close()
+
+	adapter := notify.NewTeamsAdapter("", map[string]string{"#payments": teamStub.Server.URL})
+	err := adapter.Send(context.Background(), newMockNotification())
+
+	assert.NoError(t, err)
+	assert.Len(t, teamStub.Bodies, 1)
+
+	notification := newMockNotification()
+	notification.Channel = "#discovery"
+	notification.Team = "discovery"
+	err = adapter.Send(context.Background(), notification)
+
+	assert.Error(t, err)
+	assert.Len(t, teamStub.Bodies, 1)
+}
+
+func TestWebhookAdapterSend(t *testing.T) {
+	stub := newWebhookStub()
+	defer stub.Server.Close()
+
+	notification := newMockNotification()
+	err := notify.NewWebhookAdapter(stub.Server.URL).Send(context.Background(), notification)
+
+	assert.NoError(t, err)
+	assert.Len(t, stub.Bodies, 1)
+	assert.Equal(t, "staleness", stub.Bodies[0]["event"])
+	assert.Equal(t, "#payments", stub.Bodies[0]["channel"])
+	assert.Equal(t, float64(21), stub.Bodies[0]["commits"])
+	assert.Equal(t, "payments", stub.Bodies[0]["team"])
+	assert.Equal(t, "red", stub.Bodies[0]["staleness"])
+	assert.Equal(t, notification.OldestCommitAt.Format(time.RFC3339Nano), stub.Bodies[0]["oldest_commit_at"])
+	assert.Equal(t, notification.Title(), stub.Bodies[0]["title"])
+}
+
+func TestWebhookAdapterSendError(t *testing.T) {
+	stub := newWebhookStub()
+	stub.Status = http.StatusInternalServerError
+	defer stub.Server.Close()
+
+	err := notify.NewWebhookAdapter(stub.Server.URL).Send(context.Background(), newMockNotification())
+
+	assert.EqualError(t, err, "Could not send notification, webhook returned status 500")
+}
@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a cron expression with the standard five fields of minute,
+// hour, day of month, month and day of week. Fields accept *, values, ranges
+// such as 1-5, lists such as 1,3 and steps such as */15, day of week 0 and 7
+// are both Sunday.
+type Schedule struct {
+	daysOfMonth map[int]bool
+	daysOfWeek  map[int]bool
+	hours       map[int]bool
+	minutes     map[int]bool
+	months      map[int]bool
+
+	// Cron matches either day field when both are restricted
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+type scheduleField struct {
+	max  int
+	min  int
+	name string
+}
+
+var scheduleFields = []scheduleField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7},
+}
+
+func NewSchedule(expression string) (*Schedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != len(scheduleFields) {
+		return nil, fmt.Errorf("Could not parse schedule %s: expected %d fields", expression, len(scheduleFields))
+	}
+
+	var values []map[int]bool
+	for index, field := range fields {
+		fieldValues, err := parseScheduleField(field, scheduleFields[index])
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse schedule %s: %s", expression, err)
+		}
+		values = append(values, fieldValues)
+	}
+	if values[4][7] {
+		values[4][0] = true
+	}
+
+	schedule := Schedule{
+		anyDayOfMonth: strings.HasPrefix(fields[2], "*"),
+		anyDayOfWeek:  strings.HasPrefix(fields[4], "*"),
+		daysOfMonth:   values[2],
+		daysOfWeek:    values[4],
+		hours:         values[1],
+		minutes:       values[0],
+		months:        values[3],
+	}
+	return &schedule, nil
+}
+
+// Matches checks if the minute of the time is part of the schedule.
+func (s *Schedule) Matches(t time.Time) bool {
+	return s.months[int(t.Month())] && s.matchesDay(t) && s.hours[t.Hour()] && s.minutes[t.Minute()]
+}
+
+// Next returns the first minute after the time that is part of the schedule,
+// the zero time is returned if there isn't one within the next five years.
+func (s *Schedule) Next(after time.Time) time.Time {
+	next := after.Truncate(time.Minute).Add(time.Minute)
+	end := after.AddDate(5, 0, 0)
+	for next.Before(end) {
+		switch {
+		case !s.months[int(next.Month())]:
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+		case !s.matchesDay(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+		case !s.hours[next.Hour()]:
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+		case !s.minutes[next.Minute()]:
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dayOfMonth := s.daysOfMonth[t.Day()]
+	dayOfWeek := s.daysOfWeek[int(t.Weekday())]
+	switch {
+	case s.anyDayOfMonth && s.anyDayOfWeek:
+		return true
+	case s.anyDayOfMonth:
+		return dayOfWeek
+	case s.anyDayOfWeek:
+		return dayOfMonth
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+func parseScheduleField(field string, fieldRange scheduleField) (map[int]bool, error) {
+	values := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if stepIndex := strings.Index(part, "/"); stepIndex != -1 {
+			var err error
+			step, err = strconv.Atoi(part[stepIndex+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid %s step %s", fieldRange.name, part)
+			}
+			part = part[:stepIndex]
+		}
+
+		start, end := fieldRange.min, fieldRange.max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			start, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %s", fieldRange.name, part)
+			}
+			end = start
+			if len(bounds) == 2 {
+				end, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s %s", fieldRange.name, part)
+				}
+			} else if step > 1 {
+				end = fieldRange.max
+			}
+		}
+		if start < fieldRange.min || end > fieldRange.max || start > end {
+			return nil, fmt.Errorf("%s %s is out of range", fieldRange.name, part)
+		}
+
+		for value := start; value <= end; value += step {
+			values[value] = true
+		}
+	}
+	return values, nil
+}
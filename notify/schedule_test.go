@@ -0,0 +1,75 @@
+package notify_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/notify"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// Monday 4th January 2021
+	after := time.Date(2021, 1, 4, 10, 30, 20, 0, time.UTC)
+
+	cases := map[string]time.Time{
+		"* * * * *":       time.Date(2021, 1, 4, 10, 31, 0, 0, time.UTC),
+		"*/15 * * * *":    time.Date(2021, 1, 4, 10, 45, 0, 0, time.UTC),
+		"0 9 * * 1":       time.Date(2021, 1, 11, 9, 0, 0, 0, time.UTC),
+		"0 9 * * 1-5":     time.Date(2021, 1, 5, 9, 0, 0, 0, time.UTC),
+		"30 17 * * 0":     time.Date(2021, 1, 10, 17, 30, 0, 0, time.UTC),
+		"30 17 * * 7":     time.Date(2021, 1, 10, 17, 30, 0, 0, time.UTC),
+		"0 8,12 * * *":    time.Date(2021, 1, 4, 12, 0, 0, 0, time.UTC),
+		"0 0 1 * *":       time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":      time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		"0 6 15 * 5":      time.Date(2021, 1, 8, 6, 0, 0, 0, time.UTC),
+		"5-10/5 10 * * *": time.Date(2021, 1, 5, 10, 5, 0, 0, time.UTC),
+	}
+	for expression, expected := range cases {
+		schedule, err := notify.NewSchedule(expression)
+		assert.NoError(t, err, expression)
+		assert.Equal(t, expected, schedule.Next(after), expression)
+	}
+}
+
+func TestScheduleNextTimezone(t *testing.T) {
+	location, _ := time.LoadLocation("Europe/London")
+	schedule, err := notify.NewSchedule("0 9 * * 1")
+	assert.NoError(t, err)
+
+	next := schedule.Next(time.Date(2021, 7, 1, 0, 0, 0, 0, location))
+
+	assert.Equal(t, time.Date(2021, 7, 5, 8, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestScheduleNoNextRun(t *testing.T) {
+	schedule, err := notify.NewSchedule("0 0 31 2 *")
+	assert.NoError(t, err)
+
+	assert.True(t, schedule.Next(time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC)).IsZero())
+}
+
+func TestScheduleMatches(t *testing.T) {
+	schedule, err := notify.NewSchedule("0 9 * * 1")
+	assert.NoError(t, err)
+
+	assert.True(t, schedule.Matches(time.Date(2021, 1, 4, 9, 0, 45, 0, time.UTC)))
+	assert.False(t, schedule.Matches(time.Date(2021, 1, 4, 9, 1, 0, 0, time.UTC)))
+	assert.False(t, schedule.Matches(time.Date(2021, 1, 5, 9, 0, 0, 0, time.UTC)))
+}
+
+func TestScheduleBadExpressions(t *testing.T) {
+	for expression, expectedErr := range map[string]string{
+		"0 9 * *":      "Could not parse schedule 0 9 * *: expected 5 fields",
+		"60 9 * * *":   "Could not parse schedule 60 9 * * *: minute 60 is out of range",
+		"0 9 0 * *":    "Could not parse schedule 0 9 0 * *: day of month 0 is out of range",
+		"0 9 * * mon":  "Could not parse schedule 0 9 * * mon: invalid day of week mon",
+		"*/0 9 * * *":  "Could not parse schedule */0 9 * * *: invalid minute step */0",
+		"0 17-9 * * *": "Could not parse schedule 0 17-9 * * *: hour 17-9 is out of range",
+	} {
+		schedule, err := notify.NewSchedule(expression)
+		assert.EqualError(t, err, expectedErr)
+		assert.Nil(t, schedule)
+	}
+}
@@ -0,0 +1,215 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/lobsterdore/release-dash/cache"
+	"github.com/lobsterdore/release-dash/config"
+	"github.com/lobsterdore/release-dash/dashboard"
+)
+
+// NotifyService sends a notification when an environment pair reaches the
+// configured staleness level. Each pair is only notified once per level until
+// the repeat time has passed or the pair is released, notifications due in
+// quiet hours are held back until the quiet hours end. Sent notifications are
+// tracked in the cache for each adapter, so an adapter that failed is retried
+// without repeating the others, and a restart notifies every stale pair again.
+type NotifyService struct {
+	Adapters      []NotifyAdapter
+	CacheService  cache.CacheAdapter
+	DashboardUrl  string
+	Level         string
+	Location      *time.Location
+	QuietHours    string
+	RepeatSeconds int
+}
+
+func NewNotifyService(cfg config.Config, cacheService cache.CacheAdapter) (*NotifyService, error) {
+	switch cfg.Notify.Level {
+	case dashboard.StalenessAmber, dashboard.StalenessRed:
+	default:
+		return nil, fmt.Errorf("Unknown notify level %s", cfg.Notify.Level)
+	}
+	if _, _, err := parseQuietHours(cfg.Notify.QuietHours); err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(cfg.Notify.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("Could not load notify timezone %s: %s", cfg.Notify.Timezone, err)
+	}
+
+	slackTeamWebhookUrls, err := newTeamWebhookUrls(cfg.Notify.SlackTeamWebhookUrls)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid NOTIFY_SLACK_TEAM_WEBHOOK_URLS: %s", err)
+	}
+	teamsTeamWebhookUrls, err := newTeamWebhookUrls(cfg.Notify.TeamsTeamWebhookUrls)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid NOTIFY_TEAMS_TEAM_WEBHOOK_URLS: %s", err)
+	}
+
+	var adapters []NotifyAdapter
+	if cfg.Notify.SlackWebhookUrl != "" || len(slackTeamWebhookUrls) > 0 {
+		adapters = append(adapters, NewSlackAdapter(cfg.Notify.SlackWebhookUrl, slackTeamWebhookUrls))
+	}
+	if cfg.Notify.TeamsWebhookUrl != "" || len(teamsTeamWebhookUrls) > 0 {
+		adapters = append(adapters, NewTeamsAdapter(cfg.Notify.TeamsWebhookUrl, teamsTeamWebhookUrls))
+	}
+	if cfg.Notify.WebhookUrl != "" {
+		adapters = append(adapters, NewWebhookAdapter(cfg.Notify.WebhookUrl))
+	}
+
+	service := NotifyService{
+		Adapters:      adapters,
+		CacheService:  cacheService,
+		DashboardUrl:  strings.TrimSuffix(cfg.Notify.DashboardUrl, "/"),
+		Level:         cfg.Notify.Level,
+		Location:      location,
+		QuietHours:    cfg.Notify.QuietHours,
+		RepeatSeconds: cfg.Notify.RepeatHours * 60 * 60,
+	}
+	return &service, nil
+}
+
+func (s *NotifyService) Notify(ctx context.Context, repoChangelogs []dashboard.DashboardRepoChangelog) {
+	if len(s.Adapters) == 0 {
+		return
+	}
+	if s.InQuietHours(time.Now()) {
+		log.Debug().Msg("Notifications held back during quiet hours")
+		return
+	}
+
+	for _, repoChangelog := range repoChangelogs {
+		for _, changelogCommits := range repoChangelog.ChangelogCommits {
+			notification := s.newNotification(repoChangelog, changelogCommits)
+			for index, adapter := range s.Adapters {
+				s.notifyAdapter(ctx, index, adapter, notification)
+			}
+		}
+	}
+}
+
+// notifyAdapter sends the notification through one adapter unless it has
+// already sent the pair at this level. Adapters are keyed by their position as
+// they are fixed for the life of the service, and each adapter picks a single
+// webhook for a notification.
+func (s *NotifyService) notifyAdapter(ctx context.Context, index int, adapter NotifyAdapter, notification Notification) {
+	cacheKey := fmt.Sprintf(
+		"notify_sent_%d_%s/%s/%s/%s/%s",
+		index,
+		notification.Owner,
+		notification.Repo,
+		notification.Name,
+		notification.FromRef,
+		notification.ToRef,
+	)
+	sentStaleness, sent := s.CacheService.Get(cacheKey)
+
+	if stalenessRank(notification.Staleness) < stalenessRank(s.Level) {
+		// The pair has been released, or caught up, so the next time it goes
+		// stale is notified straight away
+		if sent && sentStaleness.(string) != dashboard.StalenessNone {
+			s.CacheService.Set(cacheKey, dashboard.StalenessNone, strconv.Itoa(s.RepeatSeconds))
+		}
+		return
+	}
+	if sent && stalenessRank(sentStaleness.(string)) >= stalenessRank(notification.Staleness) {
+		return
+	}
+
+	err := adapter.Send(ctx, notification)
+	if err == errNoWebhookUrl {
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("Could not notify for repo %s/%s", notification.Owner, notification.Repo)
+		return
+	}
+	s.CacheService.Set(cacheKey, notification.Staleness, strconv.Itoa(s.RepeatSeconds))
+}
+
+func (s *NotifyService) newNotification(repoChangelog dashboard.DashboardRepoChangelog, changelogCommits dashboard.DashboardChangelogCommits) Notification {
+	notification := Notification{
+		Channel:   repoChangelog.Config.Channel,
+		Commits:   len(changelogCommits.Commits),
+		FromRef:   changelogCommits.FromRef,
+		Name:      repoChangelog.Config.Name,
+		Owner:     repoChangelog.Repository.OwnerName,
+		Repo:      repoChangelog.Repository.Name,
+		Staleness: changelogCommits.Staleness,
+		Team:      repoChangelog.Config.Team,
+		ToRef:     changelogCommits.ToRef,
+		Url:       repoChangelog.Repository.HtmlUrl,
+	}
+	if oldestCommit := changelogCommits.OldestCommit(); oldestCommit != nil {
+		notification.OldestCommitAt = &oldestCommit.AuthoredAt
+	}
+	if s.DashboardUrl != "" {
+		notification.Url = fmt.Sprintf("%s/repos/%s/%s", s.DashboardUrl, notification.Owner, notification.Repo)
+	}
+	return notification
+}
+
+func (s *NotifyService) InQuietHours(now time.Time) bool {
+	start, end, err := parseQuietHours(s.QuietHours)
+	if err != nil || start == end {
+		return false
+	}
+
+	if s.Location != nil {
+		now = now.In(s.Location)
+	}
+	minute := now.Hour()*60 + now.Minute()
+
+	if start < end {
+		return minute >= start && minute < end
+	}
+	// Quiet hours that run past midnight
+	return minute >= start || minute < end
+}
+
+func stalenessRank(staleness string) int {
+	switch staleness {
+	case dashboard.StalenessRed:
+		return 2
+	case dashboard.StalenessAmber:
+		return 1
+	}
+	return 0
+}
+
+// parseQuietHours reads a range such as 22-7 or 22:00-07:30 into minutes of
+// the day, an empty range has no quiet hours.
+func parseQuietHours(quietHours string) (int, int, error) {
+	if quietHours == "" {
+		return 0, 0, nil
+	}
+
+	parts := strings.Split(quietHours, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("Could not parse quiet hours %s", quietHours)
+	}
+	var minutes []int
+	for _, part := range parts {
+		hourMinute := strings.SplitN(strings.TrimSpace(part), ":", 2)
+		hour, err := strconv.Atoi(hourMinute[0])
+		if err != nil || hour < 0 || hour > 23 {
+			return 0, 0, fmt.Errorf("Could not parse quiet hours %s", quietHours)
+		}
+		minute := 0
+		if len(hourMinute) == 2 {
+			minute, err = strconv.Atoi(hourMinute[1])
+			if err != nil || minute < 0 || minute > 59 {
+				return 0, 0, fmt.Errorf("Could not parse quiet hours %s", quietHours)
+			}
+		}
+		minutes = append(minutes, hour*60+minute)
+	}
+	return minutes[0], minutes[1], nil
+}
@@ -0,0 +1,255 @@
+package notify_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/cache"
+	"github.com/lobsterdore/release-dash/config"
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/notify"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+func newMockNotifyConfig(webhookUrl string) config.Config {
+	cfg := config.Config{}
+	cfg.Notify.DashboardUrl = "https://dash/"
+	cfg.Notify.Level = dashboard.StalenessRed
+	cfg.Notify.RepeatHours = 24
+	cfg.Notify.Timezone = "UTC"
+	cfg.Notify.WebhookUrl = webhookUrl
+	return cfg
+}
+
+func newMockNotifyChangelogs(staleness string) []dashboard.DashboardRepoChangelog {
+	return []dashboard.DashboardRepoChangelog{{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{
+			{
+				Commits:   []scm.ScmCommit{{AuthoredAt: time.Now().Add(-2 * time.Hour), Sha: "a"}},
+				FromRef:   "stg",
+				Staleness: dashboard.StalenessNone,
+				ToRef:     "dev",
+			},
+			{
+				Commits:   []scm.ScmCommit{{AuthoredAt: time.Now().Add(-8 * 24 * time.Hour), Sha: "b"}},
+				FromRef:   "prd",
+				Staleness: staleness,
+				ToRef:     "stg",
+			},
+		},
+		Config:     &dashboard.DashboardRepoConfig{Channel: "#payments", Name: "payments-api", Team: "payments"},
+		Repository: scm.ScmRepository{HtmlUrl: "https://github.com/o/r", Name: "r", OwnerName: "o"},
+	}}
+}
+
+func TestNewNotifyServiceAdapters(t *testing.T) {
+	cfg := newMockNotifyConfig("https://hooks/generic")
+	cfg.Notify.SlackWebhookUrl = "https://hooks/slack"
+	cfg.Notify.TeamsWebhookUrl = "https://hooks/teams"
+
+	notifyService, err := notify.NewNotifyService(cfg, cache.NewLocalCacheAdapter(60, 60))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []notify.NotifyAdapter{
+		notify.NewSlackAdapter("https://hooks/slack", map[string]string{}),
+		notify.NewTeamsAdapter("https://hooks/teams", map[string]string{}),
+		notify.NewWebhookAdapter("https://hooks/generic"),
+	}, notifyService.Adapters)
+	assert.Equal(t, "https://dash", notifyService.DashboardUrl)
+	assert.Equal(t, 86400, notifyService.RepeatSeconds)
+
+	cfg = newMockNotifyConfig("")
+	cfg.Notify.SlackTeamWebhookUrls = []string{"payments=https://hooks/slack/payments?a=b", " #discovery = https://hooks/slack/discovery "}
+
+	notifyService, err = notify.NewNotifyService(cfg, cache.NewLocalCacheAdapter(60, 60))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []notify.NotifyAdapter{
+		notify.NewSlackAdapter("", map[string]string{
+			"#discovery": "https://hooks/slack/discovery",
+			"payments":   "https://hooks/slack/payments?a=b",
+		}),
+	}, notifyService.Adapters)
+}
+
+func TestNotifyTeamWebhooks(t *testing.T) {
+	stub := newWebhookStub()
+	defer stub.Server.Close()
+	teamStub := newWebhookStub()
+	defer teamStub.Server.Close()
+
+	cfg := newMockNotifyConfig("")
+	cfg.Notify.SlackTeamWebhookUrls = []string{"payments=" + teamStub.Server.URL}
+	cfg.Notify.TeamsTeamWebhookUrls = []string{"discovery=" + stub.Server.URL}
+	notifyService, err := notify.NewNotifyService(cfg, cache.NewLocalCacheAdapter(60, 60))
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessRed))
+
+	assert.Len(t, teamStub.Bodies, 1)
+	assert.Len(t, stub.Bodies, 0)
+
+	// Already notified through the team webhook
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessRed))
+	assert.Len(t, teamStub.Bodies, 1)
+}
+
+func TestNewNotifyServiceBadConfig(t *testing.T) {
+	cfg := newMockNotifyConfig("")
+	cfg.Notify.Level = "orange"
+	_, err := notify.NewNotifyService(cfg, nil)
+	assert.EqualError(t, err, "Unknown notify level orange")
+
+	cfg = newMockNotifyConfig("")
+	cfg.Notify.QuietHours = "late"
+	_, err = notify.NewNotifyService(cfg, nil)
+	assert.EqualError(t, err, "Could not parse quiet hours late")
+
+	cfg = newMockNotifyConfig("")
+	cfg.Notify.QuietHours = "22:00-25:00"
+	_, err = notify.NewNotifyService(cfg, nil)
+	assert.EqualError(t, err, "Could not parse quiet hours 22:00-25:00")
+
+	cfg = newMockNotifyConfig("")
+	cfg.Notify.SlackTeamWebhookUrls = []string{"https://hooks/slack/payments"}
+	_, err = notify.NewNotifyService(cfg, nil)
+	assert.EqualError(t, err, "Invalid NOTIFY_SLACK_TEAM_WEBHOOK_URLS: Could not parse team webhook url 1, expected team=url")
+
+	cfg = newMockNotifyConfig("")
+	cfg.Notify.Timezone = "Nowhere/Special"
+	_, err = notify.NewNotifyService(cfg, nil)
+	assert.Error(t, err)
+}
+
+func TestNotifyDedup(t *testing.T) {
+	stub := newWebhookStub()
+	defer stub.Server.Close()
+
+	notifyService, err := notify.NewNotifyService(newMockNotifyConfig(stub.Server.URL), cache.NewLocalCacheAdapter(60, 60))
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessRed))
+
+	assert.Len(t, stub.Bodies, 1)
+	assert.Equal(t, "payments-api", stub.Bodies[0]["name"])
+	assert.Equal(t, "prd", stub.Bodies[0]["from_ref"])
+	assert.Equal(t, "#payments", stub.Bodies[0]["channel"])
+	assert.Equal(t, "payments", stub.Bodies[0]["team"])
+	assert.Equal(t, "https://dash/repos/o/r", stub.Bodies[0]["url"])
+
+	// Already notified for this pair
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessRed))
+	assert.Len(t, stub.Bodies, 1)
+
+	// The pair was released then went stale again
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessNone))
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessRed))
+	assert.Len(t, stub.Bodies, 2)
+}
+
+func TestNotifyLevel(t *testing.T) {
+	stub := newWebhookStub()
+	defer stub.Server.Close()
+
+	cfg := newMockNotifyConfig(stub.Server.URL)
+	notifyService, err := notify.NewNotifyService(cfg, cache.NewLocalCacheAdapter(60, 60))
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessAmber))
+	assert.Len(t, stub.Bodies, 0)
+
+	cfg.Notify.Level = dashboard.StalenessAmber
+	notifyService, err = notify.NewNotifyService(cfg, cache.NewLocalCacheAdapter(60, 60))
+	assert.NoError(t, err)
+
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessAmber))
+	assert.Len(t, stub.Bodies, 1)
+
+	// Going from amber to red is notified again, but not back to amber
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessRed))
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessAmber))
+	assert.Len(t, stub.Bodies, 2)
+	assert.Equal(t, "red", stub.Bodies[1]["staleness"])
+}
+
+func TestNotifyRetriesFailedSends(t *testing.T) {
+	stub := newWebhookStub()
+	stub.Status = http.StatusBadGateway
+	defer stub.Server.Close()
+
+	notifyService, err := notify.NewNotifyService(newMockNotifyConfig(stub.Server.URL), cache.NewLocalCacheAdapter(60, 60))
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessRed))
+	stub.Status = http.StatusOK
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessRed))
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessRed))
+
+	assert.Len(t, stub.Bodies, 2)
+}
+
+func TestNotifyRetriesFailedAdapters(t *testing.T) {
+	stub := newWebhookStub()
+	stub.Status = http.StatusBadGateway
+	defer stub.Server.Close()
+	teamStub := newWebhookStub()
+	defer teamStub.Server.Close()
+
+	cfg := newMockNotifyConfig(stub.Server.URL)
+	cfg.Notify.SlackTeamWebhookUrls = []string{"payments=" + teamStub.Server.URL}
+	notifyService, err := notify.NewNotifyService(cfg, cache.NewLocalCacheAdapter(60, 60))
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessRed))
+	stub.Status = http.StatusOK
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessRed))
+	notifyService.Notify(ctx, newMockNotifyChangelogs(dashboard.StalenessRed))
+
+	// Only the generic webhook that failed is retried
+	assert.Len(t, stub.Bodies, 2)
+	assert.Len(t, teamStub.Bodies, 1)
+}
+
+func TestNotifyQuietHours(t *testing.T) {
+	stub := newWebhookStub()
+	defer stub.Server.Close()
+
+	cfg := newMockNotifyConfig(stub.Server.URL)
+	now := time.Now().UTC()
+	cfg.Notify.QuietHours = fmt.Sprintf("%d-%d", now.Hour(), (now.Hour()+2)%24)
+	notifyService, err := notify.NewNotifyService(cfg, cache.NewLocalCacheAdapter(60, 60))
+	assert.NoError(t, err)
+
+	notifyService.Notify(context.Background(), newMockNotifyChangelogs(dashboard.StalenessRed))
+
+	assert.Len(t, stub.Bodies, 0)
+}
+
+func TestNotifyServiceInQuietHours(t *testing.T) {
+	location, _ := time.LoadLocation("Europe/London")
+	notifyService := notify.NotifyService{Location: location, QuietHours: "22-7:30"}
+
+	assert.True(t, notifyService.InQuietHours(time.Date(2021, 1, 4, 23, 0, 0, 0, time.UTC)))
+	assert.True(t, notifyService.InQuietHours(time.Date(2021, 1, 4, 7, 29, 0, 0, time.UTC)))
+	assert.False(t, notifyService.InQuietHours(time.Date(2021, 1, 4, 7, 30, 0, 0, time.UTC)))
+	assert.False(t, notifyService.InQuietHours(time.Date(2021, 1, 4, 12, 0, 0, 0, time.UTC)))
+	// 21:30 UTC is 22:30 in London during the summer
+	assert.True(t, notifyService.InQuietHours(time.Date(2021, 7, 5, 21, 30, 0, 0, time.UTC)))
+
+	notifyService.QuietHours = "9-17"
+	assert.True(t, notifyService.InQuietHours(time.Date(2021, 1, 4, 9, 0, 0, 0, time.UTC)))
+	assert.False(t, notifyService.InQuietHours(time.Date(2021, 1, 4, 17, 0, 0, 0, time.UTC)))
+
+	notifyService.QuietHours = ""
+	assert.False(t, notifyService.InQuietHours(time.Date(2021, 1, 4, 23, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+)
+
+// SlackAdapter posts to a Slack incoming webhook, the channel of the repo is
+// passed on as an override for webhooks that allow it. Teams or channels with
+// their own webhook in TeamWebhookUrls are posted there instead.
+type SlackAdapter struct {
+	TeamWebhookUrls map[string]string
+	WebhookUrl      string
+}
+
+type slackPayload struct {
+	Channel string `json:"channel,omitempty"`
+	Text    string `json:"text"`
+}
+
+func NewSlackAdapter(webhookUrl string, teamWebhookUrls map[string]string) *SlackAdapter {
+	adapter := SlackAdapter{
+		TeamWebhookUrls: teamWebhookUrls,
+		WebhookUrl:      webhookUrl,
+	}
+
+	return &adapter
+}
+
+func (a *SlackAdapter) Send(ctx context.Context, notification Notification) error {
+	webhookUrl := teamWebhookUrl(a.WebhookUrl, a.TeamWebhookUrls, notification)
+	if webhookUrl == "" {
+		return errNoWebhookUrl
+	}
+
+	title := notification.Title()
+	if notification.Url != "" {
+		title = fmt.Sprintf("<%s|%s>", notification.Url, title)
+	}
+
+	return postJson(ctx, webhookUrl, slackPayload{
+		Channel: notification.Channel,
+		Text:    fmt.Sprintf(":hourglass: *%s*\n%s", title, notification.Summary()),
+	})
+}
@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+//go:generate go run -mod=mod github.com/golang/mock/mockgen --build_flags=-mod=mod --source=smtp.go --destination=../mocks/notify/smtp.go
+type MailAdapter interface {
+	SendMail(to []string, subject string, textBody string, htmlBody string) error
+}
+
+// SmtpAdapter sends multipart emails with a text and HTML part, STARTTLS is
+// used if the server offers it.
+type SmtpAdapter struct {
+	Addr string
+	Auth smtp.Auth
+	From string
+}
+
+func NewSmtpAdapter(host string, port string, username string, password string, from string) *SmtpAdapter {
+	adapter := SmtpAdapter{
+		Addr: net.JoinHostPort(host, port),
+		From: from,
+	}
+	if username != "" {
+		adapter.Auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &adapter
+}
+
+func (a *SmtpAdapter) SendMail(to []string, subject string, textBody string, htmlBody string) error {
+	message, err := newMailMessage(a.From, to, subject, textBody, htmlBody)
+	if err != nil {
+		return err
+	}
+
+	err = smtp.SendMail(a.Addr, a.Auth, a.From, to, message)
+	if err != nil {
+		return fmt.Errorf("Could not send mail to %s: %s", strings.Join(to, ", "), err)
+	}
+	return nil
+}
+
+func newMailMessage(from string, to []string, subject string, textBody string, htmlBody string) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		content     string
+		contentType string
+	}{
+		{content: textBody, contentType: "text/plain; charset=UTF-8"},
+		{content: htmlBody, contentType: "text/html; charset=UTF-8"},
+	} {
+		partWriter, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Transfer-Encoding": {"quoted-printable"},
+			"Content-Type":              {part.contentType},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Could not create mail part: %s", err)
+		}
+		encoder := quotedprintable.NewWriter(partWriter)
+		if _, err := encoder.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("Could not write mail part: %s", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, fmt.Errorf("Could not write mail part: %s", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("Could not write mail: %s", err)
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
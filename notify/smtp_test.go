@@ -0,0 +1,176 @@
+package notify_test
+
+import (
+	"bufio"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/notify"
+)
+
+// smtpSink is a local SMTP server that keeps every message it receives.
+type smtpSink struct {
+	Listener net.Listener
+	Messages []smtpSinkMessage
+
+	mux sync.Mutex
+}
+
+type smtpSinkMessage struct {
+	Data       string
+	From       string
+	Recipients []string
+}
+
+func newSmtpSink(t *testing.T) *smtpSink {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &smtpSink{Listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go sink.serve(conn)
+		}
+	}()
+	return sink
+}
+
+func (s *smtpSink) HostPort() (string, string) {
+	host, port, _ := net.SplitHostPort(s.Listener.Addr().String())
+	return host, port
+}
+
+func (s *smtpSink) Close() {
+	s.Listener.Close()
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = conn.Write([]byte(line + "\r\n"))
+	}
+
+	message := smtpSinkMessage{}
+	reply("220 localhost sink")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			message = smtpSinkMessage{From: strings.Trim(strings.TrimSpace(line)[10:], "<>")}
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			message.Recipients = append(message.Recipients, strings.Trim(strings.TrimSpace(line)[8:], "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			message.Data = data.String()
+			s.mux.Lock()
+			s.Messages = append(s.Messages, message)
+			s.mux.Unlock()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// readSinkMessage splits a multipart message into its subject, text and HTML.
+func readSinkMessage(t *testing.T, message smtpSinkMessage) (*mail.Message, string, string, string) {
+	parsed, err := mail.ReadMessage(strings.NewReader(message.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := map[string]string{}
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err != nil {
+			break
+		}
+		content, _ := ioutil.ReadAll(quotedprintable.NewReader(part))
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		// Line endings are sent as CRLF over SMTP
+		parts[mediaType] = strings.ReplaceAll(string(content), "\r\n", "\n")
+	}
+	return parsed, subject, parts["text/plain"], parts["text/html"]
+}
+
+func TestSmtpAdapterSendMail(t *testing.T) {
+	sink := newSmtpSink(t)
+	defer sink.Close()
+
+	host, port := sink.HostPort()
+	err := notify.NewSmtpAdapter(host, port, "", "", "dash@example.com").SendMail(
+		[]string{"manager@example.com"},
+		"Release digest: 2 services",
+		"plain body",
+		"<p>html body – with a long line that needs to be wrapped by the quoted printable encoding of the mail</p>",
+	)
+
+	assert.NoError(t, err)
+	assert.Len(t, sink.Messages, 1)
+	assert.Equal(t, "dash@example.com", sink.Messages[0].From)
+	assert.Equal(t, []string{"manager@example.com"}, sink.Messages[0].Recipients)
+
+	parsed, subject, textBody, htmlBody := readSinkMessage(t, sink.Messages[0])
+	assert.Equal(t, "dash@example.com", parsed.Header.Get("From"))
+	assert.Equal(t, "manager@example.com", parsed.Header.Get("To"))
+	assert.Equal(t, "Release digest: 2 services", subject)
+	assert.Equal(t, "plain body", textBody)
+	assert.Equal(t, "<p>html body – with a long line that needs to be wrapped by the quoted printable encoding of the mail</p>", htmlBody)
+}
+
+func TestSmtpAdapterSendMailError(t *testing.T) {
+	sink := newSmtpSink(t)
+	host, port := sink.HostPort()
+	sink.Close()
+
+	err := notify.NewSmtpAdapter(host, port, "", "", "dash@example.com").SendMail([]string{"manager@example.com"}, "s", "t", "h")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Could not send mail to manager@example.com")
+}
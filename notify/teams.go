@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+)
+
+// TeamsAdapter posts a message card to a Microsoft Teams incoming webhook,
+// teams or channels with their own webhook in TeamWebhookUrls are posted there
+// instead.
+type TeamsAdapter struct {
+	TeamWebhookUrls map[string]string
+	WebhookUrl      string
+}
+
+type teamsPayload struct {
+	Context         string         `json:"@context"`
+	PotentialAction []teamsAction  `json:"potentialAction,omitempty"`
+	Sections        []teamsSection `json:"sections"`
+	Summary         string         `json:"summary"`
+	ThemeColor      string         `json:"themeColor"`
+	Title           string         `json:"title"`
+	Type            string         `json:"@type"`
+}
+
+type teamsAction struct {
+	Name    string              `json:"name"`
+	Targets []teamsActionTarget `json:"targets"`
+	Type    string              `json:"@type"`
+}
+
+type teamsActionTarget struct {
+	Os  string `json:"os"`
+	Uri string `json:"uri"`
+}
+
+type teamsSection struct {
+	Facts []teamsFact `json:"facts"`
+	Text  string      `json:"text"`
+}
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func NewTeamsAdapter(webhookUrl string, teamWebhookUrls map[string]string) *TeamsAdapter {
+	adapter := TeamsAdapter{
+		TeamWebhookUrls: teamWebhookUrls,
+		WebhookUrl:      webhookUrl,
+	}
+
+	return &adapter
+}
+
+func (a *TeamsAdapter) Send(ctx context.Context, notification Notification) error {
+	webhookUrl := teamWebhookUrl(a.WebhookUrl, a.TeamWebhookUrls, notification)
+	if webhookUrl == "" {
+		return errNoWebhookUrl
+	}
+
+	themeColor := "FFA000"
+	if notification.Staleness == dashboard.StalenessRed {
+		themeColor = "E53935"
+	}
+
+	var facts []teamsFact
+	if notification.Team != "" {
+		facts = append(facts, teamsFact{Name: "Team", Value: notification.Team})
+	}
+	if notification.Channel != "" {
+		facts = append(facts, teamsFact{Name: "Channel", Value: notification.Channel})
+	}
+	facts = append(facts, teamsFact{Name: "Repo", Value: notification.Owner + "/" + notification.Repo})
+
+	payload := teamsPayload{
+		Context:    "https://schema.org/extensions",
+		Sections:   []teamsSection{{Facts: facts, Text: notification.Summary()}},
+		Summary:    notification.Title(),
+		ThemeColor: themeColor,
+		Title:      notification.Title(),
+		Type:       "MessageCard",
+	}
+	if notification.Url != "" {
+		payload.PotentialAction = []teamsAction{{
+			Name:    "View on dashboard",
+			Targets: []teamsActionTarget{{Os: "default", Uri: notification.Url}},
+			Type:    "OpenUri",
+		}}
+	}
+
+	return postJson(ctx, webhookUrl, payload)
+}
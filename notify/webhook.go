@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"context"
+)
+
+const EventStaleness = "staleness"
+
+// WebhookAdapter posts the notification as JSON to any endpoint, receivers
+// can route on the team and channel fields.
+type WebhookAdapter struct {
+	Url string
+}
+
+type webhookPayload struct {
+	Event string `json:"event"`
+	Notification
+	Summary string `json:"summary"`
+	Title   string `json:"title"`
+}
+
+func NewWebhookAdapter(url string) *WebhookAdapter {
+	adapter := WebhookAdapter{
+		Url: url,
+	}
+
+	return &adapter
+}
+
+func (a *WebhookAdapter) Send(ctx context.Context, notification Notification) error {
+	return postJson(ctx, a.Url, webhookPayload{
+		Event:        EventStaleness,
+		Notification: notification,
+		Summary:      notification.Summary(),
+		Title:        notification.Title(),
+	})
+}
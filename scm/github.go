@@ -5,6 +5,9 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/url"
+	"path"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/die-net/lrucache"
@@ -68,6 +71,39 @@ func CheckForRetry(resp *github.Response, err error) error {
 	return nil
 }
 
+func (c *GithubAdapter) CreateRelease(ctx context.Context, owner string, repo string, release ScmRelease) (*ScmRelease, error) {
+	log.Debug().Msgf("Creating release %s for repo %s/%s", release.TagName, owner, repo)
+
+	var resp *github.Response
+	var createdRelease *github.RepositoryRelease
+
+	err := c.Retrier.Run(func() error {
+		var errReq error
+		createdRelease, resp, errReq = c.Client.Repositories.CreateRelease(ctx, owner, repo, &github.RepositoryRelease{
+			Body:            github.String(release.Body),
+			Draft:           github.Bool(release.Draft),
+			Name:            github.String(release.Name),
+			TagName:         github.String(release.TagName),
+			TargetCommitish: github.String(release.TargetCommitish),
+		})
+		return CheckForRetry(resp, errReq)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not create release: %s", err)
+	}
+
+	scmRelease := ScmRelease{
+		Body:            createdRelease.GetBody(),
+		Draft:           createdRelease.GetDraft(),
+		HtmlUrl:         createdRelease.GetHTMLURL(),
+		Name:            createdRelease.GetName(),
+		TagName:         createdRelease.GetTagName(),
+		TargetCommitish: createdRelease.GetTargetCommitish(),
+	}
+	return &scmRelease, nil
+}
+
 func (c *GithubAdapter) GetChangelogForBranches(ctx context.Context, owner string, repo string, fromBranch string, toBranch string) (*[]ScmCommit, error) {
 	log.Debug().Msgf("Grabbing changelog for repo %s/%s, from-branch %s, to-branch %s", owner, repo, fromBranch, toBranch)
 
@@ -117,6 +153,14 @@ func (c *GithubAdapter) GetChangelogForRefs(ctx context.Context, owner string, r
 	return allScmCommits, nil
 }
 
+// GetChangelogForRefNames compares any two branches, tags or commit shas
+// without resolving them first.
+func (c *GithubAdapter) GetChangelogForRefNames(ctx context.Context, owner string, repo string, fromRef string, toRef string) (*[]ScmCommit, error) {
+	log.Debug().Msgf("Grabbing changelog for repo %s/%s, from-ref %s, to-ref %s", owner, repo, fromRef, toRef)
+
+	return c.GetRepoCompareCommits(ctx, owner, repo, fromRef, toRef)
+}
+
 func (c *GithubAdapter) GetChangelogForTags(ctx context.Context, owner string, repo string, fromTag string, toTag string) (*[]ScmCommit, error) {
 	log.Debug().Msgf("Grabbing changelog for repo %s/%s, from-tag %s, to-tag %s", owner, repo, fromTag, toTag)
 
@@ -202,17 +246,211 @@ func (c *GithubAdapter) GetRepoCompareCommits(ctx context.Context, owner string,
 
 	var allScmCommits []ScmCommit
 	for _, commit := range comparison.Commits {
-		scmCommit := ScmCommit{
-			Message: *commit.Commit.Message,
-			HtmlUrl: *commit.HTMLURL,
+		allScmCommits = append(allScmCommits, newScmCommit(commit))
+	}
+	return &allScmCommits, nil
+
+}
+
+func (c *GithubAdapter) GetCommit(ctx context.Context, owner string, repo string, sha string) (*ScmCommit, error) {
+	var resp *github.Response
+	var commit *github.RepositoryCommit
+
+	err := c.Retrier.Run(func() error {
+		var errReq error
+		commit, resp, errReq = c.Client.Repositories.GetCommit(ctx, owner, repo, sha)
+		return CheckForRetry(resp, errReq)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not get repo commit: %s", err)
+	}
+
+	scmCommit := newScmCommit(commit)
+	for _, file := range commit.Files {
+		scmCommit.Files = append(scmCommit.Files, file.GetFilename())
+	}
+
+	return &scmCommit, nil
+}
+
+func (c *GithubAdapter) GetCommitPullRequests(ctx context.Context, owner string, repo string, sha string) ([]ScmPullRequest, error) {
+	var resp *github.Response
+	var pullRequests []*github.PullRequest
+
+	err := c.Retrier.Run(func() error {
+		var errReq error
+		pullRequests, resp, errReq = c.Client.PullRequests.ListPullRequestsWithCommit(ctx, owner, repo, sha, nil)
+		return CheckForRetry(resp, errReq)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not get pull requests for commit: %s", err)
+	}
+
+	var allScmPullRequests []ScmPullRequest
+	for _, pullRequest := range pullRequests {
+		allScmPullRequests = append(allScmPullRequests, newScmPullRequest(pullRequest))
+	}
+
+	return allScmPullRequests, nil
+}
+
+func (c *GithubAdapter) GetPullRequest(ctx context.Context, owner string, repo string, number int) (*ScmPullRequest, error) {
+	var resp *github.Response
+	var pullRequest *github.PullRequest
+
+	err := c.Retrier.Run(func() error {
+		var errReq error
+		pullRequest, resp, errReq = c.Client.PullRequests.Get(ctx, owner, repo, number)
+		return CheckForRetry(resp, errReq)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not get pull request: %s", err)
+	}
+
+	scmPullRequest := newScmPullRequest(pullRequest)
+	return &scmPullRequest, nil
+}
+
+// GetCompareStatus compares two refs without listing the commits between
+// them, used to check if one ref already contains another.
+func (c *GithubAdapter) GetCompareStatus(ctx context.Context, owner string, repo string, baseRef string, headRef string) (*ScmCompareStatus, error) {
+	var resp *github.Response
+	var comparison *github.CommitsComparison
+
+	err := c.Retrier.Run(func() error {
+		var errReq error
+		comparison, resp, errReq = c.Client.Repositories.CompareCommits(ctx, owner, repo, baseRef, headRef)
+		return CheckForRetry(resp, errReq)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not get repo comparison: %s", err)
+	}
+
+	return &ScmCompareStatus{
+		AheadBy:  comparison.GetAheadBy(),
+		BehindBy: comparison.GetBehindBy(),
+		Status:   comparison.GetStatus(),
+	}, nil
+}
+
+func (c *GithubAdapter) GetCommitStatus(ctx context.Context, owner string, repo string, ref string) (*ScmCommitStatus, error) {
+	var resp *github.Response
+	var combinedStatus *github.CombinedStatus
+
+	err := c.Retrier.Run(func() error {
+		var errReq error
+		combinedStatus, resp, errReq = c.Client.Repositories.GetCombinedStatus(ctx, owner, repo, ref, nil)
+		return CheckForRetry(resp, errReq)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not get combined status for ref: %s", err)
+	}
+
+	opt := &github.ListCheckRunsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var allCheckRuns []*github.CheckRun
+	for {
+		var checkRuns *github.ListCheckRunsResults
+		err = c.Retrier.Run(func() error {
+			var errReq error
+			checkRuns, resp, errReq = c.Client.Checks.ListCheckRunsForRef(ctx, owner, repo, ref, opt)
+			return CheckForRetry(resp, errReq)
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("Could not get check runs for ref: %s", err)
+		}
+
+		allCheckRuns = append(allCheckRuns, checkRuns.CheckRuns...)
+		if resp.NextPage == 0 {
+			break
 		}
-		if commit.Author != nil && commit.Author.AvatarURL != nil {
-			scmCommit.AuthorAvatarUrl = *commit.Author.AvatarURL
+		opt.Page = resp.NextPage
+	}
+
+	scmCommitStatus := ScmCommitStatus{}
+	for _, status := range combinedStatus.Statuses {
+		switch status.GetState() {
+		case "error", "failure":
+			scmCommitStatus.fail(status.GetContext(), status.GetTargetURL())
+		case "pending":
+			scmCommitStatus.pend()
+		default:
+			scmCommitStatus.succeed()
 		}
-		allScmCommits = append(allScmCommits, scmCommit)
 	}
-	return &allScmCommits, nil
+	for _, checkRun := range allCheckRuns {
+		if checkRun.GetStatus() != "completed" {
+			scmCommitStatus.pend()
+			continue
+		}
+		switch checkRun.GetConclusion() {
+		case "action_required", "cancelled", "failure", "timed_out":
+			scmCommitStatus.fail(checkRun.GetName(), checkRun.GetHTMLURL())
+		default:
+			scmCommitStatus.succeed()
+		}
+	}
+
+	return &scmCommitStatus, nil
+}
 
+func newScmPullRequest(pullRequest *github.PullRequest) ScmPullRequest {
+	scmPullRequest := ScmPullRequest{
+		AuthorAvatarUrl: pullRequest.GetUser().GetAvatarURL(),
+		AuthorLogin:     pullRequest.GetUser().GetLogin(),
+		HtmlUrl:         pullRequest.GetHTMLURL(),
+		Merged:          pullRequest.MergedAt != nil,
+		Number:          pullRequest.GetNumber(),
+		Title:           pullRequest.GetTitle(),
+	}
+	if scmPullRequest.Merged {
+		scmPullRequest.MergeCommitSha = pullRequest.GetMergeCommitSHA()
+	}
+	for _, label := range pullRequest.Labels {
+		scmPullRequest.Labels = append(scmPullRequest.Labels, label.GetName())
+	}
+	return scmPullRequest
+}
+
+func newScmCommit(commit *github.RepositoryCommit) ScmCommit {
+	scmCommit := ScmCommit{
+		AuthorLogin: commit.GetAuthor().GetLogin(),
+		AuthorName:  commit.GetCommit().GetAuthor().GetName(),
+		AuthoredAt:  commit.GetCommit().GetAuthor().GetDate(),
+		IsMerge:     len(commit.Parents) > 1,
+		Message:     commit.GetCommit().GetMessage(),
+		HtmlUrl:     commit.GetHTMLURL(),
+		Sha:         commit.GetSHA(),
+	}
+	if commit.Author != nil && commit.Author.AvatarURL != nil {
+		scmCommit.AuthorAvatarUrl = *commit.Author.AvatarURL
+	}
+	return scmCommit
+}
+
+func (c *GithubAdapter) GetRepo(ctx context.Context, owner string, repo string) (*ScmRepository, error) {
+	var resp *github.Response
+	var repository *github.Repository
+
+	err := c.Retrier.Run(func() error {
+		var errReq error
+		repository, resp, errReq = c.Client.Repositories.Get(ctx, owner, repo)
+		return CheckForRetry(resp, errReq)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not get repo: %s", err)
+	}
+
+	scmRepo := newScmRepository(repository)
+	return &scmRepo, nil
 }
 
 func (c *GithubAdapter) GetRepoBranch(ctx context.Context, owner string, repo string, branchName string) (*ScmRef, error) {
@@ -238,6 +476,13 @@ func (c *GithubAdapter) GetRepoBranch(ctx context.Context, owner string, repo st
 }
 
 func (c *GithubAdapter) GetRepoFile(ctx context.Context, owner string, repo string, sha string, filePath string) ([]byte, error) {
+	content, _, err := c.FindRepoFile(ctx, owner, repo, sha, []string{filePath})
+	return content, err
+}
+
+// FindRepoFile returns the first of filePaths that exists in the repo along
+// with its path, the tree is only fetched once however many paths are given.
+func (c *GithubAdapter) FindRepoFile(ctx context.Context, owner string, repo string, sha string, filePaths []string) ([]byte, string, error) {
 	var repoTree *github.Tree
 	var resp *github.Response
 	err := c.Retrier.Run(func() error {
@@ -246,26 +491,33 @@ func (c *GithubAdapter) GetRepoFile(ctx context.Context, owner string, repo stri
 		return CheckForRetry(resp, errReq)
 	})
 	if err != nil {
-		return nil, fmt.Errorf("Could not get repo tree: %s", err)
+		return nil, "", fmt.Errorf("Could not get repo tree: %s", err)
 	}
 
+	treePaths := map[string]bool{}
 	for _, treeEntry := range repoTree.Entries {
-		if *treeEntry.Path == filePath {
-			content, _, _, err := c.Client.Repositories.GetContents(ctx, owner, repo, filePath, nil)
-			if err != nil {
-				return nil, fmt.Errorf("Could not get repo file contents: %s", err)
-			}
-
-			raw, err := base64.StdEncoding.DecodeString(*content.Content)
-			if err != nil {
-				return nil, fmt.Errorf("Could not decode repo file: %s", err)
-			}
-
-			return raw, nil
+		treePaths[treeEntry.GetPath()] = true
+	}
+
+	for _, filePath := range filePaths {
+		if !treePaths[filePath] {
+			continue
+		}
+
+		content, _, _, err := c.Client.Repositories.GetContents(ctx, owner, repo, filePath, nil)
+		if err != nil {
+			return nil, "", fmt.Errorf("Could not get repo file contents: %s", err)
 		}
+
+		raw, err := base64.StdEncoding.DecodeString(*content.Content)
+		if err != nil {
+			return nil, "", fmt.Errorf("Could not decode repo file: %s", err)
+		}
+
+		return raw, filePath, nil
 	}
 
-	return nil, nil
+	return nil, "", nil
 }
 
 func (c *GithubAdapter) GetRepoTag(ctx context.Context, owner string, repo string, tagName string) (*ScmRef, error) {
@@ -298,6 +550,107 @@ func (c *GithubAdapter) GetRepoTag(ctx context.Context, owner string, repo strin
 	return &scmRef, nil
 }
 
+// GetRepoTags lists the tags whose names match a path.Match pattern such as
+// prod-*, oldest first.
+func (c *GithubAdapter) GetRepoTags(ctx context.Context, owner string, repo string, pattern string) ([]ScmTag, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("Could not match tag pattern %s: %s", pattern, err)
+	}
+
+	// Only the literal prefix of the pattern can be matched by the API
+	prefix := pattern
+	if index := strings.IndexAny(pattern, "*?[\\"); index >= 0 {
+		prefix = pattern[:index]
+	}
+
+	opts := &github.ReferenceListOptions{
+		Ref:         "tags/" + prefix,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var allRefs []*github.Reference
+	var refs []*github.Reference
+	var resp *github.Response
+	for {
+		err := c.Retrier.Run(func() error {
+			var errReq error
+			refs, resp, errReq = c.Client.Git.ListMatchingRefs(ctx, owner, repo, opts)
+			return CheckForRetry(resp, errReq)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Could not get repo tags: %s", err)
+		}
+		allRefs = append(allRefs, refs...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	var allScmTags []ScmTag
+	for _, ref := range allRefs {
+		tagName := strings.TrimPrefix(*ref.Ref, "refs/tags/")
+		if matched, _ := path.Match(pattern, tagName); !matched {
+			continue
+		}
+
+		scmTag, err := c.getScmTag(ctx, owner, repo, tagName, ref.Object)
+		if err != nil {
+			return nil, err
+		}
+		allScmTags = append(allScmTags, *scmTag)
+	}
+
+	sort.SliceStable(allScmTags, func(i, j int) bool {
+		if !allScmTags[i].TaggedAt.Equal(allScmTags[j].TaggedAt) {
+			return allScmTags[i].TaggedAt.Before(allScmTags[j].TaggedAt)
+		}
+		return allScmTags[i].Name < allScmTags[j].Name
+	})
+
+	return allScmTags, nil
+}
+
+// getScmTag follows a tag ref to its commit, the first tagger date found is
+// kept so that re-tagging an old commit is dated when the tag was made.
+func (c *GithubAdapter) getScmTag(ctx context.Context, owner string, repo string, tagName string, gitObj *github.GitObject) (*ScmTag, error) {
+	scmTag := ScmTag{Name: tagName}
+	for *gitObj.Type == "tag" {
+		var tag *github.Tag
+		var resp *github.Response
+		err := c.Retrier.Run(func() error {
+			var errReq error
+			tag, resp, errReq = c.Client.Git.GetTag(ctx, owner, repo, *gitObj.SHA)
+			return CheckForRetry(resp, errReq)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Could not get tag %s for repo: %s", tagName, err)
+		}
+		if scmTag.TaggedAt.IsZero() && tag.Tagger != nil && tag.Tagger.Date != nil {
+			scmTag.TaggedAt = *tag.Tagger.Date
+		}
+		gitObj = tag.Object
+	}
+	scmTag.Sha = *gitObj.SHA
+
+	if scmTag.TaggedAt.IsZero() {
+		var commit *github.Commit
+		var resp *github.Response
+		err := c.Retrier.Run(func() error {
+			var errReq error
+			commit, resp, errReq = c.Client.Git.GetCommit(ctx, owner, repo, scmTag.Sha)
+			return CheckForRetry(resp, errReq)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Could not get commit for tag %s: %s", tagName, err)
+		}
+		if commit.Committer != nil && commit.Committer.Date != nil {
+			scmTag.TaggedAt = *commit.Committer.Date
+		}
+	}
+
+	return &scmTag, nil
+}
+
 func (c *GithubAdapter) GetUserRepos(ctx context.Context, user string) ([]ScmRepository, error) {
 	opts := &github.RepositoryListOptions{
 		ListOptions: github.ListOptions{PerPage: 100},
@@ -324,14 +677,17 @@ func (c *GithubAdapter) GetUserRepos(ctx context.Context, user string) ([]ScmRep
 	var allScmRepos []ScmRepository
 
 	for _, repo := range allRepos {
-		scmRepo := ScmRepository{
-			DefaultBranch: *repo.DefaultBranch,
-			HtmlUrl:       *repo.HTMLURL,
-			Name:          *repo.Name,
-			OwnerName:     *repo.Owner.Login,
-		}
-		allScmRepos = append(allScmRepos, scmRepo)
+		allScmRepos = append(allScmRepos, newScmRepository(repo))
 	}
 
 	return allScmRepos, nil
 }
+
+func newScmRepository(repo *github.Repository) ScmRepository {
+	return ScmRepository{
+		DefaultBranch: *repo.DefaultBranch,
+		HtmlUrl:       *repo.HTMLURL,
+		Name:          *repo.Name,
+		OwnerName:     *repo.Owner.Login,
+	}
+}
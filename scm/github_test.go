@@ -73,13 +73,19 @@ func TestGetChangelogForBranchesHasChanges(t *testing.T) {
 	expectedChangelog := []scm.ScmCommit{
 		{
 			AuthorAvatarUrl: "a",
+			AuthorLogin:     "l",
+			AuthorName:      "n",
 			Message:         "test-commit",
 			HtmlUrl:         "h",
+			Sha:             "s",
 		},
 		{
 			AuthorAvatarUrl: "a",
+			AuthorLogin:     "l",
+			AuthorName:      "n",
 			Message:         "test-commit",
 			HtmlUrl:         "h",
+			Sha:             "s",
 		},
 	}
 
@@ -87,6 +93,56 @@ func TestGetChangelogForBranchesHasChanges(t *testing.T) {
 	assert.Equal(t, &expectedChangelog, changelog)
 }
 
+func TestGetChangelogForRefNamesHasChanges(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	owner := "o"
+	repo := "test-repo"
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	changelog, err := githubAdapter.GetChangelogForRefNames(ctx, owner, repo, "prod", "stg")
+
+	expectedCommit := scm.ScmCommit{
+		AuthorAvatarUrl: "a",
+		AuthorLogin:     "l",
+		AuthorName:      "n",
+		Message:         "test-commit",
+		HtmlUrl:         "h",
+		Sha:             "s",
+	}
+	expectedChangelog := []scm.ScmCommit{expectedCommit, expectedCommit}
+
+	assert.NoError(t, err)
+	assert.Equal(t, &expectedChangelog, changelog)
+}
+
+func TestGetChangelogForRefNamesError(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	owner := "o"
+	repo := "500"
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	changelog, err := githubAdapter.GetChangelogForRefNames(ctx, owner, repo, "prod", "stg")
+
+	assert.Error(t, err)
+	assert.Nil(t, changelog)
+}
+
 func TestGetChangelogForTagsHasChanges(t *testing.T) {
 	client, teardown := testsupport.SetupGithubClientMock()
 	defer teardown()
@@ -108,13 +164,19 @@ func TestGetChangelogForTagsHasChanges(t *testing.T) {
 	expectedChangelog := []scm.ScmCommit{
 		{
 			AuthorAvatarUrl: "a",
+			AuthorLogin:     "l",
+			AuthorName:      "n",
 			Message:         "test-commit",
 			HtmlUrl:         "h",
+			Sha:             "s",
 		},
 		{
 			AuthorAvatarUrl: "a",
+			AuthorLogin:     "l",
+			AuthorName:      "n",
 			Message:         "test-commit",
 			HtmlUrl:         "h",
+			Sha:             "s",
 		},
 	}
 
@@ -143,13 +205,19 @@ func TestGetChangelogForTagsHasChangesMissingFromTag(t *testing.T) {
 	expectedChangelog := []scm.ScmCommit{
 		{
 			AuthorAvatarUrl: "a",
+			AuthorLogin:     "l",
+			AuthorName:      "n",
 			Message:         "test-commit",
 			HtmlUrl:         "h",
+			Sha:             "s",
 		},
 		{
 			AuthorAvatarUrl: "a",
+			AuthorLogin:     "l",
+			AuthorName:      "n",
 			Message:         "test-commit",
 			HtmlUrl:         "h",
+			Sha:             "s",
 		},
 	}
 
@@ -301,13 +369,19 @@ func TestGetRepoCompareCommitsHasCommits(t *testing.T) {
 	expectedComparison := []scm.ScmCommit{
 		{
 			AuthorAvatarUrl: "a",
+			AuthorLogin:     "l",
+			AuthorName:      "n",
 			Message:         "test-commit",
 			HtmlUrl:         "h",
+			Sha:             "s",
 		},
 		{
 			AuthorAvatarUrl: "a",
+			AuthorLogin:     "l",
+			AuthorName:      "n",
 			Message:         "test-commit",
 			HtmlUrl:         "h",
+			Sha:             "s",
 		},
 	}
 
@@ -339,6 +413,46 @@ func TestGetRepoCompareCommitsError(t *testing.T) {
 	assert.Nil(t, comparison)
 }
 
+func TestGetRepo(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	expectedScmRepo := scm.ScmRepository{
+		DefaultBranch: "master",
+		HtmlUrl:       "url",
+		Name:          "test-repo",
+		OwnerName:     "o",
+	}
+
+	scmRepo, err := githubAdapter.GetRepo(ctx, "o", "test-repo")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &expectedScmRepo, scmRepo)
+}
+
+func TestGetRepoError(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	_, err := githubAdapter.GetRepo(ctx, "o", "500")
+
+	assert.Error(t, err)
+}
+
 func TestGetRepoBranchHasBranch(t *testing.T) {
 	client, teardown := testsupport.SetupGithubClientMock()
 	defer teardown()
@@ -433,6 +547,55 @@ func TestGetRepoFileMissingFile(t *testing.T) {
 	assert.Nil(t, repoFile)
 }
 
+func TestFindRepoFileHasFile(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	repo := "test-repo"
+	owner := "o"
+	sha := "s"
+	paths := []string{".github/releasedash.yml", ".releasedash.yml"}
+	content := "LS0tCgplbnZpcm9ubWVudF90YWdzOgogIC0gZnJvbS10YWcKICAtIHRvLXRhZwpuYW1lOiByCg=="
+
+	expectedRepoFile, _ := base64.StdEncoding.DecodeString(content)
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	repoFile, repoFilePath, err := githubAdapter.FindRepoFile(ctx, owner, repo, sha, paths)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRepoFile, repoFile)
+	assert.Equal(t, ".releasedash.yml", repoFilePath)
+}
+
+func TestFindRepoFileMissingFile(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	repo := "missingfile"
+	owner := "o"
+	sha := "s"
+	paths := []string{".releasedash.yml", ".releasedash.json"}
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	repoFile, repoFilePath, err := githubAdapter.FindRepoFile(ctx, owner, repo, sha, paths)
+
+	assert.NoError(t, err)
+	assert.Nil(t, repoFile)
+	assert.Equal(t, "", repoFilePath)
+}
+
 func TestGetRepoTagHasTag(t *testing.T) {
 	client, teardown := testsupport.SetupGithubClientMock()
 	defer teardown()
@@ -502,6 +665,71 @@ func TestGetRepoTagError(t *testing.T) {
 	assert.Nil(t, scmTag)
 }
 
+func TestGetRepoTagsHasTags(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	owner := "o"
+	repo := "test-repo"
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	scmTags, err := githubAdapter.GetRepoTags(ctx, owner, repo, "prod-?")
+
+	expectedScmTags := []scm.ScmTag{
+		{
+			Name:     "prod-1",
+			Sha:      "1f2e3d4c5b6a7980a1b2c3d4e5f60718293a4b5c",
+			TaggedAt: time.Date(2020, time.October, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			Name:     "prod-2",
+			Sha:      "9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b",
+			TaggedAt: time.Date(2020, time.October, 5, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedScmTags, scmTags)
+}
+
+func TestGetRepoTagsBadPattern(t *testing.T) {
+	githubAdapter := scm.GithubAdapter{
+		Client:  github.NewClient(nil),
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	scmTags, err := githubAdapter.GetRepoTags(context.Background(), "o", "test-repo", "prod-[")
+
+	assert.Error(t, err)
+	assert.Nil(t, scmTags)
+}
+
+func TestGetRepoTagsError(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	owner := "o"
+	repo := "500"
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	scmTags, err := githubAdapter.GetRepoTags(ctx, owner, repo, "prod-*")
+
+	assert.Error(t, err)
+	assert.Nil(t, scmTags)
+}
+
 func TestUserReposHasRepos(t *testing.T) {
 	client, teardown := testsupport.SetupGithubClientMock()
 	defer teardown()
@@ -547,3 +775,321 @@ func TestUserReposListError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, scmRepos)
 }
+
+func TestGetCommitHasFiles(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	owner := "o"
+	repo := "test-repo"
+	sha := "3e0f3d8c432ca2a03a3222fb55de63934338022f"
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	commit, err := githubAdapter.GetCommit(ctx, owner, repo, sha)
+
+	expectedCommit := scm.ScmCommit{
+		AuthorAvatarUrl: "a",
+		AuthorLogin:     "l",
+		AuthorName:      "n",
+		AuthoredAt:      time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC),
+		Message:         "test-commit",
+		HtmlUrl:         "h",
+		Sha:             sha,
+		Files:           []string{"services/a/main.go", "README.md"},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, &expectedCommit, commit)
+}
+
+func TestGetCommitError(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	owner := "o"
+	repo := "500"
+	sha := "3e0f3d8c432ca2a03a3222fb55de63934338022f"
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	commit, err := githubAdapter.GetCommit(ctx, owner, repo, sha)
+
+	assert.Error(t, err)
+	assert.Nil(t, commit)
+}
+
+func TestGetCommitPullRequestsHasPullRequests(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	owner := "o"
+	repo := "test-repo"
+	sha := "3e0f3d8c432ca2a03a3222fb55de63934338022f"
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	pullRequests, err := githubAdapter.GetCommitPullRequests(ctx, owner, repo, sha)
+
+	expectedPullRequests := []scm.ScmPullRequest{
+		{
+			AuthorAvatarUrl: "a",
+			AuthorLogin:     "l",
+			HtmlUrl:         "https://github.com/o/test-repo/pull/12",
+			Labels:          []string{"feature", "payments"},
+			Merged:          true,
+			Number:          12,
+			Title:           "Add refunds",
+		},
+		{
+			AuthorLogin: "l",
+			HtmlUrl:     "https://github.com/o/test-repo/pull/13",
+			Number:      13,
+			Title:       "Draft work",
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedPullRequests, pullRequests)
+}
+
+func TestGetCommitPullRequestsError(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	owner := "o"
+	repo := "500"
+	sha := "3e0f3d8c432ca2a03a3222fb55de63934338022f"
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	pullRequests, err := githubAdapter.GetCommitPullRequests(ctx, owner, repo, sha)
+
+	assert.Error(t, err)
+	assert.Nil(t, pullRequests)
+}
+
+func TestGetCommitStatus(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	owner := "o"
+	repo := "test-repo"
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	tests := map[string]scm.ScmCommitStatus{
+		"failing": {
+			FailureName: "ci/deploy",
+			FailureUrl:  "https://ci/deploy/1",
+			State:       scm.CommitStatusFailure,
+		},
+		// The failing check run is on the second page
+		"paged": {
+			FailureName: "deploy",
+			FailureUrl:  "https://github.com/o/test-repo/runs/6",
+			State:       scm.CommitStatusFailure,
+		},
+		"pending":   {State: scm.CommitStatusPending},
+		"passing":   {State: scm.CommitStatusSuccess},
+		"unchecked": {},
+	}
+
+	for ref, expectedStatus := range tests {
+		status, err := githubAdapter.GetCommitStatus(ctx, owner, repo, ref)
+
+		assert.NoError(t, err, ref)
+		assert.Equal(t, &expectedStatus, status, ref)
+	}
+}
+
+func TestGetCommitStatusError(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	owner := "o"
+	repo := "500"
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	status, err := githubAdapter.GetCommitStatus(ctx, owner, repo, "passing")
+
+	assert.Error(t, err)
+	assert.Nil(t, status)
+}
+
+func TestCreateRelease(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	owner := "o"
+	repo := "test-repo"
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	release, err := githubAdapter.CreateRelease(ctx, owner, repo, scm.ScmRelease{
+		Body:            "# app: stg > prod",
+		Draft:           true,
+		Name:            "v1.2.0",
+		TagName:         "v1.2.0",
+		TargetCommitish: "3e0f3d8c432ca2a03a3222fb55de63934338022f",
+	})
+
+	expectedRelease := scm.ScmRelease{
+		Body:            "# app: stg > prod",
+		Draft:           true,
+		HtmlUrl:         "https://github.com/o/test-repo/releases/tag/untagged-1",
+		Name:            "v1.2.0",
+		TagName:         "v1.2.0",
+		TargetCommitish: "3e0f3d8c432ca2a03a3222fb55de63934338022f",
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, &expectedRelease, release)
+}
+
+func TestCreateReleaseError(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	owner := "o"
+	repo := "500"
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	release, err := githubAdapter.CreateRelease(ctx, owner, repo, scm.ScmRelease{TagName: "v1.2.0"})
+
+	assert.Error(t, err)
+	assert.Nil(t, release)
+}
+
+func TestGetPullRequest(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	pullRequest, err := githubAdapter.GetPullRequest(ctx, "o", "test-repo", 12)
+
+	expectedPullRequest := scm.ScmPullRequest{
+		AuthorAvatarUrl: "a",
+		AuthorLogin:     "l",
+		HtmlUrl:         "https://github.com/o/test-repo/pull/12",
+		Labels:          []string{"payments"},
+		MergeCommitSha:  "3e0f3d8c432ca2a03a3222fb55de63934338022f",
+		Merged:          true,
+		Number:          12,
+		Title:           "Add refunds",
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, &expectedPullRequest, pullRequest)
+}
+
+func TestGetPullRequestError(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	pullRequest, err := githubAdapter.GetPullRequest(ctx, "o", "500", 12)
+
+	assert.Error(t, err)
+	assert.Nil(t, pullRequest)
+}
+
+func TestGetCompareStatus(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	compareStatus, err := githubAdapter.GetCompareStatus(ctx, "o", "test-repo", "3e0f3d8c432ca2a03a3222fb55de63934338022f", "prod")
+
+	expectedCompareStatus := scm.ScmCompareStatus{
+		AheadBy: 2,
+		Status:  scm.CompareStatusAhead,
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, &expectedCompareStatus, compareStatus)
+	assert.True(t, compareStatus.HeadContainsBase())
+}
+
+func TestGetCompareStatusError(t *testing.T) {
+	client, teardown := testsupport.SetupGithubClientMock()
+	defer teardown()
+
+	githubAdapter := scm.GithubAdapter{
+		Client:  client,
+		Retrier: retry.NewRetrier(5, 5*time.Second, 30*time.Second),
+	}
+
+	ctx := context.Background()
+
+	compareStatus, err := githubAdapter.GetCompareStatus(ctx, "o", "500", "3e0f3d8c432ca2a03a3222fb55de63934338022f", "prod")
+
+	assert.Error(t, err)
+	assert.Nil(t, compareStatus)
+}
+
+func TestScmCompareStatusHeadContainsBase(t *testing.T) {
+	assert.True(t, scm.ScmCompareStatus{Status: scm.CompareStatusIdentical}.HeadContainsBase())
+	assert.False(t, scm.ScmCompareStatus{Status: scm.CompareStatusBehind}.HeadContainsBase())
+	assert.False(t, scm.ScmCompareStatus{Status: scm.CompareStatusDiverged}.HeadContainsBase())
+}
@@ -2,21 +2,97 @@ package scm
 
 import (
 	"context"
+	"strings"
+	"time"
 )
 
 //go:generate go run -mod=mod github.com/golang/mock/mockgen --build_flags=-mod=mod --source=scm.go --destination=../mocks/scm/scm.go
 type ScmAdapter interface {
+	CreateRelease(ctx context.Context, owner string, repo string, release ScmRelease) (*ScmRelease, error)
 	GetChangelogForBranches(ctx context.Context, owner string, repo string, fromBranch string, toBranch string) (*[]ScmCommit, error)
+	GetChangelogForRefs(ctx context.Context, owner string, repo string, refFrom *ScmRef, refTo *ScmRef) (*[]ScmCommit, error)
+	GetChangelogForRefNames(ctx context.Context, owner string, repo string, fromRef string, toRef string) (*[]ScmCommit, error)
 	GetChangelogForTags(ctx context.Context, owner string, repo string, fromTag string, toTag string) (*[]ScmCommit, error)
+	GetCommit(ctx context.Context, owner string, repo string, sha string) (*ScmCommit, error)
+	GetCommitPullRequests(ctx context.Context, owner string, repo string, sha string) ([]ScmPullRequest, error)
+	GetCommitStatus(ctx context.Context, owner string, repo string, ref string) (*ScmCommitStatus, error)
+	GetCompareStatus(ctx context.Context, owner string, repo string, baseRef string, headRef string) (*ScmCompareStatus, error)
+	GetPullRequest(ctx context.Context, owner string, repo string, number int) (*ScmPullRequest, error)
+	GetRepo(ctx context.Context, owner string, repo string) (*ScmRepository, error)
 	GetRepoBranch(ctx context.Context, owner string, repo string, branchName string) (*ScmRef, error)
 	GetRepoFile(ctx context.Context, owner string, repo string, sha string, filePath string) ([]byte, error)
+	FindRepoFile(ctx context.Context, owner string, repo string, sha string, filePaths []string) ([]byte, string, error)
+	GetRepoTag(ctx context.Context, owner string, repo string, tagName string) (*ScmRef, error)
+	GetRepoTags(ctx context.Context, owner string, repo string, pattern string) ([]ScmTag, error)
 	GetUserRepos(ctx context.Context, user string) ([]ScmRepository, error)
 }
 
 type ScmCommit struct {
 	AuthorAvatarUrl string
+	AuthorLogin     string
+	AuthorName      string
+	AuthoredAt      time.Time
+	IsMerge         bool
 	Message         string
 	HtmlUrl         string
+	Sha             string
+	// Files is only populated when a single commit is fetched
+	Files []string
+}
+
+const (
+	CommitStatusFailure = "failure"
+	CommitStatusPending = "pending"
+	CommitStatusSuccess = "success"
+)
+
+// ScmCommitStatus combines the statuses and check runs for a commit, State is
+// empty if the commit has neither.
+type ScmCommitStatus struct {
+	FailureName string
+	FailureUrl  string
+	State       string
+}
+
+const (
+	CompareStatusAhead     = "ahead"
+	CompareStatusBehind    = "behind"
+	CompareStatusDiverged  = "diverged"
+	CompareStatusIdentical = "identical"
+)
+
+// ScmCompareStatus describes how the head of a comparison relates to the base.
+type ScmCompareStatus struct {
+	AheadBy  int
+	BehindBy int
+	Status   string
+}
+
+// HeadContainsBase checks if the base is an ancestor of, or the same as, the head.
+func (s ScmCompareStatus) HeadContainsBase() bool {
+	return s.Status == CompareStatusAhead || s.Status == CompareStatusIdentical
+}
+
+type ScmPullRequest struct {
+	AuthorAvatarUrl string
+	AuthorLogin     string
+	HtmlUrl         string
+	Labels          []string
+	// MergeCommitSha is the commit that the pull request landed as, empty
+	// until merged
+	MergeCommitSha string
+	Merged         bool
+	Number         int
+	Title          string
+}
+
+type ScmRelease struct {
+	Body            string
+	Draft           bool
+	HtmlUrl         string
+	Name            string
+	TagName         string
+	TargetCommitish string
 }
 
 type ScmRef struct {
@@ -24,9 +100,46 @@ type ScmRef struct {
 	Name        string
 }
 
+// ScmTag is a tag along with the time it was made, the tagger date for
+// annotated tags and the commit date for lightweight tags.
+type ScmTag struct {
+	Name     string
+	Sha      string
+	TaggedAt time.Time
+}
+
 type ScmRepository struct {
 	DefaultBranch string
 	HtmlUrl       string
 	Name          string
 	OwnerName     string
 }
+
+func (r ScmRepository) FileHtmlUrl(filePath string) string {
+	return strings.TrimSuffix(r.HtmlUrl, "/") + "/blob/" + r.DefaultBranch + "/" + filePath
+}
+
+func (r ScmRepository) CompareHtmlUrl(baseRef string, headRef string) string {
+	return strings.TrimSuffix(r.HtmlUrl, "/") + "/compare/" + baseRef + "..." + headRef
+}
+
+// fail keeps the first failure found, a failure overrides any other state.
+func (s *ScmCommitStatus) fail(name string, url string) {
+	if s.State != CommitStatusFailure {
+		s.FailureName = name
+		s.FailureUrl = url
+	}
+	s.State = CommitStatusFailure
+}
+
+func (s *ScmCommitStatus) pend() {
+	if s.State != CommitStatusFailure {
+		s.State = CommitStatusPending
+	}
+}
+
+func (s *ScmCommitStatus) succeed() {
+	if s.State == "" {
+		s.State = CommitStatusSuccess
+	}
+}
@@ -0,0 +1,469 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lobsterdore/release-dash/cache"
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+// The api types keep the JSON field names stable, they should only ever be
+// added to within a version of the api.
+
+type apiRepo struct {
+	ConfigError         string   `json:"config_error"`
+	ConfigPath          string   `json:"config_path"`
+	DefaultBranch       string   `json:"default_branch"`
+	DisplayMode         string   `json:"display_mode"`
+	EnvironmentBranches []string `json:"environment_branches"`
+	EnvironmentTags     []string `json:"environment_tags"`
+	Group               string   `json:"group"`
+	HtmlUrl             string   `json:"html_url"`
+	Labels              []string `json:"labels"`
+	Name                string   `json:"name"`
+	Owner               string   `json:"owner"`
+	Repo                string   `json:"repo"`
+	Team                string   `json:"team"`
+}
+
+type apiReposData struct {
+	Repos []apiRepo `json:"repos"`
+}
+
+type apiChangelog struct {
+	Breaking       bool             `json:"breaking"`
+	Commits        []apiCommit      `json:"commits"`
+	FromRef        string           `json:"from_ref"`
+	HiddenCommits  int              `json:"hidden_commits"`
+	Name           string           `json:"name"`
+	OldestCommitAt *time.Time       `json:"oldest_commit_at"`
+	PullRequests   []apiPullRequest `json:"pull_requests"`
+	Staleness      string           `json:"staleness"`
+	Status         *apiCommitStatus `json:"status"`
+	Tickets        []apiTicket      `json:"tickets"`
+	ToRef          string           `json:"to_ref"`
+}
+
+type apiChangelogsData struct {
+	Changelogs []apiChangelog `json:"changelogs"`
+}
+
+type apiCommit struct {
+	AuthorAvatarUrl string           `json:"author_avatar_url"`
+	AuthorLogin     string           `json:"author_login"`
+	AuthorName      string           `json:"author_name"`
+	Group           string           `json:"group"`
+	HtmlUrl         string           `json:"html_url"`
+	IsMerge         bool             `json:"is_merge"`
+	Message         string           `json:"message"`
+	Sha             string           `json:"sha"`
+	Status          *apiCommitStatus `json:"status"`
+}
+
+type apiCommitStatus struct {
+	FailureName string `json:"failure_name"`
+	FailureUrl  string `json:"failure_url"`
+	State       string `json:"state"`
+}
+
+type apiPullRequest struct {
+	AuthorLogin string   `json:"author_login"`
+	CommitShas  []string `json:"commit_shas"`
+	HtmlUrl     string   `json:"html_url"`
+	Labels      []string `json:"labels"`
+	Number      int      `json:"number"`
+	Title       string   `json:"title"`
+}
+
+type apiTicket struct {
+	CommitShas []string `json:"commit_shas"`
+	Key        string   `json:"key"`
+	Url        string   `json:"url"`
+}
+
+type apiSearchData struct {
+	Query   string            `json:"query"`
+	Results []apiSearchResult `json:"results"`
+}
+
+type apiSearchResult struct {
+	Commit  apiCommit          `json:"commit"`
+	Name    string             `json:"name"`
+	Owner   string             `json:"owner"`
+	Pending []apiSearchPending `json:"pending"`
+	Repo    string             `json:"repo"`
+	Tickets []apiTicket        `json:"tickets"`
+}
+
+type apiSearchPending struct {
+	FromRef string `json:"from_ref"`
+	ToRef   string `json:"to_ref"`
+}
+
+type apiLookupData struct {
+	Ref      string             `json:"ref"`
+	Services []apiLookupService `json:"services"`
+}
+
+type apiLookupService struct {
+	Commit       *apiCommit             `json:"commit"`
+	Environments []apiLookupEnvironment `json:"environments"`
+	Error        string                 `json:"error"`
+	Name         string                 `json:"name"`
+	PullRequest  *apiPullRequest        `json:"pull_request"`
+}
+
+type apiLookupEnvironment struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+type ApiHandler struct {
+	CacheService     cache.CacheAdapter
+	DashboardService dashboard.DashboardProvider
+}
+
+func NewApiHandler(dashboardService *dashboard.DashboardService, cacheService cache.CacheAdapter) *ApiHandler {
+	apiHandler := ApiHandler{
+		CacheService:     cacheService,
+		DashboardService: dashboardService,
+	}
+
+	return &apiHandler
+}
+
+// Http serves /api/v1/repos and /api/v1/repos/{owner}/{repo}/changelogs from
+// the cached dashboard data, /api/v1/repos/{owner}/{repo}/lookup?ref= checks
+// which environments contain a commit or pull request.
+func (h *ApiHandler) Http(respWriter http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(respWriter, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(request.URL.Path, "/api/v1/repos"), "/")
+	if path == "" {
+		h.repos(respWriter, request)
+		return
+	}
+
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) != 3 || pathParts[0] == "" || pathParts[1] == "" {
+		http.NotFound(respWriter, request)
+		return
+	}
+
+	switch pathParts[2] {
+	case "changelogs":
+		h.changelogs(respWriter, request, pathParts[0], pathParts[1])
+	case "lookup":
+		h.lookup(respWriter, request, pathParts[0], pathParts[1])
+	default:
+		http.NotFound(respWriter, request)
+	}
+}
+
+func (h *ApiHandler) repos(respWriter http.ResponseWriter, request *http.Request) {
+	cachedData, found := h.CacheService.Get("homepage_repo_data")
+	if !found {
+		http.Error(respWriter, "Dashboard repo data not present yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	data := apiReposData{Repos: []apiRepo{}}
+	for _, dashboardRepo := range cachedData.([]dashboard.DashboardRepo) {
+		data.Repos = append(data.Repos, newApiRepo(dashboardRepo))
+	}
+
+	writeApiJson(respWriter, request, data)
+}
+
+func (h *ApiHandler) changelogs(respWriter http.ResponseWriter, request *http.Request, owner string, repo string) {
+	cachedData, found := h.CacheService.Get("homepage_changelog_data")
+	if !found {
+		http.Error(respWriter, "Dashboard changelog data not present yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	service := request.URL.Query().Get("service")
+	data := apiChangelogsData{Changelogs: []apiChangelog{}}
+	repoFound := false
+	for _, repoChangelog := range cachedData.([]dashboard.DashboardRepoChangelog) {
+		if !strings.EqualFold(repoChangelog.Repository.OwnerName, owner) || !strings.EqualFold(repoChangelog.Repository.Name, repo) {
+			continue
+		}
+		if service != "" && !strings.EqualFold(repoChangelog.Config.Name, service) {
+			continue
+		}
+		repoFound = true
+		for _, changelogCommits := range repoChangelog.ChangelogCommits {
+			data.Changelogs = append(data.Changelogs, newApiChangelog(repoChangelog.Config.Name, changelogCommits))
+		}
+	}
+
+	if !repoFound {
+		http.NotFound(respWriter, request)
+		return
+	}
+
+	writeApiJson(respWriter, request, data)
+}
+
+func (h *ApiHandler) lookup(respWriter http.ResponseWriter, request *http.Request, owner string, repo string) {
+	query := request.URL.Query()
+	ref := strings.TrimSpace(query.Get("ref"))
+	if ref == "" {
+		http.Error(respWriter, "The ref query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	dashboardRepos, status := getRequestDashboardRepos(h.CacheService, owner, repo, query.Get("service"))
+	if status != http.StatusOK {
+		http.Error(respWriter, http.StatusText(status), status)
+		return
+	}
+
+	data := apiLookupData{Ref: ref, Services: []apiLookupService{}}
+	for _, result := range lookupDashboardCommit(request.Context(), h.DashboardService, dashboardRepos, ref) {
+		data.Services = append(data.Services, newApiLookupService(result))
+	}
+
+	writeApiJson(respWriter, request, data)
+}
+
+// Search serves /api/v1/search?q=, pending commits are matched on their
+// message, author, sha and ticket keys.
+func (h *ApiHandler) Search(respWriter http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(respWriter, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := strings.TrimSpace(request.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(respWriter, "The q query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	cachedData, found := h.CacheService.Get("homepage_changelog_data")
+	if !found {
+		http.Error(respWriter, "Dashboard changelog data not present yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	data := apiSearchData{Query: query, Results: []apiSearchResult{}}
+	for _, result := range dashboard.SearchDashboardRepoChangelogs(cachedData.([]dashboard.DashboardRepoChangelog), query) {
+		data.Results = append(data.Results, newApiSearchResult(result))
+	}
+
+	writeApiJson(respWriter, request, data)
+}
+
+// writeApiJson writes the data with an ETag of its content, a 304 is sent
+// instead if the client already has the same content.
+func writeApiJson(respWriter http.ResponseWriter, request *http.Request, data interface{}) {
+	responseBytes, err := json.Marshal(data)
+	if err != nil {
+		respWriter.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	hash := sha256.Sum256(responseBytes)
+	etag := `"` + hex.EncodeToString(hash[:]) + `"`
+
+	respWriter.Header().Set("Cache-Control", "no-cache")
+	respWriter.Header().Set("ETag", etag)
+	if etagMatches(request.Header.Get("If-None-Match"), etag) {
+		respWriter.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	respWriter.Header().Set("Content-Type", "application/json")
+	_, _ = respWriter.Write(responseBytes)
+}
+
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func newApiRepo(dashboardRepo dashboard.DashboardRepo) apiRepo {
+	displayMode := dashboardRepo.Config.DisplayMode
+	if displayMode == "" {
+		displayMode = dashboard.DisplayModeCommits
+	}
+	return apiRepo{
+		ConfigError:         dashboardRepo.ConfigError,
+		ConfigPath:          dashboardRepo.ConfigPath,
+		DefaultBranch:       dashboardRepo.Repository.DefaultBranch,
+		DisplayMode:         displayMode,
+		EnvironmentBranches: emptyIfNil(dashboardRepo.Config.EnvironmentBranches),
+		EnvironmentTags:     emptyIfNil(dashboardRepo.Config.EnvironmentTags),
+		Group:               dashboardRepo.Config.Group,
+		HtmlUrl:             dashboardRepo.Repository.HtmlUrl,
+		Labels:              emptyIfNil(dashboardRepo.Config.Labels),
+		Name:                dashboardRepo.Config.Name,
+		Owner:               dashboardRepo.Repository.OwnerName,
+		Repo:                dashboardRepo.Repository.Name,
+		Team:                dashboardRepo.Config.Team,
+	}
+}
+
+func newApiChangelog(name string, changelogCommits dashboard.DashboardChangelogCommits) apiChangelog {
+	changelog := apiChangelog{
+		Breaking:      changelogCommits.HasBreakingChanges(),
+		Commits:       []apiCommit{},
+		FromRef:       changelogCommits.FromRef,
+		HiddenCommits: changelogCommits.HiddenCommits,
+		Name:          name,
+		PullRequests:  []apiPullRequest{},
+		Staleness:     changelogCommits.Staleness,
+		Status:        newApiCommitStatus(changelogCommits.Status),
+		Tickets:       []apiTicket{},
+		ToRef:         changelogCommits.ToRef,
+	}
+
+	if oldestCommit := changelogCommits.OldestCommit(); oldestCommit != nil {
+		changelog.OldestCommitAt = &oldestCommit.AuthoredAt
+	}
+
+	commitGroups := map[string]string{}
+	for _, commitGroup := range changelogCommits.CommitGroups() {
+		for _, commit := range commitGroup.Commits {
+			commitGroups[commit.Sha] = commitGroup.Name
+		}
+	}
+
+	for _, commit := range changelogCommits.Commits {
+		var status *apiCommitStatus
+		if commitStatus, found := changelogCommits.CommitStatuses[commit.Sha]; found {
+			status = newApiCommitStatus(&commitStatus)
+		}
+		changelog.Commits = append(changelog.Commits, newApiCommit(commit, commitGroups[commit.Sha], status))
+	}
+
+	for _, pullRequest := range changelogCommits.PullRequests {
+		changelog.PullRequests = append(changelog.PullRequests, apiPullRequest{
+			AuthorLogin: pullRequest.AuthorLogin,
+			CommitShas:  emptyIfNil(pullRequest.CommitShas),
+			HtmlUrl:     pullRequest.HtmlUrl,
+			Labels:      emptyIfNil(pullRequest.Labels),
+			Number:      pullRequest.Number,
+			Title:       pullRequest.Title,
+		})
+	}
+
+	for _, ticket := range changelogCommits.Tickets {
+		changelog.Tickets = append(changelog.Tickets, apiTicket{
+			CommitShas: emptyIfNil(ticket.CommitShas),
+			Key:        ticket.Key,
+			Url:        ticket.Url,
+		})
+	}
+
+	return changelog
+}
+
+func newApiLookupService(result RepoLookupResult) apiLookupService {
+	lookupService := apiLookupService{
+		Environments: []apiLookupEnvironment{},
+		Error:        result.Error,
+		Name:         result.DashboardRepo.Config.Name,
+	}
+	if result.Location == nil {
+		return lookupService
+	}
+
+	if result.Location.Commit != nil {
+		commit := newApiCommit(*result.Location.Commit, "", nil)
+		lookupService.Commit = &commit
+	}
+	if pullRequest := result.Location.PullRequest; pullRequest != nil {
+		lookupService.PullRequest = &apiPullRequest{
+			AuthorLogin: pullRequest.AuthorLogin,
+			CommitShas:  []string{pullRequest.MergeCommitSha},
+			HtmlUrl:     pullRequest.HtmlUrl,
+			Labels:      emptyIfNil(pullRequest.Labels),
+			Number:      pullRequest.Number,
+			Title:       pullRequest.Title,
+		}
+	}
+	for _, environment := range result.Location.Environments {
+		lookupService.Environments = append(lookupService.Environments, apiLookupEnvironment{
+			Name:   environment.Name,
+			Status: environment.Status,
+		})
+	}
+	return lookupService
+}
+
+func newApiSearchResult(result dashboard.DashboardSearchResult) apiSearchResult {
+	searchResult := apiSearchResult{
+		Commit:  newApiCommit(result.Commit, result.Group, nil),
+		Name:    result.Config.Name,
+		Owner:   result.Repository.OwnerName,
+		Pending: []apiSearchPending{},
+		Repo:    result.Repository.Name,
+		Tickets: []apiTicket{},
+	}
+
+	for _, pending := range result.Pending {
+		searchResult.Pending = append(searchResult.Pending, apiSearchPending{
+			FromRef: pending.FromRef,
+			ToRef:   pending.ToRef,
+		})
+	}
+
+	for _, ticket := range result.Tickets {
+		searchResult.Tickets = append(searchResult.Tickets, apiTicket{
+			CommitShas: emptyIfNil(ticket.CommitShas),
+			Key:        ticket.Key,
+			Url:        ticket.Url,
+		})
+	}
+
+	return searchResult
+}
+
+func newApiCommit(commit scm.ScmCommit, group string, status *apiCommitStatus) apiCommit {
+	return apiCommit{
+		AuthorAvatarUrl: commit.AuthorAvatarUrl,
+		AuthorLogin:     commit.AuthorLogin,
+		AuthorName:      commit.AuthorName,
+		Group:           group,
+		HtmlUrl:         commit.HtmlUrl,
+		IsMerge:         commit.IsMerge,
+		Message:         commit.Message,
+		Sha:             commit.Sha,
+		Status:          status,
+	}
+}
+
+func newApiCommitStatus(status *scm.ScmCommitStatus) *apiCommitStatus {
+	if status == nil || status.State == "" {
+		return nil
+	}
+	return &apiCommitStatus{
+		FailureName: status.FailureName,
+		FailureUrl:  status.FailureUrl,
+		State:       status.State,
+	}
+}
+
+func emptyIfNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
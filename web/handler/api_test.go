@@ -0,0 +1,395 @@
+package handler_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/lobsterdore/release-dash/web/handler"
+
+	mock_cache "github.com/lobsterdore/release-dash/mocks/cache"
+	mock_dashboard "github.com/lobsterdore/release-dash/mocks/dashboard"
+)
+
+func serveApi(apiHandler handler.ApiHandler, method string, url string, ifNoneMatch string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(apiHandler.Http).ServeHTTP(rr, req)
+	return rr
+}
+
+func serveApiSearch(apiHandler handler.ApiHandler, method string, url string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(apiHandler.Search).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestApiRepos(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+
+	mockRepo := newMockReleaseNotesRepo()
+	mockRepo.ConfigPath = ".releasedash.yml"
+	mockRepo.Repository.DefaultBranch = "main"
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		AnyTimes().
+		Return([]dashboard.DashboardRepo{mockRepo}, true)
+
+	apiHandler := handler.ApiHandler{CacheService: mockCacheService}
+
+	rr := serveApi(apiHandler, "GET", "/api/v1/repos", "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"repos": [{
+			"config_error": "",
+			"config_path": ".releasedash.yml",
+			"default_branch": "main",
+			"display_mode": "commits",
+			"environment_branches": [],
+			"environment_tags": ["dev", "stg", "prod"],
+			"group": "",
+			"html_url": "https://github.com/o/r",
+			"labels": [],
+			"name": "app",
+			"owner": "o",
+			"repo": "r",
+			"team": ""
+		}]
+	}`, rr.Body.String())
+
+	etag := rr.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	rr = serveApi(apiHandler, "GET", "/api/v1/repos/", etag)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+
+	rr = serveApi(apiHandler, "GET", "/api/v1/repos", `"other", W/`+etag)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+
+	rr = serveApi(apiHandler, "GET", "/api/v1/repos", `"other"`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestApiChangelogs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+
+	mockRepo := newMockReleaseNotesRepo()
+	mockChangelogCommits := newMockReleaseNotesChangelogCommits()
+	mockChangelogCommits.CommitStatuses = map[string]scm.ScmCommitStatus{
+		"3e0f3d8c432ca2a03a3222fb55de63934338022f": {State: scm.CommitStatusSuccess},
+	}
+	mockChangelogCommits.Commits[0].AuthoredAt = time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	mockChangelogCommits.HiddenCommits = 1
+	mockChangelogCommits.PullRequests = []dashboard.DashboardPullRequest{{
+		CommitShas: []string{"3e0f3d8c432ca2a03a3222fb55de63934338022f"},
+		ScmPullRequest: scm.ScmPullRequest{
+			AuthorLogin: "l",
+			HtmlUrl:     "https://github.com/o/r/pull/12",
+			Merged:      true,
+			Number:      12,
+			Title:       "Refunds",
+		},
+	}}
+	mockChangelogCommits.Staleness = dashboard.StalenessAmber
+	mockChangelogCommits.Status = &scm.ScmCommitStatus{
+		FailureName: "lint",
+		FailureUrl:  "https://github.com/o/r/runs/1",
+		State:       scm.CommitStatusFailure,
+	}
+	mockRepoChangelogs := []dashboard.DashboardRepoChangelog{
+		{
+			ChangelogCommits: []dashboard.DashboardChangelogCommits{mockChangelogCommits},
+			Config:           mockRepo.Config,
+			Repository:       mockRepo.Repository,
+		},
+		{
+			ChangelogCommits: []dashboard.DashboardChangelogCommits{{FromRef: "prod", ToRef: "stg"}},
+			Config:           &dashboard.DashboardRepoConfig{Name: "other"},
+			Repository:       scm.ScmRepository{Name: "other", OwnerName: "o"},
+		},
+	}
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		AnyTimes().
+		Return(mockRepoChangelogs, true)
+
+	apiHandler := handler.ApiHandler{CacheService: mockCacheService}
+
+	rr := serveApi(apiHandler, "GET", "/api/v1/repos/o/r/changelogs", "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"changelogs": [{
+			"breaking": false,
+			"commits": [
+				{
+					"author_avatar_url": "",
+					"author_login": "",
+					"author_name": "",
+					"group": "Features",
+					"html_url": "https://github.com/o/r/commit/a",
+					"is_merge": false,
+					"message": "feat: PAY-1 add refunds\n\nbody",
+					"sha": "3e0f3d8c432ca2a03a3222fb55de63934338022f",
+					"status": {"failure_name": "", "failure_url": "", "state": "success"}
+				},
+				{
+					"author_avatar_url": "",
+					"author_login": "",
+					"author_name": "",
+					"group": "Fixes",
+					"html_url": "https://github.com/o/r/commit/b",
+					"is_merge": false,
+					"message": "fix: rounding",
+					"sha": "812b303948b570247b727aeb8c1b187336ad4256",
+					"status": null
+				}
+			],
+			"from_ref": "prod",
+			"hidden_commits": 1,
+			"name": "app",
+			"oldest_commit_at": "2021-06-01T10:00:00Z",
+			"pull_requests": [{
+				"author_login": "l",
+				"commit_shas": ["3e0f3d8c432ca2a03a3222fb55de63934338022f"],
+				"html_url": "https://github.com/o/r/pull/12",
+				"labels": [],
+				"number": 12,
+				"title": "Refunds"
+			}],
+			"staleness": "amber",
+			"status": {"failure_name": "lint", "failure_url": "https://github.com/o/r/runs/1", "state": "failure"},
+			"tickets": [{"commit_shas": [], "key": "PAY-1", "url": "https://jira/browse/PAY-1"}],
+			"to_ref": "stg"
+		}]
+	}`, rr.Body.String())
+
+	rr = serveApi(apiHandler, "GET", "/api/v1/repos/o/missing/changelogs", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = serveApi(apiHandler, "GET", "/api/v1/repos/o/r/changelogs?service=missing", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = serveApi(apiHandler, "GET", "/api/v1/repos/o/r/other", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestApiSearch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		AnyTimes().
+		Return(newMockSearchChangelogs(), true)
+
+	apiHandler := handler.ApiHandler{CacheService: mockCacheService}
+
+	rr := serveApiSearch(apiHandler, "GET", "/api/v1/search?q=pay-1")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"query": "pay-1",
+		"results": [{
+			"commit": {
+				"author_avatar_url": "",
+				"author_login": "",
+				"author_name": "",
+				"group": "Features",
+				"html_url": "https://github.com/o/r/commit/a",
+				"is_merge": false,
+				"message": "feat: PAY-1 add refunds\n\nbody",
+				"sha": "3e0f3d8c432ca2a03a3222fb55de63934338022f",
+				"status": null
+			},
+			"name": "app",
+			"owner": "o",
+			"pending": [{"from_ref": "stg", "to_ref": "dev"}, {"from_ref": "prod", "to_ref": "stg"}],
+			"repo": "r",
+			"tickets": [{"commit_shas": ["3e0f3d8c432ca2a03a3222fb55de63934338022f"], "key": "PAY-1", "url": "https://jira/browse/PAY-1"}]
+		}]
+	}`, rr.Body.String())
+
+	rr = serveApiSearch(apiHandler, "GET", "/api/v1/search?q=missing")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"query": "missing", "results": []}`, rr.Body.String())
+
+	rr = serveApiSearch(apiHandler, "GET", "/api/v1/search")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serveApiSearch(apiHandler, "POST", "/api/v1/search?q=pay-1")
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func TestApiLookup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockRepo := newMockReleaseNotesRepo()
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		AnyTimes().
+		Return([]dashboard.DashboardRepo{mockRepo}, true)
+
+	mockDashboardService.
+		EXPECT().
+		GetDashboardCommitLocation(gomock.Any(), mockRepo, "#12").
+		Times(1).
+		Return(&dashboard.DashboardCommitLocation{
+			Commit: &scm.ScmCommit{
+				AuthorLogin: "l",
+				HtmlUrl:     "https://github.com/o/r/commit/a",
+				Message:     "Refunds (#12)",
+				Sha:         "3e0f3d8c432ca2a03a3222fb55de63934338022f",
+			},
+			Environments: []dashboard.DashboardCommitEnvironment{
+				{Name: "dev", Status: dashboard.CommitLocationContained},
+				{Name: "stg", Status: dashboard.CommitLocationMissing},
+			},
+			PullRequest: &scm.ScmPullRequest{
+				AuthorLogin:    "l",
+				HtmlUrl:        "https://github.com/o/r/pull/12",
+				MergeCommitSha: "3e0f3d8c432ca2a03a3222fb55de63934338022f",
+				Merged:         true,
+				Number:         12,
+				Title:          "Refunds",
+			},
+		}, nil)
+
+	mockDashboardService.
+		EXPECT().
+		GetDashboardCommitLocation(gomock.Any(), mockRepo, "missing").
+		Times(1).
+		Return(nil, errors.New("Could not get commit missing: error"))
+
+	mockDashboardService.
+		EXPECT().
+		GetDashboardCommitLocation(gomock.Any(), mockRepo, "nocommit").
+		Times(1).
+		Return(&dashboard.DashboardCommitLocation{}, nil)
+
+	apiHandler := handler.ApiHandler{CacheService: mockCacheService, DashboardService: mockDashboardService}
+
+	rr := serveApi(apiHandler, "GET", "/api/v1/repos/o/r/lookup?ref=%2312", "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"ref": "#12",
+		"services": [{
+			"commit": {
+				"author_avatar_url": "",
+				"author_login": "l",
+				"author_name": "",
+				"group": "",
+				"html_url": "https://github.com/o/r/commit/a",
+				"is_merge": false,
+				"message": "Refunds (#12)",
+				"sha": "3e0f3d8c432ca2a03a3222fb55de63934338022f",
+				"status": null
+			},
+			"environments": [
+				{"name": "dev", "status": "contained"},
+				{"name": "stg", "status": "missing"}
+			],
+			"error": "",
+			"name": "app",
+			"pull_request": {
+				"author_login": "l",
+				"commit_shas": ["3e0f3d8c432ca2a03a3222fb55de63934338022f"],
+				"html_url": "https://github.com/o/r/pull/12",
+				"labels": [],
+				"number": 12,
+				"title": "Refunds"
+			}
+		}]
+	}`, rr.Body.String())
+
+	rr = serveApi(apiHandler, "GET", "/api/v1/repos/o/r/lookup?ref=missing", "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"ref": "missing",
+		"services": [{
+			"commit": null,
+			"environments": [],
+			"error": "Could not get commit missing: error",
+			"name": "app",
+			"pull_request": null
+		}]
+	}`, rr.Body.String())
+
+	// A location without a commit is still reported
+	rr = serveApi(apiHandler, "GET", "/api/v1/repos/o/r/lookup?ref=nocommit", "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"ref": "nocommit",
+		"services": [{
+			"commit": null,
+			"environments": [],
+			"error": "",
+			"name": "app",
+			"pull_request": null
+		}]
+	}`, rr.Body.String())
+
+	rr = serveApi(apiHandler, "GET", "/api/v1/repos/o/r/lookup", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serveApi(apiHandler, "GET", "/api/v1/repos/o/missing/lookup?ref=a", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestApiNoData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+
+	mockCacheService.
+		EXPECT().
+		Get(gomock.Any()).
+		AnyTimes().
+		Return(nil, false)
+
+	apiHandler := handler.ApiHandler{CacheService: mockCacheService}
+
+	rr := serveApi(apiHandler, "GET", "/api/v1/repos", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	rr = serveApi(apiHandler, "GET", "/api/v1/repos/o/r/changelogs", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	rr = serveApi(apiHandler, "POST", "/api/v1/repos", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+	rr = serveApiSearch(apiHandler, "GET", "/api/v1/search?q=pay-1")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+)
+
+const (
+	csrfCookieName = "release_dash_csrf"
+	csrfFieldName  = "csrf_token"
+	csrfTokenBytes = 32
+)
+
+// csrfToken returns the token for the forms of a page, a new token is set as
+// a cookie when the request doesn't have one. Forms post the token back so
+// that it can be checked against the cookie.
+func csrfToken(respWriter http.ResponseWriter, request *http.Request) string {
+	if cookie, err := request.Cookie(csrfCookieName); err == nil && isCsrfToken(cookie.Value) {
+		return cookie.Value
+	}
+
+	tokenBytes := make([]byte, csrfTokenBytes)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return ""
+	}
+	token := hex.EncodeToString(tokenBytes)
+	http.SetCookie(respWriter, &http.Cookie{
+		HttpOnly: true,
+		Name:     csrfCookieName,
+		Path:     "/",
+		SameSite: http.SameSiteStrictMode,
+		Value:    token,
+	})
+	return token
+}
+
+func isCsrfToken(token string) bool {
+	tokenBytes, err := hex.DecodeString(token)
+	return err == nil && len(tokenBytes) == csrfTokenBytes
+}
+
+// checkCsrfToken compares the token posted with a form to the cookie set when
+// the form was rendered.
+func checkCsrfToken(request *http.Request) bool {
+	cookie, err := request.Cookie(csrfCookieName)
+	if err != nil || !isCsrfToken(cookie.Value) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(request.PostFormValue(csrfFieldName))) == 1
+}
+
+// checkSameOrigin makes sure that a form was posted from the dashboard itself,
+// browsers send the Origin of a post but older ones only send the Referer.
+func checkSameOrigin(request *http.Request) bool {
+	source := request.Header.Get("Origin")
+	if source == "" || source == "null" {
+		source = request.Referer()
+	}
+	if source == "" {
+		return false
+	}
+
+	sourceUrl, err := url.Parse(source)
+	if err != nil {
+		return false
+	}
+	return sourceUrl.Host != "" && sourceUrl.Host == request.Host
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/lobsterdore/release-dash/asset"
+	"github.com/lobsterdore/release-dash/cache"
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/web/templatefns"
+)
+
+const (
+	doraDaysDefault = 30
+	doraDaysMax     = 365
+)
+
+// daysWindows are the time windows offered on the DORA and trends pages, any
+// number of days can be asked for with the days query param.
+var daysWindows = []int{7, 30, 90}
+
+type DoraData struct {
+	Days int
+	// Error is set when the history could not be read
+	Error   string
+	Report  history.DoraReport
+	Windows []DaysWindow
+}
+
+type DaysWindow struct {
+	Days     int
+	Selected bool
+	Url      string
+}
+
+type apiDoraData struct {
+	Days         int                  `json:"days"`
+	Environments []apiDoraEnvironment `json:"environments"`
+	Repos        []apiDoraGroup       `json:"repos"`
+	Since        time.Time            `json:"since"`
+	Teams        []apiDoraGroup       `json:"teams"`
+	Until        time.Time            `json:"until"`
+}
+
+type apiDoraEnvironment struct {
+	Deployments int     `json:"deployments"`
+	Name        string  `json:"name"`
+	PerWeek     float64 `json:"per_week"`
+	Repos       int     `json:"repos"`
+}
+
+type apiDoraGroup struct {
+	Commits     int              `json:"commits"`
+	Deployments int              `json:"deployments"`
+	LeadTime    *apiDoraLeadTime `json:"lead_time"`
+	Name        string           `json:"name"`
+	Owner       string           `json:"owner"`
+	PerWeek     float64          `json:"per_week"`
+	Repo        string           `json:"repo"`
+	Service     string           `json:"service"`
+}
+
+type apiDoraLeadTime struct {
+	Commits       int   `json:"commits"`
+	MeanSeconds   int64 `json:"mean_seconds"`
+	MedianSeconds int64 `json:"median_seconds"`
+	P90Seconds    int64 `json:"p90_seconds"`
+}
+
+type DoraHandler struct {
+	CacheService   cache.CacheAdapter
+	HistoryService history.HistoryProvider
+	// ProductionEnvironments overrides the last environment of each repo
+	// pipeline as production when set
+	ProductionEnvironments []string
+}
+
+func NewDoraHandler(historyService history.HistoryProvider, cacheService cache.CacheAdapter, productionEnvironments []string) *DoraHandler {
+	doraHandler := DoraHandler{
+		CacheService:   cacheService,
+		HistoryService: historyService,
+	}
+	for _, environment := range productionEnvironments {
+		if environment = strings.TrimSpace(environment); environment != "" {
+			doraHandler.ProductionEnvironments = append(doraHandler.ProductionEnvironments, environment)
+		}
+	}
+
+	return &doraHandler
+}
+
+// Http serves /metrics/dora, deployment frequency per environment and lead
+// time for changes per repo and team over the last ?days=30.
+func (h *DoraHandler) Http(respWriter http.ResponseWriter, request *http.Request) {
+	tmpl, err := template.New("dora").Funcs(templatefns.TemplateFnsMap).Parse(asset.ReadTemplateFile("html/base.html"))
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get html/base.html")
+		return
+	}
+
+	tmpl, err = tmpl.Parse(asset.ReadTemplateFile("html/dora.html"))
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get html/dora.html")
+		return
+	}
+
+	days := queryInt(request.URL.Query(), "days", doraDaysDefault, 1, doraDaysMax)
+	data := DoraData{
+		Days:    days,
+		Windows: newDaysWindows(request.URL, days),
+	}
+
+	report, err := h.getDoraReport(days, time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get history for DORA metrics")
+		respWriter.WriteHeader(http.StatusInternalServerError)
+		data.Error = "Could not read the history"
+	} else {
+		data.Report = report
+	}
+
+	err = tmpl.Execute(respWriter, data)
+	if err != nil {
+		respWriter.WriteHeader(http.StatusInternalServerError)
+		_, _ = respWriter.Write([]byte(err.Error()))
+		return
+	}
+}
+
+// Api serves /api/v1/metrics/dora with the same days param as the page.
+func (h *DoraHandler) Api(respWriter http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(respWriter, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	days := queryInt(request.URL.Query(), "days", doraDaysDefault, 1, doraDaysMax)
+	report, err := h.getDoraReport(days, time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get history for DORA metrics")
+		http.Error(respWriter, "Could not read the history", http.StatusInternalServerError)
+		return
+	}
+
+	writeApiJson(respWriter, request, newApiDoraData(days, report))
+}
+
+func (h *DoraHandler) getDoraReport(days int, now time.Time) (history.DoraReport, error) {
+	since := now.AddDate(0, 0, -days)
+	movements, err := h.HistoryService.GetMovements(history.HistoryFilter{Since: since, Until: now})
+	if err != nil {
+		return history.DoraReport{}, err
+	}
+
+	var dashboardRepos []dashboard.DashboardRepo
+	if cachedData, found := h.CacheService.Get("homepage_repo_data"); found {
+		dashboardRepos = cachedData.([]dashboard.DashboardRepo)
+	}
+
+	return history.NewDoraReport(movements, dashboardRepos, h.ProductionEnvironments, since, now), nil
+}
+
+func newDaysWindows(requestUrl *url.URL, days int) []DaysWindow {
+	var windows []DaysWindow
+	for _, windowDays := range daysWindows {
+		query := requestUrl.Query()
+		query.Set("days", strconv.Itoa(windowDays))
+		windows = append(windows, DaysWindow{
+			Days:     windowDays,
+			Selected: windowDays == days,
+			Url:      requestUrl.Path + "?" + query.Encode(),
+		})
+	}
+	return windows
+}
+
+func newApiDoraData(days int, report history.DoraReport) apiDoraData {
+	data := apiDoraData{
+		Days:         days,
+		Environments: []apiDoraEnvironment{},
+		Repos:        []apiDoraGroup{},
+		Since:        report.Since,
+		Teams:        []apiDoraGroup{},
+		Until:        report.Until,
+	}
+	for _, environment := range report.Environments {
+		data.Environments = append(data.Environments, apiDoraEnvironment{
+			Deployments: environment.Deployments,
+			Name:        environment.Name,
+			PerWeek:     environment.PerWeek,
+			Repos:       environment.Repos,
+		})
+	}
+	for _, repo := range report.Repos {
+		data.Repos = append(data.Repos, newApiDoraGroup(repo))
+	}
+	for _, team := range report.Teams {
+		data.Teams = append(data.Teams, newApiDoraGroup(team))
+	}
+	return data
+}
+
+func newApiDoraGroup(group history.DoraGroup) apiDoraGroup {
+	apiGroup := apiDoraGroup{
+		Commits:     group.Commits,
+		Deployments: group.Deployments,
+		Name:        group.Name,
+		Owner:       group.Owner,
+		PerWeek:     group.PerWeek,
+		Repo:        group.Repo,
+		Service:     group.Service,
+	}
+	if group.LeadTime.Commits > 0 {
+		apiGroup.LeadTime = &apiDoraLeadTime{
+			Commits:       group.LeadTime.Commits,
+			MeanSeconds:   int64(group.LeadTime.Mean / time.Second),
+			MedianSeconds: int64(group.LeadTime.Median / time.Second),
+			P90Seconds:    int64(group.LeadTime.P90 / time.Second),
+		}
+	}
+	return apiGroup
+}
@@ -0,0 +1,154 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/lobsterdore/release-dash/web/handler"
+
+	mock_cache "github.com/lobsterdore/release-dash/mocks/cache"
+	mock_history "github.com/lobsterdore/release-dash/mocks/history"
+)
+
+func newMockDoraMovements() []history.HistoryMovement {
+	movedAt := time.Now().Add(-24 * time.Hour)
+	return []history.HistoryMovement{
+		{
+			Commits:     []history.HistoryCommit{{AuthoredAt: movedAt.Add(-2 * time.Hour)}, {AuthoredAt: movedAt.Add(-4 * time.Hour)}},
+			Environment: "stg",
+			MovedAt:     movedAt,
+			Owner:       "o",
+			Repo:        "r",
+			Service:     "app",
+		},
+	}
+}
+
+func newMockDoraRepos() []dashboard.DashboardRepo {
+	return []dashboard.DashboardRepo{{
+		Config:     &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"dev", "stg"}, Name: "app", Team: "payments"},
+		Repository: scm.ScmRepository{Name: "r", OwnerName: "o"},
+	}}
+}
+
+func serveDora(t *testing.T, urlPath string, api bool, movements []history.HistoryMovement, err error) *httptest.ResponseRecorder {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockHistoryService := mock_history.NewMockHistoryProvider(ctrl)
+
+	mockHistoryService.
+		EXPECT().
+		GetMovements(gomock.Any()).
+		Times(1).
+		DoAndReturn(func(filter history.HistoryFilter) ([]history.HistoryMovement, error) {
+			assert.Equal(t, 0, filter.Limit)
+			assert.WithinDuration(t, time.Now(), filter.Until, time.Minute)
+			return movements, err
+		})
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		AnyTimes().
+		Return(newMockDoraRepos(), true)
+
+	doraHandler := handler.NewDoraHandler(mockHistoryService, mockCacheService, []string{""})
+
+	req, reqErr := http.NewRequest("GET", urlPath, nil)
+	if reqErr != nil {
+		t.Fatal(reqErr)
+	}
+
+	rr := httptest.NewRecorder()
+	if api {
+		http.HandlerFunc(doraHandler.Api).ServeHTTP(rr, req)
+	} else {
+		http.HandlerFunc(doraHandler.Http).ServeHTTP(rr, req)
+	}
+	return rr
+}
+
+func TestDoraHandler(t *testing.T) {
+	rr := serveDora(t, "/metrics/dora?days=7", false, newMockDoraMovements(), nil)
+	resBody := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, resBody, "Last 7 days")
+	assert.Contains(t, resBody, `class="chip blue lighten-1 white-text" href="/metrics/dora?days=7"`)
+	assert.Contains(t, resBody, `href="/metrics/dora?days=30"`)
+	assert.Contains(t, resBody, "<td>stg</td><td>1</td><td>1.0</td><td>1</td>")
+	assert.Contains(t, resBody, "payments")
+	assert.Contains(t, resBody, `<a href="/repos/o/r">app</a>`)
+	assert.Contains(t, resBody, "<td>3h</td>")
+	assert.Contains(t, resBody, "<td>4h</td>")
+}
+
+func TestDoraHandlerEmpty(t *testing.T) {
+	rr := serveDora(t, "/metrics/dora", false, nil, nil)
+	resBody := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, resBody, "Last 30 days")
+	assert.Contains(t, resBody, "No environments have moved in this period")
+	assert.Contains(t, resBody, "Nothing has reached production in this period")
+}
+
+func TestDoraHandlerError(t *testing.T) {
+	rr := serveDora(t, "/metrics/dora", false, nil, errors.New("error"))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Could not read the history")
+}
+
+func TestDoraHandlerApi(t *testing.T) {
+	rr := serveDora(t, "/api/v1/metrics/dora?days=14", true, newMockDoraMovements(), nil)
+
+	var data struct {
+		Days         int `json:"days"`
+		Environments []struct {
+			Deployments int     `json:"deployments"`
+			Name        string  `json:"name"`
+			PerWeek     float64 `json:"per_week"`
+		} `json:"environments"`
+		Repos []struct {
+			LeadTime *struct {
+				Commits       int   `json:"commits"`
+				MedianSeconds int64 `json:"median_seconds"`
+				P90Seconds    int64 `json:"p90_seconds"`
+			} `json:"lead_time"`
+			Name  string `json:"name"`
+			Owner string `json:"owner"`
+		} `json:"repos"`
+		Teams []struct {
+			Name string `json:"name"`
+		} `json:"teams"`
+	}
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &data))
+	assert.Equal(t, 14, data.Days)
+	assert.Len(t, data.Environments, 1)
+	assert.Equal(t, 0.5, data.Environments[0].PerWeek)
+	assert.Len(t, data.Repos, 1)
+	assert.Equal(t, 2, data.Repos[0].LeadTime.Commits)
+	assert.Equal(t, int64(3*60*60), data.Repos[0].LeadTime.MedianSeconds)
+	assert.Equal(t, int64(4*60*60), data.Repos[0].LeadTime.P90Seconds)
+	assert.Len(t, data.Teams, 1)
+	assert.Equal(t, "payments", data.Teams[0].Name)
+}
+
+func TestDoraHandlerApiError(t *testing.T) {
+	rr := serveDora(t, "/api/v1/metrics/dora", true, nil, errors.New("error"))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
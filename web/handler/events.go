@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	EventRefresh = "refresh"
+
+	eventsKeepAliveInterval = 15 * time.Second
+	eventsRetryMilliseconds = 3000
+)
+
+type event struct {
+	Data string
+	Id   string
+	Name string
+}
+
+type refreshEventData struct {
+	RefreshedAt time.Time `json:"refreshed_at"`
+}
+
+// EventsHandler streams Server-Sent Events to every connected browser, each
+// stream is closed before the server write timeout and reopened by the
+// browser. The last event is replayed to browsers that missed it while
+// reconnecting.
+type EventsHandler struct {
+	// MaxStreamDuration is how long a stream is held open, zero holds it open
+	// until the browser disconnects
+	MaxStreamDuration time.Duration
+
+	clients   map[chan event]bool
+	lastEvent *event
+	mux       sync.Mutex
+}
+
+func NewEventsHandler(writeTimeout time.Duration) *EventsHandler {
+	eventsHandler := EventsHandler{
+		MaxStreamDuration: writeTimeout * 9 / 10,
+		clients:           map[chan event]bool{},
+	}
+
+	return &eventsHandler
+}
+
+// PublishRefresh tells browsers that new changelog data has been stored.
+func (h *EventsHandler) PublishRefresh(refreshedAt time.Time) {
+	data, err := json.Marshal(refreshEventData{RefreshedAt: refreshedAt})
+	if err != nil {
+		log.Error().Err(err).Msg("Could not marshal refresh event")
+		return
+	}
+	h.publish(event{
+		Data: string(data),
+		Id:   strconv.FormatInt(refreshedAt.UnixNano(), 10),
+		Name: EventRefresh,
+	})
+}
+
+func (h *EventsHandler) publish(e event) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	h.lastEvent = &e
+	for client := range h.clients {
+		// Slow clients miss the event rather than blocking the publisher
+		select {
+		case client <- e:
+		default:
+		}
+	}
+}
+
+func (h *EventsHandler) subscribe(lastEventId string) (chan event, *event) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	if h.clients == nil {
+		h.clients = map[chan event]bool{}
+	}
+	client := make(chan event, 1)
+	h.clients[client] = true
+
+	var missedEvent *event
+	if lastEventId != "" && h.lastEvent != nil && h.lastEvent.Id != lastEventId {
+		missedEvent = h.lastEvent
+	}
+	return client, missedEvent
+}
+
+func (h *EventsHandler) unsubscribe(client chan event) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	delete(h.clients, client)
+}
+
+// Http serves /events as a text/event-stream.
+func (h *EventsHandler) Http(respWriter http.ResponseWriter, request *http.Request) {
+	flusher, ok := respWriter.(http.Flusher)
+	if !ok {
+		http.Error(respWriter, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	client, missedEvent := h.subscribe(request.Header.Get("Last-Event-ID"))
+	defer h.unsubscribe(client)
+
+	respWriter.Header().Set("Cache-Control", "no-cache")
+	respWriter.Header().Set("Connection", "keep-alive")
+	respWriter.Header().Set("Content-Type", "text/event-stream")
+	respWriter.Header().Set("X-Accel-Buffering", "no")
+
+	_, _ = fmt.Fprintf(respWriter, "retry: %d\n\n", eventsRetryMilliseconds)
+	if missedEvent != nil {
+		writeEvent(respWriter, *missedEvent)
+	}
+	flusher.Flush()
+
+	var streamEnd <-chan time.Time
+	if h.MaxStreamDuration > 0 {
+		streamTimer := time.NewTimer(h.MaxStreamDuration)
+		defer streamTimer.Stop()
+		streamEnd = streamTimer.C
+	}
+	keepAlive := time.NewTicker(eventsKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case e := <-client:
+			writeEvent(respWriter, e)
+			flusher.Flush()
+		case <-keepAlive.C:
+			_, _ = fmt.Fprint(respWriter, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-streamEnd:
+			return
+		case <-request.Context().Done():
+			return
+		}
+	}
+}
+
+func writeEvent(respWriter http.ResponseWriter, e event) {
+	_, _ = fmt.Fprintf(respWriter, "id: %s\nevent: %s\ndata: %s\n\n", e.Id, e.Name, e.Data)
+}
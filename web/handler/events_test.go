@@ -0,0 +1,148 @@
+package handler_test
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/web/handler"
+
+	mock_cache "github.com/lobsterdore/release-dash/mocks/cache"
+	mock_dashboard "github.com/lobsterdore/release-dash/mocks/dashboard"
+)
+
+func readEventLines(t *testing.T, reader *bufio.Reader, prefix string) string {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimSpace(line)
+		}
+	}
+}
+
+func TestEventsPublishRefresh(t *testing.T) {
+	eventsHandler := handler.NewEventsHandler(0)
+	server := httptest.NewServer(http.HandlerFunc(eventsHandler.Http))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	assert.Equal(t, "retry: 3000", readEventLines(t, reader, "retry:"))
+
+	refreshedAt := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	eventsHandler.PublishRefresh(refreshedAt)
+
+	assert.Equal(t, "id: 1622541600000000000", readEventLines(t, reader, "id:"))
+	assert.Equal(t, "event: refresh", readEventLines(t, reader, "event:"))
+	assert.Equal(t, `data: {"refreshed_at":"2021-06-01T10:00:00Z"}`, readEventLines(t, reader, "data:"))
+}
+
+func TestEventsReplaysMissedEvent(t *testing.T) {
+	eventsHandler := handler.NewEventsHandler(200 * time.Millisecond)
+	server := httptest.NewServer(http.HandlerFunc(eventsHandler.Http))
+	defer server.Close()
+
+	eventsHandler.PublishRefresh(time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC))
+
+	// The first connection has nothing to catch up on and ends once the max
+	// stream duration has passed
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "retry: 3000\n\n", string(body))
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "id: 1622541600000000000\nevent: refresh\n")
+
+	req.Header.Set("Last-Event-ID", "1622541600000000000")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(body), "event: refresh")
+}
+
+func TestFetchChangelogsPublishesRefresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockCtx := context.Background()
+	mockRepos := []dashboard.DashboardRepo{newMockReleaseNotesRepo()}
+	mockRepoChangelogs := []dashboard.DashboardRepoChangelog{{Config: mockRepos[0].Config, Repository: mockRepos[0].Repository}}
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return(mockRepos, true)
+	mockDashboardService.
+		EXPECT().
+		GetDashboardChangelogs(mockCtx, mockRepos).
+		Times(1).
+		Return(mockRepoChangelogs)
+	mockCacheService.
+		EXPECT().
+		Set("homepage_changelog_data", mockRepoChangelogs, "60").
+		Times(1)
+	mockCacheService.
+		EXPECT().
+		Set("homepage_changelog_refreshed", gomock.Any(), "60").
+		Times(1)
+
+	eventsHandler := handler.NewEventsHandler(0)
+	server := httptest.NewServer(http.HandlerFunc(eventsHandler.Http))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	readEventLines(t, reader, "retry:")
+
+	homepageHandler := handler.HomepageHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+		EventsHandler:    eventsHandler,
+	}
+	homepageHandler.FetchChangelogs(mockCtx, "60")
+
+	assert.Equal(t, "event: refresh", readEventLines(t, reader, "event:"))
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/lobsterdore/release-dash/asset"
+	"github.com/lobsterdore/release-dash/cache"
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/web/templatefns"
+)
+
+// feedPromotionsLimit caps the promotions that are kept for the feeds, the
+// oldest are dropped first.
+const feedPromotionsLimit = 200
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+	Id      string      `xml:"id"`
+	Links   []atomLink  `xml:"link"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Body string `xml:",chardata"`
+	Type string `xml:"type,attr"`
+}
+
+type atomEntry struct {
+	Content atomContent `xml:"content"`
+	Id      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+// FeedHandler serves the promotions from the history when it is enabled, so
+// that the feeds survive restarts, otherwise they are kept in the cache.
+type FeedHandler struct {
+	CacheService   cache.CacheAdapter
+	HistoryService history.HistoryProvider
+}
+
+func NewFeedHandler(cacheService cache.CacheAdapter, historyService history.HistoryProvider) *FeedHandler {
+	feedHandler := FeedHandler{
+		CacheService:   cacheService,
+		HistoryService: historyService,
+	}
+
+	return &feedHandler
+}
+
+// AddPromotions stores promotions ahead of the ones already in the feeds,
+// the history records promotions itself when it is enabled.
+func (h *FeedHandler) AddPromotions(promotions []dashboard.DashboardPromotion) {
+	if len(promotions) == 0 || h.HistoryService != nil {
+		return
+	}
+	log.Info().Msgf("Recording %d environment promotions", len(promotions))
+
+	promotions = append(promotions, h.getCachedPromotions()...)
+	if len(promotions) > feedPromotionsLimit {
+		promotions = promotions[:feedPromotionsLimit]
+	}
+	h.CacheService.Set("feed_promotions", promotions, "-1")
+}
+
+// Http serves Atom feeds of promotions at /feed.atom,
+// /environments/{environment}/feed.atom and /repos/{owner}/{repo}/feed.atom.
+func (h *FeedHandler) Http(respWriter http.ResponseWriter, request *http.Request) {
+	title, filter, ok := newFeedFilter(request.URL.Path)
+	if !ok {
+		http.NotFound(respWriter, request)
+		return
+	}
+
+	movements, err := h.getMovements(filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get promotions from history")
+		respWriter.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	tmpl, err := template.New("feed_entry").Funcs(templatefns.TemplateFnsMap).Parse(asset.ReadTemplateFile("html/feed_entry.html"))
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get html/feed_entry.html")
+		return
+	}
+
+	baseUrl := requestBaseUrl(request)
+	feed := atomFeed{
+		Author: atomAuthor{Name: "Release Dash"},
+		// The id stays the same whichever host the feed is reached on
+		Id: "urn:release-dash:feed:" + request.URL.EscapedPath(),
+		Links: []atomLink{
+			{Href: baseUrl + request.URL.Path, Rel: "self", Type: "application/atom+xml"},
+			{Href: baseUrl + "/", Rel: "alternate", Type: "text/html"},
+		},
+		Title:   title,
+		Updated: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	for _, movement := range movements {
+		entry, err := newAtomEntry(tmpl, movement)
+		if err != nil {
+			respWriter.WriteHeader(http.StatusInternalServerError)
+			_, _ = respWriter.Write([]byte(err.Error()))
+			return
+		}
+		if len(feed.Entries) == 0 {
+			feed.Updated = entry.Updated
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		respWriter.WriteHeader(http.StatusInternalServerError)
+		_, _ = respWriter.Write([]byte(err.Error()))
+		return
+	}
+
+	respWriter.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	_, _ = respWriter.Write([]byte(xml.Header))
+	_, _ = respWriter.Write(body)
+}
+
+// getMovements returns the promotions that match a feed newest first.
+func (h *FeedHandler) getMovements(filter history.HistoryFilter) ([]history.HistoryMovement, error) {
+	filter.Limit = feedPromotionsLimit
+	if h.HistoryService != nil {
+		return h.HistoryService.GetMovements(filter)
+	}
+
+	var movements []history.HistoryMovement
+	for _, promotion := range h.getCachedPromotions() {
+		movement := history.NewHistoryMovement(promotion)
+		if filter.Matches(movement) {
+			movements = append(movements, movement)
+		}
+	}
+	return movements, nil
+}
+
+func (h *FeedHandler) getCachedPromotions() []dashboard.DashboardPromotion {
+	cachedData, found := h.CacheService.Get("feed_promotions")
+	if !found {
+		return nil
+	}
+	return cachedData.([]dashboard.DashboardPromotion)
+}
+
+// newFeedFilter works out the feed title and which promotions belong in the
+// feed from the request path.
+func newFeedFilter(urlPath string) (string, history.HistoryFilter, bool) {
+	if urlPath == "/feed.atom" {
+		return "Promotions", history.HistoryFilter{}, true
+	}
+	if !strings.HasSuffix(urlPath, "/feed.atom") {
+		return "", history.HistoryFilter{}, false
+	}
+	urlPath = strings.TrimSuffix(urlPath, "/feed.atom")
+
+	if strings.HasPrefix(urlPath, "/environments/") {
+		// Environment branches can contain slashes, e.g. release/prod
+		environment := strings.TrimPrefix(urlPath, "/environments/")
+		if environment == "" {
+			return "", history.HistoryFilter{}, false
+		}
+		return fmt.Sprintf("Promotions to %s", environment), history.HistoryFilter{Environment: environment}, true
+	}
+
+	if strings.HasPrefix(urlPath, "/repos/") {
+		pathParts := strings.Split(strings.TrimPrefix(urlPath, "/repos/"), "/")
+		if len(pathParts) != 2 || pathParts[0] == "" || pathParts[1] == "" {
+			return "", history.HistoryFilter{}, false
+		}
+		owner := pathParts[0]
+		repo := pathParts[1]
+		return fmt.Sprintf("Promotions for %s/%s", owner, repo), history.HistoryFilter{Owner: owner, Repo: repo}, true
+	}
+
+	return "", history.HistoryFilter{}, false
+}
+
+func newAtomEntry(tmpl *template.Template, movement history.HistoryMovement) (atomEntry, error) {
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, movement); err != nil {
+		return atomEntry{}, fmt.Errorf("Could not render feed entry: %s", err)
+	}
+
+	commits := "commits"
+	if len(movement.Commits) == 1 {
+		commits = "commit"
+	}
+	// Matches the id of the promotion the movement was recorded from
+	id := dashboard.PromotionId(movement.Owner, movement.Repo, movement.Service, movement.Environment, movement.FromSha, movement.ToSha)
+	return atomEntry{
+		Content: atomContent{Body: content.String(), Type: "html"},
+		Id:      "urn:release-dash:promotion:" + id,
+		Link:    atomLink{Href: movement.HtmlUrl, Rel: "alternate"},
+		Title:   fmt.Sprintf("%s promoted to %s (%d %s)", movement.Service, movement.Environment, len(movement.Commits), commits),
+		Updated: movement.MovedAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// requestBaseUrl is the scheme and host the dashboard was reached on, the
+// X-Forwarded-Proto header is trusted when running behind a proxy.
+func requestBaseUrl(request *http.Request) string {
+	scheme := "http"
+	if request.TLS != nil || request.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + request.Host
+}
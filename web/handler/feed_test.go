@@ -0,0 +1,215 @@
+package handler_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/lobsterdore/release-dash/web/handler"
+
+	mock_cache "github.com/lobsterdore/release-dash/mocks/cache"
+	mock_history "github.com/lobsterdore/release-dash/mocks/history"
+)
+
+func newMockPromotions() []dashboard.DashboardPromotion {
+	promotedAt := time.Date(2020, time.October, 2, 9, 30, 0, 0, time.UTC)
+	return []dashboard.DashboardPromotion{
+		{
+			Commits: []scm.ScmCommit{
+				{AuthorLogin: "dev1", HtmlUrl: "https://github.com/o/r/commit/abcdef123", Message: "add refunds <beta>\n\nlong description", Sha: "abcdef123"},
+			},
+			Config:      &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"stg", "prod"}, Name: "app"},
+			Environment: "prod",
+			FromSha:     "1111111aaa",
+			PromotedAt:  promotedAt,
+			Repository:  scm.ScmRepository{HtmlUrl: "https://github.com/o/r", Name: "r", OwnerName: "o"},
+			ToSha:       "abcdef123",
+		},
+		{
+			Config:      &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"stg", "prod"}, Name: "billing"},
+			Environment: "stg",
+			FromSha:     "2222222bbb",
+			PromotedAt:  promotedAt.Add(-time.Hour),
+			Repository:  scm.ScmRepository{HtmlUrl: "https://github.com/o/billing", Name: "billing", OwnerName: "o"},
+			ToSha:       "3333333ccc",
+		},
+	}
+}
+
+type testAtomFeed struct {
+	Entries []struct {
+		Content string `xml:"content"`
+		Id      string `xml:"id"`
+		Link    struct {
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Title   string `xml:"title"`
+		Updated string `xml:"updated"`
+	} `xml:"entry"`
+	Id      string `xml:"id"`
+	Title   string `xml:"title"`
+	Updated string `xml:"updated"`
+}
+
+func serveFeed(t *testing.T, urlPath string, promotions []dashboard.DashboardPromotion) *httptest.ResponseRecorder {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockCacheService.
+		EXPECT().
+		Get("feed_promotions").
+		AnyTimes().
+		Return(promotions, promotions != nil)
+
+	feedHandler := handler.FeedHandler{CacheService: mockCacheService}
+
+	req, err := http.NewRequest("GET", urlPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Host = "dash.example.com"
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(feedHandler.Http).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestFeedHandlerAll(t *testing.T) {
+	rr := serveFeed(t, "/feed.atom", newMockPromotions())
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/atom+xml; charset=utf-8", rr.Header().Get("Content-Type"))
+
+	var feed testAtomFeed
+	assert.NoError(t, xml.Unmarshal(rr.Body.Bytes(), &feed))
+	assert.Equal(t, "Promotions", feed.Title)
+	assert.Equal(t, "urn:release-dash:feed:/feed.atom", feed.Id)
+	assert.Equal(t, "2020-10-02T09:30:00Z", feed.Updated)
+	assert.Len(t, feed.Entries, 2)
+
+	entry := feed.Entries[0]
+	assert.Equal(t, "app promoted to prod (1 commit)", entry.Title)
+	assert.Equal(t, "urn:release-dash:promotion:o/r/app/prod/1111111aaa/abcdef123", entry.Id)
+	assert.Equal(t, "https://github.com/o/r/compare/1111111aaa...abcdef123", entry.Link.Href)
+	assert.Contains(t, entry.Content, "add refunds &lt;beta&gt;")
+	assert.NotContains(t, entry.Content, "long description")
+	assert.Contains(t, entry.Content, "(dev1)")
+
+	assert.Equal(t, "billing promoted to stg (0 commits)", feed.Entries[1].Title)
+	assert.Contains(t, feed.Entries[1].Content, "No commits were promoted")
+}
+
+func TestFeedHandlerEnvironment(t *testing.T) {
+	rr := serveFeed(t, "/environments/stg/feed.atom", newMockPromotions())
+
+	var feed testAtomFeed
+	assert.NoError(t, xml.Unmarshal(rr.Body.Bytes(), &feed))
+	assert.Equal(t, "Promotions to stg", feed.Title)
+	assert.Len(t, feed.Entries, 1)
+	assert.Equal(t, "billing promoted to stg (0 commits)", feed.Entries[0].Title)
+}
+
+func TestFeedHandlerRepo(t *testing.T) {
+	rr := serveFeed(t, "/repos/O/R/feed.atom", newMockPromotions())
+
+	var feed testAtomFeed
+	assert.NoError(t, xml.Unmarshal(rr.Body.Bytes(), &feed))
+	assert.Equal(t, "Promotions for O/R", feed.Title)
+	assert.Len(t, feed.Entries, 1)
+	assert.Equal(t, "app promoted to prod (1 commit)", feed.Entries[0].Title)
+}
+
+func TestFeedHandlerEmpty(t *testing.T) {
+	rr := serveFeed(t, "/feed.atom", nil)
+
+	var feed testAtomFeed
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, xml.Unmarshal(rr.Body.Bytes(), &feed))
+	assert.Empty(t, feed.Entries)
+}
+
+func TestFeedHandlerNotFound(t *testing.T) {
+	for _, urlPath := range []string{"/environments/feed.atom", "/environments/stg", "/repos/o/feed.atom", "/repos/o/r/x/feed.atom"} {
+		rr := serveFeed(t, urlPath, newMockPromotions())
+		assert.Equal(t, http.StatusNotFound, rr.Code, urlPath)
+	}
+}
+
+func TestFeedHandlerAddPromotions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockPromotions := newMockPromotions()
+
+	mockCacheService.
+		EXPECT().
+		Get("feed_promotions").
+		Times(1).
+		Return(mockPromotions[1:], true)
+	mockCacheService.
+		EXPECT().
+		Set("feed_promotions", mockPromotions, "-1").
+		Times(1)
+
+	feedHandler := handler.FeedHandler{CacheService: mockCacheService}
+	feedHandler.AddPromotions(mockPromotions[:1])
+	feedHandler.AddPromotions(nil)
+
+	ctrl.Finish()
+}
+
+func TestFeedHandlerHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockHistoryService := mock_history.NewMockHistoryProvider(ctrl)
+	mockHistoryService.
+		EXPECT().
+		GetMovements(history.HistoryFilter{Limit: 200, Owner: "O", Repo: "R"}).
+		Times(1).
+		Return([]history.HistoryMovement{history.NewHistoryMovement(newMockPromotions()[0])}, nil)
+
+	// Promotions aren't kept in the cache when the history is enabled
+	feedHandler := handler.NewFeedHandler(mock_cache.NewMockCacheAdapter(ctrl), mockHistoryService)
+	feedHandler.AddPromotions(newMockPromotions())
+
+	req, _ := http.NewRequest("GET", "/repos/O/R/feed.atom", nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(feedHandler.Http).ServeHTTP(rr, req)
+
+	var feed testAtomFeed
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, xml.Unmarshal(rr.Body.Bytes(), &feed))
+	assert.Len(t, feed.Entries, 1)
+	assert.Equal(t, "app promoted to prod (1 commit)", feed.Entries[0].Title)
+	assert.Equal(t, "urn:release-dash:promotion:o/r/app/prod/1111111aaa/abcdef123", feed.Entries[0].Id)
+	assert.Equal(t, "https://github.com/o/r/compare/1111111aaa...abcdef123", feed.Entries[0].Link.Href)
+	assert.Contains(t, feed.Entries[0].Content, "(dev1)")
+}
+
+func TestFeedHandlerHistoryError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockHistoryService := mock_history.NewMockHistoryProvider(ctrl)
+	mockHistoryService.
+		EXPECT().
+		GetMovements(gomock.Any()).
+		Times(1).
+		Return(nil, errors.New("error"))
+
+	feedHandler := handler.NewFeedHandler(nil, mockHistoryService)
+
+	req, _ := http.NewRequest("GET", "/feed.atom", nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(feedHandler.Http).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
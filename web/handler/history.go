@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/lobsterdore/release-dash/asset"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/web/templatefns"
+)
+
+const (
+	historyDateFormat   = "2006-01-02"
+	historyLimitDefault = 100
+	historyLimitMax     = 1000
+)
+
+type HistoryData struct {
+	Days        []HistoryDay
+	Environment string
+	Error       string
+	Owner       string
+	Repo        string
+	Since       string
+	Until       string
+}
+
+type HistoryDay struct {
+	Date      time.Time
+	Movements []history.HistoryMovement
+}
+
+type apiHistoryData struct {
+	Movements []history.HistoryMovement `json:"movements"`
+}
+
+type HistoryHandler struct {
+	HistoryService history.HistoryProvider
+	// Location is used for the since and until dates and to group movements
+	// by day
+	Location *time.Location
+}
+
+func NewHistoryHandler(historyService history.HistoryProvider, timezone string) (*HistoryHandler, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("Could not load history timezone %s: %s", timezone, err)
+	}
+
+	historyHandler := HistoryHandler{
+		HistoryService: historyService,
+		Location:       location,
+	}
+
+	return &historyHandler, nil
+}
+
+// Http serves /history, a timeline of environment ref movements that can be
+// narrowed down with the environment, owner, repo, since and until params.
+func (h *HistoryHandler) Http(respWriter http.ResponseWriter, request *http.Request) {
+	tmpl, err := template.New("history").Funcs(templatefns.TemplateFnsMap).Parse(asset.ReadTemplateFile("html/base.html"))
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get html/base.html")
+		return
+	}
+
+	tmpl, err = tmpl.Parse(asset.ReadTemplateFile("html/history.html"))
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get html/history.html")
+		return
+	}
+
+	query := request.URL.Query()
+	data := HistoryData{
+		Environment: query.Get("environment"),
+		Owner:       query.Get("owner"),
+		Repo:        query.Get("repo"),
+		Since:       query.Get("since"),
+		Until:       query.Get("until"),
+	}
+
+	filter, err := h.newHistoryFilter(query)
+	if err != nil {
+		respWriter.WriteHeader(http.StatusBadRequest)
+		data.Error = err.Error()
+	} else {
+		movements, err := h.HistoryService.GetMovements(filter)
+		if err != nil {
+			log.Error().Err(err).Msg("Could not get history")
+			respWriter.WriteHeader(http.StatusInternalServerError)
+			data.Error = "Could not read the history"
+		}
+		data.Days = h.groupHistoryDays(movements)
+	}
+
+	err = tmpl.Execute(respWriter, data)
+	if err != nil {
+		respWriter.WriteHeader(http.StatusInternalServerError)
+		_, _ = respWriter.Write([]byte(err.Error()))
+		return
+	}
+}
+
+// Api serves /api/v1/history with the same params as the timeline, newest
+// movements first.
+func (h *HistoryHandler) Api(respWriter http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(respWriter, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := h.newHistoryFilter(request.URL.Query())
+	if err != nil {
+		http.Error(respWriter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	movements, err := h.HistoryService.GetMovements(filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get history")
+		http.Error(respWriter, "Could not read the history", http.StatusInternalServerError)
+		return
+	}
+
+	data := apiHistoryData{Movements: []history.HistoryMovement{}}
+	data.Movements = append(data.Movements, movements...)
+	writeApiJson(respWriter, request, data)
+}
+
+// newHistoryFilter reads the filter from query params, since and until are
+// dates in the history timezone and both days are included.
+func (h *HistoryHandler) newHistoryFilter(query url.Values) (history.HistoryFilter, error) {
+	filter := history.HistoryFilter{
+		Environment: query.Get("environment"),
+		Limit:       queryInt(query, "limit", historyLimitDefault, 1, historyLimitMax),
+		Owner:       query.Get("owner"),
+		Repo:        query.Get("repo"),
+		Service:     query.Get("service"),
+	}
+
+	if since := strings.TrimSpace(query.Get("since")); since != "" {
+		sinceDate, err := time.ParseInLocation(historyDateFormat, since, h.Location)
+		if err != nil {
+			return history.HistoryFilter{}, fmt.Errorf("Could not parse since %s, expected a date like 2020-10-01", since)
+		}
+		filter.Since = sinceDate
+	}
+	if until := strings.TrimSpace(query.Get("until")); until != "" {
+		untilDate, err := time.ParseInLocation(historyDateFormat, until, h.Location)
+		if err != nil {
+			return history.HistoryFilter{}, fmt.Errorf("Could not parse until %s, expected a date like 2020-10-01", until)
+		}
+		filter.Until = untilDate.AddDate(0, 0, 1)
+	}
+	return filter, nil
+}
+
+// groupHistoryDays splits movements into days, movements are expected newest
+// first.
+func (h *HistoryHandler) groupHistoryDays(movements []history.HistoryMovement) []HistoryDay {
+	var days []HistoryDay
+	for _, movement := range movements {
+		movement.MovedAt = movement.MovedAt.In(h.Location)
+		year, month, day := movement.MovedAt.Date()
+		date := time.Date(year, month, day, 0, 0, 0, 0, h.Location)
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(date) {
+			days = append(days, HistoryDay{Date: date})
+		}
+		days[len(days)-1].Movements = append(days[len(days)-1].Movements, movement)
+	}
+	return days
+}
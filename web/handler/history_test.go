@@ -0,0 +1,188 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/web/handler"
+
+	mock_history "github.com/lobsterdore/release-dash/mocks/history"
+)
+
+func newMockHistoryMovements() []history.HistoryMovement {
+	tuesday := time.Date(2020, time.October, 6, 23, 30, 0, 0, time.UTC)
+	return []history.HistoryMovement{
+		{
+			Commits:     []history.HistoryCommit{{AuthorLogin: "dev1", HtmlUrl: "https://github.com/o/r/commit/abcdef123", Message: "add refunds\n\ndetails", Sha: "abcdef123"}},
+			Environment: "prod",
+			FromSha:     "1111111aaa",
+			HtmlUrl:     "https://github.com/o/r/compare/1111111aaa...abcdef123",
+			MovedAt:     tuesday,
+			Owner:       "o",
+			Repo:        "r",
+			Service:     "app",
+			ToSha:       "abcdef123",
+		},
+		{
+			Commits:     []history.HistoryCommit{},
+			Environment: "prod",
+			FromSha:     "2222222bbb",
+			MovedAt:     tuesday.Add(-2 * time.Hour),
+			Owner:       "o",
+			Repo:        "billing",
+			Service:     "billing",
+			ToSha:       "1111111aaa",
+		},
+	}
+}
+
+func TestHistoryHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockHistoryService := mock_history.NewMockHistoryProvider(ctrl)
+	historyHandler, err := handler.NewHistoryHandler(mockHistoryService, "Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paris, _ := time.LoadLocation("Europe/Paris")
+	expectedFilter := history.HistoryFilter{
+		Environment: "prod",
+		Limit:       100,
+		Since:       time.Date(2020, time.October, 6, 0, 0, 0, 0, paris),
+		Until:       time.Date(2020, time.October, 8, 0, 0, 0, 0, paris),
+	}
+	mockHistoryService.
+		EXPECT().
+		GetMovements(expectedFilter).
+		Times(1).
+		Return(newMockHistoryMovements(), nil)
+
+	req, err := http.NewRequest("GET", "/history?environment=prod&since=2020-10-06&until=2020-10-07", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(historyHandler.Http).ServeHTTP(rr, req)
+	resBody := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	// 23:30 UTC on Tuesday is early on Wednesday in Paris
+	assert.Contains(t, resBody, "Wednesday 7 October 2020")
+	assert.Contains(t, resBody, "Tuesday 6 October 2020")
+	assert.Contains(t, resBody, "01:30")
+	assert.Contains(t, resBody, "add refunds")
+	assert.NotContains(t, resBody, "details")
+	assert.Contains(t, resBody, `href="https://github.com/o/r/compare/1111111aaa...abcdef123"`)
+	assert.Contains(t, resBody, `value="2020-10-06"`)
+}
+
+func TestHistoryHandlerEmpty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockHistoryService := mock_history.NewMockHistoryProvider(ctrl)
+	historyHandler, _ := handler.NewHistoryHandler(mockHistoryService, "UTC")
+
+	mockHistoryService.
+		EXPECT().
+		GetMovements(gomock.Any()).
+		Times(1).
+		Return(nil, nil)
+
+	req, err := http.NewRequest("GET", "/history", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(historyHandler.Http).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "No environments have moved in this period")
+}
+
+func TestHistoryHandlerBadDate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockHistoryService := mock_history.NewMockHistoryProvider(ctrl)
+	historyHandler, _ := handler.NewHistoryHandler(mockHistoryService, "UTC")
+
+	req, err := http.NewRequest("GET", "/history?since=last-tuesday", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(historyHandler.Http).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Could not parse since last-tuesday")
+}
+
+func TestHistoryHandlerApi(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockHistoryService := mock_history.NewMockHistoryProvider(ctrl)
+	historyHandler, _ := handler.NewHistoryHandler(mockHistoryService, "UTC")
+
+	mockHistoryService.
+		EXPECT().
+		GetMovements(history.HistoryFilter{Limit: 1, Owner: "o", Repo: "r"}).
+		Times(1).
+		Return(newMockHistoryMovements()[:1], nil)
+
+	req, err := http.NewRequest("GET", "/api/v1/history?owner=o&repo=r&limit=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(historyHandler.Api).ServeHTTP(rr, req)
+
+	var data map[string][]map[string]interface{}
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &data))
+	assert.Len(t, data["movements"], 1)
+	assert.Equal(t, "prod", data["movements"][0]["environment"])
+	assert.Equal(t, "2020-10-06T23:30:00Z", data["movements"][0]["moved_at"])
+	assert.Equal(t, "abcdef123", data["movements"][0]["to_sha"])
+}
+
+func TestHistoryHandlerApiError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockHistoryService := mock_history.NewMockHistoryProvider(ctrl)
+	historyHandler, _ := handler.NewHistoryHandler(mockHistoryService, "UTC")
+
+	mockHistoryService.
+		EXPECT().
+		GetMovements(gomock.Any()).
+		Times(1).
+		Return(nil, errors.New("error"))
+
+	req, err := http.NewRequest("GET", "/api/v1/history", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(historyHandler.Api).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestNewHistoryHandlerBadTimezone(t *testing.T) {
+	historyHandler, err := handler.NewHistoryHandler(nil, "Mars/Olympus")
+
+	assert.Error(t, err)
+	assert.Nil(t, historyHandler)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"html/template"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,22 +15,59 @@ import (
 	"github.com/lobsterdore/release-dash/asset"
 	"github.com/lobsterdore/release-dash/cache"
 	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/notify"
 	"github.com/lobsterdore/release-dash/web/templatefns"
 )
 
 type HomepageData struct {
-	RepoChangelogs []dashboard.DashboardRepoChangelog
+	// CsrfToken is posted with the draft release forms
+	CsrfToken string
+	Facets    []HomepageFacet
+	// Filtered is set when the board is narrowed down by any query params
+	Filtered        bool
+	Groups          []dashboard.DashboardBoardGroup
+	ReleasesEnabled bool
+	RepoChangelogs  []dashboard.DashboardRepoChangelog
+}
+
+type HomepageFacet struct {
+	Name    string
+	Options []HomepageFacetOption
+}
+
+type HomepageFacetOption struct {
+	Selected bool
+	// Url toggles the option on or off while keeping any other filters
+	Url   string
+	Value string
 }
 
 type HomepageHandler struct {
 	CacheService     cache.CacheAdapter
 	DashboardService dashboard.DashboardProvider
+	// EventsHandler is told when changelogs are refreshed, can be nil
+	EventsHandler *EventsHandler
+	// FeedHandler is given the environment refs that moved on each refresh,
+	// can be nil
+	FeedHandler *FeedHandler
+	// HistoryService stores the environment refs seen on each refresh, can be
+	// nil
+	HistoryService history.HistoryProvider
+	// NotifyService is given each set of refreshed changelogs, can be nil
+	NotifyService   notify.NotifyProvider
+	ReleasesEnabled bool
 }
 
-func NewHomepageHandler(dashboardService *dashboard.DashboardService, cacheService cache.CacheAdapter) *HomepageHandler {
+func NewHomepageHandler(dashboardService *dashboard.DashboardService, cacheService cache.CacheAdapter, eventsHandler *EventsHandler, feedHandler *FeedHandler, historyService history.HistoryProvider, notifyService notify.NotifyProvider, releasesEnabled bool) *HomepageHandler {
 	homepageHandler := HomepageHandler{
 		CacheService:     cacheService,
 		DashboardService: dashboardService,
+		EventsHandler:    eventsHandler,
+		FeedHandler:      feedHandler,
+		HistoryService:   historyService,
+		NotifyService:    notifyService,
+		ReleasesEnabled:  releasesEnabled,
 	}
 
 	return &homepageHandler
@@ -83,14 +122,66 @@ func (h *HomepageHandler) FetchChangelogs(ctx context.Context, expireSeconds str
 	cachedData, found := h.CacheService.Get("homepage_repo_data")
 	if found {
 		dashboardRepos := cachedData.([]dashboard.DashboardRepo)
+		previousChangelogs, previousFound := h.getPreviousChangelogs()
 		dashboardChangelogs := h.DashboardService.GetDashboardChangelogs(ctx, dashboardRepos)
+		refreshedAt := time.Now()
 		h.CacheService.Set("homepage_changelog_data", dashboardChangelogs, expireSeconds)
+		h.CacheService.Set("homepage_changelog_refreshed", refreshedAt, expireSeconds)
+		if h.EventsHandler != nil {
+			h.EventsHandler.PublishRefresh(refreshedAt)
+		}
+		if previousFound {
+			h.recordPromotions(ctx, previousChangelogs, dashboardChangelogs, refreshedAt)
+		}
+		if h.HistoryService != nil {
+			err := h.HistoryService.RecordSnapshots(dashboardChangelogs, refreshedAt)
+			if err != nil {
+				log.Error().Err(err).Msg("Could not record pending commit snapshots in history")
+			}
+		}
+		if h.NotifyService != nil {
+			h.NotifyService.Notify(ctx, dashboardChangelogs)
+		}
 		log.Info().Msg("Dashboard changelog repo data refreshed")
 	} else {
 		log.Info().Msg("Dashboard repo data not present yet")
 	}
 }
 
+// getPreviousChangelogs returns the changelogs that promotions are detected
+// against, the history is preferred over the cache as it survives restarts.
+func (h *HomepageHandler) getPreviousChangelogs() ([]dashboard.DashboardRepoChangelog, bool) {
+	if h.HistoryService != nil {
+		previousChangelogs, err := h.HistoryService.GetPreviousChangelogs()
+		if err != nil {
+			log.Error().Err(err).Msg("Could not get environment refs from history")
+			return nil, false
+		}
+		return previousChangelogs, true
+	}
+	if h.FeedHandler == nil {
+		return nil, false
+	}
+	cachedData, found := h.CacheService.Get("homepage_changelog_data")
+	if !found {
+		return nil, false
+	}
+	return cachedData.([]dashboard.DashboardRepoChangelog), true
+}
+
+func (h *HomepageHandler) recordPromotions(ctx context.Context, previousChangelogs []dashboard.DashboardRepoChangelog, dashboardChangelogs []dashboard.DashboardRepoChangelog, refreshedAt time.Time) {
+	promotions := h.DashboardService.GetDashboardPromotions(ctx, previousChangelogs, dashboardChangelogs, refreshedAt)
+	if h.FeedHandler != nil {
+		h.FeedHandler.AddPromotions(promotions)
+	}
+	if h.HistoryService != nil {
+		err := h.HistoryService.Record(dashboardChangelogs, promotions, refreshedAt)
+		if err != nil {
+			log.Error().Err(err).Msg("Could not record environment refs in history")
+		}
+	}
+}
+
 func (h *HomepageHandler) Http(respWriter http.ResponseWriter, request *http.Request) {
 	var tmpl *template.Template
 	var data HomepageData
@@ -105,13 +196,25 @@ func (h *HomepageHandler) Http(respWriter http.ResponseWriter, request *http.Req
 			return
 		}
 
-		tmpl, err = tmpl.Parse(asset.ReadTemplateFile("html/homepage.html"))
-		if err != nil {
-			log.Error().Err(err).Msg("Could not get html/homepage.html")
-			return
+		for _, parseFile := range []string{"html/homepage.html", "html/ci_status.html"} {
+			tmpl, err = tmpl.Parse(asset.ReadTemplateFile(parseFile))
+			if err != nil {
+				log.Error().Err(err).Msgf("Could not get %s", parseFile)
+				return
+			}
 		}
+		query := request.URL.Query()
+		boardFilter := newDashboardBoardFilter(query)
+		filteredChangelogs := boardFilter.Apply(repoChangelogs)
 		data = HomepageData{
-			RepoChangelogs: repoChangelogs,
+			Facets:          newHomepageFacets(query, dashboard.GetDashboardBoardFacets(repoChangelogs)),
+			Filtered:        !boardFilter.IsEmpty(),
+			Groups:          dashboard.GetDashboardBoardGroups(filteredChangelogs),
+			ReleasesEnabled: h.ReleasesEnabled,
+			RepoChangelogs:  filteredChangelogs,
+		}
+		if h.ReleasesEnabled {
+			data.CsrfToken = csrfToken(respWriter, request)
 		}
 	} else {
 		tmpl, err = template.New("homepage_loading").Funcs(templatefns.TemplateFnsMap).Parse(asset.ReadTemplateFile("html/base.html"))
@@ -134,3 +237,70 @@ func (h *HomepageHandler) Http(respWriter http.ResponseWriter, request *http.Req
 		return
 	}
 }
+
+// newDashboardBoardFilter reads the board filter from query params such as
+// ?team=payments&label=critical&owner=acme, params can be repeated.
+func newDashboardBoardFilter(query url.Values) dashboard.DashboardBoardFilter {
+	return dashboard.DashboardBoardFilter{
+		Groups: query["group"],
+		Labels: query["label"],
+		Owners: query["owner"],
+		Teams:  query["team"],
+	}
+}
+
+func newHomepageFacets(query url.Values, boardFacets dashboard.DashboardBoardFacets) []HomepageFacet {
+	var facets []HomepageFacet
+	for _, facet := range []struct {
+		name   string
+		param  string
+		values []string
+	}{
+		{name: "Team", param: "team", values: boardFacets.Teams},
+		{name: "Group", param: "group", values: boardFacets.Groups},
+		{name: "Label", param: "label", values: boardFacets.Labels},
+		{name: "Owner", param: "owner", values: boardFacets.Owners},
+	} {
+		if len(facet.values) == 0 {
+			continue
+		}
+		homepageFacet := HomepageFacet{Name: facet.name}
+		for _, value := range facet.values {
+			homepageFacet.Options = append(homepageFacet.Options, newHomepageFacetOption(query, facet.param, value))
+		}
+		facets = append(facets, homepageFacet)
+	}
+	return facets
+}
+
+func newHomepageFacetOption(query url.Values, param string, value string) HomepageFacetOption {
+	option := HomepageFacetOption{Value: value}
+
+	var paramValues []string
+	for _, paramValue := range query[param] {
+		if strings.EqualFold(paramValue, value) {
+			option.Selected = true
+			continue
+		}
+		paramValues = append(paramValues, paramValue)
+	}
+	if !option.Selected {
+		paramValues = append(paramValues, value)
+	}
+
+	optionQuery := url.Values{}
+	for key, values := range query {
+		optionQuery[key] = values
+	}
+	if len(paramValues) > 0 {
+		optionQuery[param] = paramValues
+	} else {
+		delete(optionQuery, param)
+	}
+
+	option.Url = "/"
+	if len(optionQuery) > 0 {
+		option.Url += "?" + optionQuery.Encode()
+	}
+	return option
+}
@@ -2,9 +2,11 @@ package handler_test
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -16,6 +18,8 @@ import (
 
 	mock_cache "github.com/lobsterdore/release-dash/mocks/cache"
 	mock_dashboard "github.com/lobsterdore/release-dash/mocks/dashboard"
+	mock_history "github.com/lobsterdore/release-dash/mocks/history"
+	mock_notify "github.com/lobsterdore/release-dash/mocks/notify"
 )
 
 func TestHomepageHasRepoHasChanges(t *testing.T) {
@@ -30,18 +34,33 @@ func TestHomepageHasRepoHasChanges(t *testing.T) {
 
 	mockCtx := context.Background()
 
-	mockMessage := "mock message"
+	mockMessage := "feat!: mock message"
+	mockTicketUrl := "https://jira/browse/PAY-1"
 	mockUrl := "u"
+	mockFailureUrl := "https://github.com/o/r/runs/1"
 
 	mockChangelogCommits := dashboard.DashboardChangelogCommits{
 		Commits: []scm.ScmCommit{
 			{
 				AuthorAvatarUrl: mockAvatarURL,
+				AuthoredAt:      time.Now().Add(-50 * time.Hour),
 				Message:         mockMessage,
 				HtmlUrl:         mockUrl,
+				Sha:             "a",
 			},
 		},
-		FromRef: "stg",
+		CommitStatuses: map[string]scm.ScmCommitStatus{
+			"a": {State: scm.CommitStatusSuccess},
+		},
+		FromRef:       "stg",
+		HiddenCommits: 2,
+		Staleness:     dashboard.StalenessRed,
+		Status: &scm.ScmCommitStatus{
+			FailureName: "lint",
+			FailureUrl:  mockFailureUrl,
+			State:       scm.CommitStatusFailure,
+		},
+		Tickets: []dashboard.DashboardTicket{{Key: "PAY-1", Url: mockTicketUrl}},
 		ToRef:   "dev",
 	}
 	mockRepoChangelog := dashboard.DashboardRepoChangelog{
@@ -86,9 +105,103 @@ func TestHomepageHasRepoHasChanges(t *testing.T) {
 	assert.Equal(t, rr.Code, http.StatusOK)
 	assert.Contains(t, resBody, mockRepoName)
 	assert.Contains(t, resBody, "dev > stg")
+	assert.Contains(t, resBody, "1 change (+2 hidden), oldest 2 days ago")
+	assert.Contains(t, resBody, `<div class="card z-depth-1 red darken-3">`)
+	assert.Contains(t, resBody, "Overdue for release")
+	assert.Contains(t, resBody, "breaking")
+	assert.Contains(t, resBody, "Breaking")
+	assert.Contains(t, resBody, "Tickets in this release")
+	assert.Contains(t, resBody, mockTicketUrl)
+	assert.Contains(t, resBody, "ci failing")
+	assert.Contains(t, resBody, mockFailureUrl)
+	assert.Contains(t, resBody, "ci passing")
+	assert.NotContains(t, resBody, "Draft release")
 	assert.Contains(t, resBody, mockMessage)
 }
 
+func TestHomepageHasRepoHasPullRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockRepoName := "r"
+	mockPullRequestUrl := "https://github.com/o/r/pull/12"
+
+	mockChangelogCommits := dashboard.DashboardChangelogCommits{
+		Commits: []scm.ScmCommit{
+			{Message: "add refunds", Sha: "a"},
+			{Message: "direct hotfix", Sha: "b"},
+		},
+		FromRef: "stg",
+		PullRequests: []dashboard.DashboardPullRequest{
+			{
+				CommitShas: []string{"a"},
+				ScmPullRequest: scm.ScmPullRequest{
+					HtmlUrl: mockPullRequestUrl,
+					Labels:  []string{"payments"},
+					Merged:  true,
+					Number:  12,
+					Title:   "Refunds",
+				},
+			},
+		},
+		ToRef: "dev",
+	}
+	mockRepoChangelogs := []dashboard.DashboardRepoChangelog{{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{mockChangelogCommits},
+		Config: &dashboard.DashboardRepoConfig{
+			DisplayMode:     dashboard.DisplayModePullRequests,
+			EnvironmentTags: []string{"dev", "stg"},
+			Name:            mockRepoName,
+		},
+		Repository: scm.ScmRepository{
+			OwnerName: "o",
+			Name:      mockRepoName,
+		},
+	}}
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		Times(1).
+		Return(mockRepoChangelogs, true)
+
+	homepageHandler := handler.HomepageHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+		ReleasesEnabled:  true,
+	}
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(homepageHandler.Http)
+
+	handler.ServeHTTP(rr, req)
+	resBody := rr.Body.String()
+
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assert.Contains(t, resBody, "#12 Refunds")
+	assert.Contains(t, resBody, "payments")
+	assert.Contains(t, resBody, mockPullRequestUrl)
+	assert.Contains(t, resBody, "Direct commits")
+	assert.Contains(t, resBody, "direct hotfix")
+	assert.NotContains(t, resBody, "add refunds")
+	assert.Contains(t, resBody, `action="/api/repos/o/r/releases"`)
+	assert.Contains(t, resBody, "Draft release")
+
+	// The form carries the CSRF token set as a cookie
+	cookies := rr.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "release_dash_csrf", cookies[0].Name)
+	assert.Len(t, cookies[0].Value, 64)
+	assert.Contains(t, resBody, `name="csrf_token" value="`+cookies[0].Value+`"`)
+}
+
 func TestHomepageHasRepoNoChanges(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
@@ -125,3 +238,220 @@ func TestHomepageHasRepoNoChanges(t *testing.T) {
 	assert.Equal(t, rr.Code, http.StatusOK)
 	assert.NotContains(t, resBody, "<h2>r</h2>")
 }
+
+func TestHomepageFilters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockChangelogCommits := []dashboard.DashboardChangelogCommits{{
+		Commits: []scm.ScmCommit{{Message: "mock message", Sha: "a"}},
+		FromRef: "stg",
+		ToRef:   "dev",
+	}}
+	mockRepoChangelogs := []dashboard.DashboardRepoChangelog{
+		{
+			ChangelogCommits: mockChangelogCommits,
+			Config:           &dashboard.DashboardRepoConfig{Group: "backend", Labels: []string{"critical"}, Name: "payments-api", Team: "payments"},
+			Repository:       scm.ScmRepository{Name: "payments-api", OwnerName: "acme"},
+		},
+		{
+			ChangelogCommits: mockChangelogCommits,
+			Config:           &dashboard.DashboardRepoConfig{Group: "frontend", Name: "storefront", Team: "web"},
+			Repository:       scm.ScmRepository{Name: "storefront", OwnerName: "acme"},
+		},
+	}
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		Times(3).
+		Return(mockRepoChangelogs, true)
+
+	homepageHandler := handler.HomepageHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	serveHomepage := func(url string) string {
+		req, _ := http.NewRequest("GET", url, nil)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(homepageHandler.Http).ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		return rr.Body.String()
+	}
+
+	resBody := serveHomepage("/")
+	assert.Contains(t, resBody, "payments-api")
+	assert.Contains(t, resBody, "storefront")
+	assert.Contains(t, resBody, `<h3 class="board-group-title">backend</h3>`)
+	assert.Contains(t, resBody, `href="/?team=payments"`)
+	assert.Contains(t, resBody, `href="/?label=critical"`)
+	assert.NotContains(t, resBody, "Clear filters")
+
+	resBody = serveHomepage("/?team=payments&owner=ACME")
+	assert.Contains(t, resBody, "payments-api")
+	assert.NotContains(t, resBody, "storefront</a>")
+	assert.NotContains(t, resBody, `<h3 class="board-group-title">frontend</h3>`)
+	assert.Contains(t, resBody, `href="/?owner=ACME"`)
+	assert.Contains(t, resBody, `href="/?owner=ACME&amp;team=payments&amp;team=web"`)
+	assert.Contains(t, resBody, "Clear filters")
+
+	resBody = serveHomepage("/?label=missing")
+	assert.Contains(t, resBody, "No repos match the filters")
+}
+
+func TestFetchChangelogsNotifies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+	mockNotifyService := mock_notify.NewMockNotifyProvider(ctrl)
+
+	mockCtx := context.Background()
+	mockRepos := []dashboard.DashboardRepo{newMockReleaseNotesRepo()}
+	mockRepoChangelogs := []dashboard.DashboardRepoChangelog{{Config: mockRepos[0].Config, Repository: mockRepos[0].Repository}}
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return(mockRepos, true)
+	mockDashboardService.
+		EXPECT().
+		GetDashboardChangelogs(mockCtx, mockRepos).
+		Times(1).
+		Return(mockRepoChangelogs)
+	mockCacheService.
+		EXPECT().
+		Set(gomock.Any(), gomock.Any(), "60").
+		Times(2)
+	mockNotifyService.
+		EXPECT().
+		Notify(mockCtx, mockRepoChangelogs).
+		Times(1)
+
+	homepageHandler := handler.HomepageHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+		NotifyService:    mockNotifyService,
+	}
+	homepageHandler.FetchChangelogs(mockCtx, "60")
+
+	ctrl.Finish()
+}
+
+func TestFetchChangelogsRecordsPromotions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockCtx := context.Background()
+	mockRepos := []dashboard.DashboardRepo{newMockReleaseNotesRepo()}
+	mockPreviousChangelogs := []dashboard.DashboardRepoChangelog{{Config: mockRepos[0].Config, Repository: mockRepos[0].Repository}}
+	mockRepoChangelogs := []dashboard.DashboardRepoChangelog{{Config: mockRepos[0].Config, Repository: mockRepos[0].Repository}}
+	mockPromotions := newMockPromotions()[:1]
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return(mockRepos, true)
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		Times(1).
+		Return(mockPreviousChangelogs, true)
+	mockDashboardService.
+		EXPECT().
+		GetDashboardChangelogs(mockCtx, mockRepos).
+		Times(1).
+		Return(mockRepoChangelogs)
+	mockCacheService.
+		EXPECT().
+		Set(gomock.Any(), gomock.Any(), "60").
+		Times(2)
+	mockDashboardService.
+		EXPECT().
+		GetDashboardPromotions(mockCtx, mockPreviousChangelogs, mockRepoChangelogs, gomock.Any()).
+		Times(1).
+		Return(mockPromotions)
+	mockCacheService.
+		EXPECT().
+		Get("feed_promotions").
+		Times(1).
+		Return(nil, false)
+	mockCacheService.
+		EXPECT().
+		Set("feed_promotions", mockPromotions, "-1").
+		Times(1)
+
+	homepageHandler := handler.HomepageHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+		FeedHandler:      &handler.FeedHandler{CacheService: mockCacheService},
+	}
+	homepageHandler.FetchChangelogs(mockCtx, "60")
+
+	ctrl.Finish()
+}
+
+func TestFetchChangelogsRecordsHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+	mockHistoryService := mock_history.NewMockHistoryProvider(ctrl)
+
+	mockCtx := context.Background()
+	mockRepos := []dashboard.DashboardRepo{newMockReleaseNotesRepo()}
+	mockPreviousChangelogs := []dashboard.DashboardRepoChangelog{{Config: &dashboard.DashboardRepoConfig{Name: "app"}, Repository: mockRepos[0].Repository}}
+	mockRepoChangelogs := []dashboard.DashboardRepoChangelog{{Config: mockRepos[0].Config, Repository: mockRepos[0].Repository}}
+	mockPromotions := newMockPromotions()[:1]
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return(mockRepos, true)
+	mockHistoryService.
+		EXPECT().
+		GetPreviousChangelogs().
+		Times(1).
+		Return(mockPreviousChangelogs, nil)
+	mockDashboardService.
+		EXPECT().
+		GetDashboardChangelogs(mockCtx, mockRepos).
+		Times(1).
+		Return(mockRepoChangelogs)
+	mockCacheService.
+		EXPECT().
+		Set(gomock.Any(), gomock.Any(), "60").
+		Times(2)
+	mockDashboardService.
+		EXPECT().
+		GetDashboardPromotions(mockCtx, mockPreviousChangelogs, mockRepoChangelogs, gomock.Any()).
+		Times(1).
+		Return(mockPromotions)
+	mockHistoryService.
+		EXPECT().
+		Record(mockRepoChangelogs, mockPromotions, gomock.Any()).
+		Times(1).
+		Return(errors.New("error"))
+	mockHistoryService.
+		EXPECT().
+		RecordSnapshots(mockRepoChangelogs, gomock.Any()).
+		Times(1).
+		Return(nil)
+
+	homepageHandler := handler.HomepageHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+		HistoryService:   mockHistoryService,
+	}
+	homepageHandler.FetchChangelogs(mockCtx, "60")
+
+	ctrl.Finish()
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/lobsterdore/release-dash/asset"
+	"github.com/lobsterdore/release-dash/cache"
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/web/templatefns"
+)
+
+const (
+	ReleaseNotesFormatMarkdown = "markdown"
+	ReleaseNotesFormatText     = "text"
+)
+
+type ReleaseNote struct {
+	ChangelogCommits dashboard.DashboardChangelogCommits
+	Name             string
+}
+
+type ReleaseNotesData struct {
+	ReleaseNotes []ReleaseNote
+}
+
+type ReleaseNotesHandler struct {
+	CacheService     cache.CacheAdapter
+	DashboardService dashboard.DashboardProvider
+}
+
+func NewReleaseNotesHandler(dashboardService *dashboard.DashboardService, cacheService cache.CacheAdapter) *ReleaseNotesHandler {
+	releaseNotesHandler := ReleaseNotesHandler{
+		CacheService:     cacheService,
+		DashboardService: dashboardService,
+	}
+
+	return &releaseNotesHandler
+}
+
+// errUnknownEnvironments is returned when release notes are requested for
+// refs that aren't environments of the repo, these are never compared so that
+// the dashboard can't be used to compare arbitrary refs.
+var errUnknownEnvironments = errors.New("from and to must be environments of the repo")
+
+// Http serves /api/repos/{owner}/{repo}/release-notes?from=stg&to=prod, the
+// notes cover the changes in from that are not yet in to. Adjacent
+// environments are served from the cached changelogs, any other pair of
+// environments is compared on demand.
+func (h *ReleaseNotesHandler) Http(respWriter http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		http.Error(respWriter, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	owner, repo, ok := parseRepoPath(request.URL.Path, "release-notes")
+	if !ok {
+		http.NotFound(respWriter, request)
+		return
+	}
+
+	query := request.URL.Query()
+	fromRef := query.Get("from")
+	toRef := query.Get("to")
+	if fromRef == "" || toRef == "" {
+		http.Error(respWriter, "The from and to query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = ReleaseNotesFormatMarkdown
+	}
+	if _, found := releaseNotesFormats[format]; !found {
+		http.Error(respWriter, "The format query parameter must be markdown or text", http.StatusBadRequest)
+		return
+	}
+
+	dashboardRepos, status := getRequestDashboardRepos(h.CacheService, owner, repo, query.Get("service"))
+	if status != http.StatusOK {
+		http.Error(respWriter, http.StatusText(status), status)
+		return
+	}
+
+	data, err := getReleaseNotes(request.Context(), h.CacheService, h.DashboardService, dashboardRepos, fromRef, toRef)
+	if err == errUnknownEnvironments {
+		http.NotFound(respWriter, request)
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("Could not get release notes for repo %s/%s", owner, repo)
+		http.Error(respWriter, "Could not get changelog", http.StatusInternalServerError)
+		return
+	}
+
+	releaseNotes, err := renderReleaseNotes(data, format)
+	if err != nil {
+		log.Error().Err(err).Msgf("Could not render release notes for repo %s/%s", owner, repo)
+		respWriter.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respWriter.Header().Set("Content-Type", releaseNotesFormats[format].ContentType)
+	_, _ = respWriter.Write([]byte(releaseNotes))
+}
+
+type releaseNotesFormat struct {
+	ContentType  string
+	TemplateFile string
+}
+
+var releaseNotesFormats = map[string]releaseNotesFormat{
+	ReleaseNotesFormatMarkdown: {ContentType: "text/markdown; charset=utf-8", TemplateFile: "text/release_notes.md"},
+	ReleaseNotesFormatText:     {ContentType: "text/plain; charset=utf-8", TemplateFile: "text/release_notes.txt"},
+}
+
+func renderReleaseNotes(data ReleaseNotesData, format string) (string, error) {
+	templateFile := releaseNotesFormats[format].TemplateFile
+	tmpl, err := template.New("release_notes").Funcs(template.FuncMap(templatefns.TemplateFnsMap)).Parse(asset.ReadTemplateFile(templateFile))
+	if err != nil {
+		return "", fmt.Errorf("Could not get %s: %s", templateFile, err)
+	}
+
+	var releaseNotes strings.Builder
+	err = tmpl.Execute(&releaseNotes, data)
+	if err != nil {
+		return "", fmt.Errorf("Could not execute %s: %s", templateFile, err)
+	}
+	return releaseNotes.String(), nil
+}
+
+func getReleaseNotes(ctx context.Context, cacheService cache.CacheAdapter, dashboardService dashboard.DashboardProvider, dashboardRepos []dashboard.DashboardRepo, fromRef string, toRef string) (ReleaseNotesData, error) {
+	var data ReleaseNotesData
+	for _, dashboardRepo := range dashboardRepos {
+		if !isEnvironmentPair(dashboardRepo.Config, fromRef, toRef) {
+			return data, errUnknownEnvironments
+		}
+	}
+	for _, dashboardRepo := range dashboardRepos {
+		changelogCommits, err := getChangelogCommits(ctx, cacheService, dashboardService, dashboardRepo, fromRef, toRef)
+		if err != nil {
+			return data, err
+		}
+		data.ReleaseNotes = append(data.ReleaseNotes, ReleaseNote{
+			ChangelogCommits: *changelogCommits,
+			Name:             dashboardRepo.Config.Name,
+		})
+	}
+	return data, nil
+}
+
+// getChangelogCommits looks for a cached changelog between the refs before
+// comparing them, cached changelogs run from the FromRef to the ToRef so the
+// lookup is reversed.
+func getChangelogCommits(ctx context.Context, cacheService cache.CacheAdapter, dashboardService dashboard.DashboardProvider, dashboardRepo dashboard.DashboardRepo, fromRef string, toRef string) (*dashboard.DashboardChangelogCommits, error) {
+	cachedData, found := cacheService.Get("homepage_changelog_data")
+	if found {
+		for _, repoChangelog := range cachedData.([]dashboard.DashboardRepoChangelog) {
+			if !isSameDashboardRepo(repoChangelog.Repository.OwnerName, repoChangelog.Repository.Name, repoChangelog.Config, dashboardRepo) {
+				continue
+			}
+			for _, changelogCommits := range repoChangelog.ChangelogCommits {
+				if changelogCommits.ToRef == fromRef && changelogCommits.FromRef == toRef {
+					return &changelogCommits, nil
+				}
+			}
+		}
+	}
+
+	return dashboardService.GetDashboardChangelogForRefs(ctx, dashboardRepo, toRef, fromRef)
+}
+
+func isEnvironmentPair(repoConfig *dashboard.DashboardRepoConfig, fromRef string, toRef string) bool {
+	if fromRef == toRef {
+		return false
+	}
+	foundRefs := 0
+	for _, environmentRef := range repoConfig.EnvironmentRefs() {
+		if environmentRef == fromRef || environmentRef == toRef {
+			foundRefs++
+		}
+	}
+	return foundRefs == 2
+}
+
+// getRequestDashboardRepos finds the cached dashboard repos for a request, the
+// http status to respond with is returned if none are found.
+func getRequestDashboardRepos(cacheService cache.CacheAdapter, owner string, repo string, service string) ([]dashboard.DashboardRepo, int) {
+	cachedData, found := cacheService.Get("homepage_repo_data")
+	if !found {
+		return nil, http.StatusServiceUnavailable
+	}
+
+	dashboardRepos := findDashboardRepos(cachedData.([]dashboard.DashboardRepo), owner, repo, service)
+	if len(dashboardRepos) == 0 {
+		return nil, http.StatusNotFound
+	}
+	return dashboardRepos, http.StatusOK
+}
+
+// parseRepoPath pulls the owner and repo out of /api/repos/{owner}/{repo}/{action}.
+func parseRepoPath(path string, action string) (string, string, bool) {
+	pathParts := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api/repos/"), "/"), "/")
+	if len(pathParts) != 3 || pathParts[0] == "" || pathParts[1] == "" || pathParts[2] != action {
+		return "", "", false
+	}
+	return pathParts[0], pathParts[1], true
+}
+
+// findDashboardRepos returns the dashboard repos for a GitHub repo, monorepos
+// can have one dashboard repo per service which can be narrowed down by name.
+func findDashboardRepos(dashboardRepos []dashboard.DashboardRepo, owner string, repo string, service string) []dashboard.DashboardRepo {
+	var foundRepos []dashboard.DashboardRepo
+	for _, dashboardRepo := range dashboardRepos {
+		if !strings.EqualFold(dashboardRepo.Repository.OwnerName, owner) || !strings.EqualFold(dashboardRepo.Repository.Name, repo) {
+			continue
+		}
+		if service != "" && !strings.EqualFold(dashboardRepo.Config.Name, service) {
+			continue
+		}
+		foundRepos = append(foundRepos, dashboardRepo)
+	}
+	return foundRepos
+}
+
+func isSameDashboardRepo(owner string, repo string, repoConfig *dashboard.DashboardRepoConfig, dashboardRepo dashboard.DashboardRepo) bool {
+	return strings.EqualFold(owner, dashboardRepo.Repository.OwnerName) &&
+		strings.EqualFold(repo, dashboardRepo.Repository.Name) &&
+		repoConfig.Name == dashboardRepo.Config.Name
+}
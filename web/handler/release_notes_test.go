@@ -0,0 +1,372 @@
+package handler_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/lobsterdore/release-dash/web/handler"
+
+	mock_cache "github.com/lobsterdore/release-dash/mocks/cache"
+	mock_dashboard "github.com/lobsterdore/release-dash/mocks/dashboard"
+)
+
+func newMockReleaseNotesRepo() dashboard.DashboardRepo {
+	return dashboard.DashboardRepo{
+		Config: &dashboard.DashboardRepoConfig{
+			EnvironmentTags: []string{"dev", "stg", "prod"},
+			Name:            "app",
+		},
+		Repository: scm.ScmRepository{
+			HtmlUrl:   "https://github.com/o/r",
+			Name:      "r",
+			OwnerName: "o",
+		},
+	}
+}
+
+func newMockReleaseNotesChangelogCommits() dashboard.DashboardChangelogCommits {
+	return dashboard.DashboardChangelogCommits{
+		Commits: []scm.ScmCommit{
+			{HtmlUrl: "https://github.com/o/r/commit/a", Message: "feat: PAY-1 add refunds\n\nbody", Sha: "3e0f3d8c432ca2a03a3222fb55de63934338022f"},
+			{HtmlUrl: "https://github.com/o/r/commit/b", Message: "fix: rounding", Sha: "812b303948b570247b727aeb8c1b187336ad4256"},
+		},
+		FromRef: "prod",
+		Tickets: []dashboard.DashboardTicket{{Key: "PAY-1", Url: "https://jira/browse/PAY-1"}},
+		ToRef:   "stg",
+	}
+}
+
+func serveReleaseNotes(releaseNotesHandler handler.ReleaseNotesHandler, method string, url string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(releaseNotesHandler.Http).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestReleaseNotesFromCacheMarkdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockRepo := newMockReleaseNotesRepo()
+	mockRepoChangelogs := []dashboard.DashboardRepoChangelog{{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{newMockReleaseNotesChangelogCommits()},
+		Config:           mockRepo.Config,
+		Repository:       mockRepo.Repository,
+	}}
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return([]dashboard.DashboardRepo{mockRepo}, true)
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		Times(1).
+		Return(mockRepoChangelogs, true)
+	mockDashboardService.
+		EXPECT().
+		GetDashboardChangelogForRefs(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	releaseNotesHandler := handler.ReleaseNotesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	rr := serveReleaseNotes(releaseNotesHandler, "GET", "/api/repos/o/r/release-notes?from=stg&to=prod")
+
+	expected := `# app: stg > prod
+
+## Features
+
+- feat: [PAY-1](https://jira/browse/PAY-1) add refunds ([3e0f3d8](https://github.com/o/r/commit/a))
+
+## Fixes
+
+- fix: rounding ([812b303](https://github.com/o/r/commit/b))
+
+## Tickets
+
+- [PAY-1](https://jira/browse/PAY-1)
+`
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/markdown; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, expected, rr.Body.String())
+}
+
+func TestReleaseNotesOnDemandText(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockRepo := newMockReleaseNotesRepo()
+	mockChangelogCommits := newMockReleaseNotesChangelogCommits()
+	mockChangelogCommits.FromRef = "prod"
+	mockChangelogCommits.ToRef = "dev"
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return([]dashboard.DashboardRepo{mockRepo}, true)
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		Times(1).
+		Return(nil, false)
+	mockDashboardService.
+		EXPECT().
+		GetDashboardChangelogForRefs(gomock.Any(), mockRepo, "prod", "dev").
+		Times(1).
+		Return(&mockChangelogCommits, nil)
+
+	releaseNotesHandler := handler.ReleaseNotesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	rr := serveReleaseNotes(releaseNotesHandler, "GET", "/api/repos/O/R/release-notes?from=dev&to=prod&format=text")
+
+	expected := `app: dev > prod
+
+Features
+- feat: PAY-1 add refunds (3e0f3d8)
+
+Fixes
+- fix: rounding (812b303)
+
+Tickets
+- PAY-1 https://jira/browse/PAY-1
+`
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, expected, rr.Body.String())
+}
+
+func TestReleaseNotesOnDemandError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return([]dashboard.DashboardRepo{newMockReleaseNotesRepo()}, true)
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		Times(1).
+		Return(nil, false)
+	mockDashboardService.
+		EXPECT().
+		GetDashboardChangelogForRefs(gomock.Any(), gomock.Any(), "prod", "dev").
+		Times(1).
+		Return(nil, errors.New("error"))
+
+	releaseNotesHandler := handler.ReleaseNotesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	rr := serveReleaseNotes(releaseNotesHandler, "GET", "/api/repos/o/r/release-notes?from=dev&to=prod")
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestReleaseNotesUnknownEnvironments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(3).
+		Return([]dashboard.DashboardRepo{newMockReleaseNotesRepo()}, true)
+	mockDashboardService.
+		EXPECT().
+		GetDashboardChangelogForRefs(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	releaseNotesHandler := handler.ReleaseNotesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	for _, url := range []string{
+		"/api/repos/o/r/release-notes?from=main&to=v1.0.0",
+		"/api/repos/o/r/release-notes?from=stg&to=v1.0.0",
+		"/api/repos/o/r/release-notes?from=stg&to=stg",
+	} {
+		rr := serveReleaseNotes(releaseNotesHandler, "GET", url)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code, url)
+	}
+}
+
+func TestReleaseNotesNoChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockRepo := newMockReleaseNotesRepo()
+	mockRepoChangelogs := []dashboard.DashboardRepoChangelog{{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{{FromRef: "prod", ToRef: "stg"}},
+		Config:           mockRepo.Config,
+		Repository:       mockRepo.Repository,
+	}}
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return([]dashboard.DashboardRepo{mockRepo}, true)
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		Times(1).
+		Return(mockRepoChangelogs, true)
+
+	releaseNotesHandler := handler.ReleaseNotesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	rr := serveReleaseNotes(releaseNotesHandler, "GET", "/api/repos/o/r/release-notes?from=stg&to=prod")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "# app: stg > prod\n\nNo changes to release\n", rr.Body.String())
+}
+
+func TestReleaseNotesBadRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		AnyTimes().
+		Return([]dashboard.DashboardRepo{newMockReleaseNotesRepo()}, true)
+
+	releaseNotesHandler := handler.ReleaseNotesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	tests := map[string]int{
+		"/api/repos/o/r/release-notes":                                  http.StatusBadRequest,
+		"/api/repos/o/r/release-notes?from=stg&to=prod&format=html":     http.StatusBadRequest,
+		"/api/repos/o/missing/release-notes?from=stg&to=prod":           http.StatusNotFound,
+		"/api/repos/o/r/release-notes?from=stg&to=prod&service=missing": http.StatusNotFound,
+		"/api/repos/o/r/other?from=stg&to=prod":                         http.StatusNotFound,
+	}
+
+	for url, expectedCode := range tests {
+		rr := serveReleaseNotes(releaseNotesHandler, "GET", url)
+		assert.Equal(t, expectedCode, rr.Code, url)
+	}
+
+	rr := serveReleaseNotes(releaseNotesHandler, "POST", "/api/repos/o/r/release-notes?from=stg&to=prod")
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func TestReleaseNotesNoRepoData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return(nil, false)
+
+	releaseNotesHandler := handler.ReleaseNotesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	rr := serveReleaseNotes(releaseNotesHandler, "GET", "/api/repos/o/r/release-notes?from=stg&to=prod")
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestReleaseNotesPullRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockRepo := newMockReleaseNotesRepo()
+	mockChangelogCommits := newMockReleaseNotesChangelogCommits()
+	mockChangelogCommits.PullRequests = []dashboard.DashboardPullRequest{{
+		CommitShas: []string{"3e0f3d8c432ca2a03a3222fb55de63934338022f"},
+		ScmPullRequest: scm.ScmPullRequest{
+			HtmlUrl: "https://github.com/o/r/pull/12",
+			Merged:  true,
+			Number:  12,
+			Title:   "PAY-1 Refunds",
+		},
+	}}
+	mockRepoChangelogs := []dashboard.DashboardRepoChangelog{{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{mockChangelogCommits},
+		Config:           mockRepo.Config,
+		Repository:       mockRepo.Repository,
+	}}
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return([]dashboard.DashboardRepo{mockRepo}, true)
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		Times(1).
+		Return(mockRepoChangelogs, true)
+
+	releaseNotesHandler := handler.ReleaseNotesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	rr := serveReleaseNotes(releaseNotesHandler, "GET", "/api/repos/o/r/release-notes?from=stg&to=prod")
+
+	expected := `# app: stg > prod
+
+## Pull requests
+
+- [PAY-1](https://jira/browse/PAY-1) Refunds ([#12](https://github.com/o/r/pull/12))
+
+## Direct commits
+
+- fix: rounding ([812b303](https://github.com/o/r/commit/b))
+
+## Tickets
+
+- [PAY-1](https://jira/browse/PAY-1)
+`
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, expected, rr.Body.String())
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/lobsterdore/release-dash/cache"
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/scm"
+)
+
+// releaseDedupeSeconds is how long a release is remembered for, the same tag
+// posted again within this time gets the first release back.
+const releaseDedupeSeconds = "3600"
+
+type ReleasesHandler struct {
+	CacheService     cache.CacheAdapter
+	DashboardService dashboard.DashboardProvider
+	Enabled          bool
+	// Secret has to be sent with every request, either as a bearer token or
+	// as the password of basic auth
+	Secret string
+}
+
+type releaseData struct {
+	Draft           bool   `json:"draft"`
+	HtmlUrl         string `json:"html_url"`
+	Name            string `json:"name"`
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish"`
+}
+
+func NewReleasesHandler(dashboardService *dashboard.DashboardService, cacheService cache.CacheAdapter, enabled bool, secret string) *ReleasesHandler {
+	releasesHandler := ReleasesHandler{
+		CacheService:     cacheService,
+		DashboardService: dashboardService,
+		Enabled:          enabled,
+		Secret:           secret,
+	}
+
+	return &releasesHandler
+}
+
+// Http serves POST /api/repos/{owner}/{repo}/releases?from=stg&to=prod&tag=v1.2.0,
+// a draft release is created for the tag at the head of from with the release
+// notes for the changes that are not yet in to. As with the release notes, from
+// is the upstream environment being released and to is the downstream
+// environment it is released to. This is the other way round to the changelog
+// of a card, shown as ToRef > FromRef, so the card posts from=ToRef and
+// to=FromRef. Form posts from the dashboard set redirect to be sent on to the
+// draft release.
+//
+// API clients authenticate with the secret as a bearer token. Browsers use
+// basic auth, which they send with any request to the dashboard, so form
+// posts must also come from the dashboard and carry its CSRF token.
+func (h *ReleasesHandler) Http(respWriter http.ResponseWriter, request *http.Request) {
+	if !h.Enabled {
+		http.NotFound(respWriter, request)
+		return
+	}
+
+	if request.Method != http.MethodPost {
+		http.Error(respWriter, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	bearerAuth, authorised := h.checkAuth(request)
+	if !authorised {
+		respWriter.Header().Set("WWW-Authenticate", `Basic realm="release-dash releases"`)
+		http.Error(respWriter, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	if !bearerAuth && (!checkSameOrigin(request) || !checkCsrfToken(request)) {
+		http.Error(respWriter, "Releases must be created from the dashboard", http.StatusForbidden)
+		return
+	}
+
+	owner, repo, ok := parseRepoPath(request.URL.Path, "releases")
+	if !ok {
+		http.NotFound(respWriter, request)
+		return
+	}
+
+	fromRef := request.FormValue("from")
+	toRef := request.FormValue("to")
+	tagName := request.FormValue("tag")
+	if fromRef == "" || toRef == "" || tagName == "" {
+		http.Error(respWriter, "The from, to and tag parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	dashboardRepos, status := getRequestDashboardRepos(h.CacheService, owner, repo, request.FormValue("service"))
+	if status != http.StatusOK {
+		http.Error(respWriter, http.StatusText(status), status)
+		return
+	}
+
+	// Double submits and retries get the release that was already created
+	// rather than another draft
+	dedupeKey := "releases_created_" + strings.ToLower(owner+"/"+repo+"/"+tagName)
+	if cachedRelease, found := h.CacheService.Get(dedupeKey); found {
+		writeRelease(respWriter, request, cachedRelease.(*scm.ScmRelease), http.StatusOK)
+		return
+	}
+
+	data, err := getReleaseNotes(request.Context(), h.CacheService, h.DashboardService, dashboardRepos, fromRef, toRef)
+	if err == errUnknownEnvironments {
+		http.NotFound(respWriter, request)
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("Could not get release notes for repo %s/%s", owner, repo)
+		http.Error(respWriter, "Could not get changelog", http.StatusInternalServerError)
+		return
+	}
+
+	releaseNotes, err := renderReleaseNotes(data, ReleaseNotesFormatMarkdown)
+	if err != nil {
+		log.Error().Err(err).Msgf("Could not render release notes for repo %s/%s", owner, repo)
+		respWriter.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	release, err := h.DashboardService.CreateDashboardRelease(request.Context(), dashboardRepos[0], fromRef, tagName, releaseNotes)
+	if err != nil {
+		log.Error().Err(err).Msgf("Could not create release %s for repo %s/%s", tagName, owner, repo)
+		http.Error(respWriter, "Could not create release", http.StatusInternalServerError)
+		return
+	}
+
+	h.CacheService.Set(dedupeKey, release, releaseDedupeSeconds)
+	writeRelease(respWriter, request, release, http.StatusCreated)
+}
+
+// checkAuth looks for the secret in the request, bearer is set when it was
+// sent as a bearer token rather than by a browser.
+func (h *ReleasesHandler) checkAuth(request *http.Request) (bool, bool) {
+	if h.Secret == "" {
+		return false, false
+	}
+
+	authorization := request.Header.Get("Authorization")
+	if strings.HasPrefix(authorization, "Bearer ") {
+		token := strings.TrimPrefix(authorization, "Bearer ")
+		return true, subtle.ConstantTimeCompare([]byte(token), []byte(h.Secret)) == 1
+	}
+
+	_, password, ok := request.BasicAuth()
+	return false, ok && subtle.ConstantTimeCompare([]byte(password), []byte(h.Secret)) == 1
+}
+
+func writeRelease(respWriter http.ResponseWriter, request *http.Request, release *scm.ScmRelease, status int) {
+	if request.FormValue("redirect") == "true" {
+		http.Redirect(respWriter, request, release.HtmlUrl, http.StatusSeeOther)
+		return
+	}
+
+	responseBytes, err := json.Marshal(releaseData{
+		Draft:           release.Draft,
+		HtmlUrl:         release.HtmlUrl,
+		Name:            release.Name,
+		TagName:         release.TagName,
+		TargetCommitish: release.TargetCommitish,
+	})
+	if err != nil {
+		respWriter.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	respWriter.Header().Set("Content-Type", "application/json")
+	respWriter.WriteHeader(status)
+	_, _ = respWriter.Write(responseBytes)
+}
@@ -0,0 +1,336 @@
+package handler_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/lobsterdore/release-dash/web/handler"
+
+	mock_cache "github.com/lobsterdore/release-dash/mocks/cache"
+	mock_dashboard "github.com/lobsterdore/release-dash/mocks/dashboard"
+)
+
+const (
+	mockReleasesSecret    = "s3cret"
+	mockReleasesCsrfToken = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+)
+
+func serveReleases(releasesHandler handler.ReleasesHandler, method string, target string, form url.Values) *httptest.ResponseRecorder {
+	return serveReleasesRequest(releasesHandler, method, target, form, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+mockReleasesSecret)
+	})
+}
+
+// serveReleasesForm posts like the dashboard form does, from the same origin
+// with basic auth and the CSRF token.
+func serveReleasesForm(releasesHandler handler.ReleasesHandler, form url.Values, origin string, csrfToken string) *httptest.ResponseRecorder {
+	form.Set("csrf_token", csrfToken)
+	return serveReleasesRequest(releasesHandler, "POST", "http://dash.example.com/api/repos/o/r/releases", form, func(req *http.Request) {
+		req.SetBasicAuth("me", mockReleasesSecret)
+		req.Header.Set("Origin", origin)
+		req.AddCookie(&http.Cookie{Name: "release_dash_csrf", Value: mockReleasesCsrfToken})
+	})
+}
+
+func serveReleasesRequest(releasesHandler handler.ReleasesHandler, method string, target string, form url.Values, setup func(*http.Request)) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	setup(req)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(releasesHandler.Http).ServeHTTP(rr, req)
+	return rr
+}
+
+func setupReleasesCache(mockCacheService *mock_cache.MockCacheAdapter) dashboard.DashboardRepo {
+	mockRepo := newMockReleaseNotesRepo()
+	mockRepoChangelogs := []dashboard.DashboardRepoChangelog{{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{newMockReleaseNotesChangelogCommits()},
+		Config:           mockRepo.Config,
+		Repository:       mockRepo.Repository,
+	}}
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return([]dashboard.DashboardRepo{mockRepo}, true)
+	mockCacheService.
+		EXPECT().
+		Get("releases_created_o/r/v1.2.0").
+		Times(1).
+		Return(nil, false)
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		Times(1).
+		Return(mockRepoChangelogs, true)
+
+	return mockRepo
+}
+
+func TestReleasesCreatesDraftRelease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockRepo := setupReleasesCache(mockCacheService)
+	mockRelease := scm.ScmRelease{
+		Draft:           true,
+		HtmlUrl:         "https://github.com/o/r/releases/tag/untagged-1",
+		Name:            "v1.2.0",
+		TagName:         "v1.2.0",
+		TargetCommitish: "s",
+	}
+
+	mockDashboardService.
+		EXPECT().
+		CreateDashboardRelease(gomock.Any(), mockRepo, "stg", "v1.2.0", gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ interface{}, _ dashboard.DashboardRepo, _ string, _ string, body string) (*scm.ScmRelease, error) {
+			assert.Contains(t, body, "# app: stg > prod")
+			assert.Contains(t, body, "[PAY-1](https://jira/browse/PAY-1)")
+			return &mockRelease, nil
+		})
+	mockCacheService.
+		EXPECT().
+		Set("releases_created_o/r/v1.2.0", &mockRelease, "3600").
+		Times(1)
+
+	releasesHandler := handler.ReleasesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+		Enabled:          true,
+		Secret:           mockReleasesSecret,
+	}
+
+	rr := serveReleases(releasesHandler, "POST", "/api/repos/o/r/releases?from=stg&to=prod&tag=v1.2.0", url.Values{})
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.JSONEq(t, `{
+		"draft": true,
+		"html_url": "https://github.com/o/r/releases/tag/untagged-1",
+		"name": "v1.2.0",
+		"tag_name": "v1.2.0",
+		"target_commitish": "s"
+	}`, rr.Body.String())
+}
+
+func TestReleasesFormRedirects(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	setupReleasesCache(mockCacheService)
+	mockRelease := scm.ScmRelease{HtmlUrl: "https://github.com/o/r/releases/tag/untagged-1"}
+
+	mockDashboardService.
+		EXPECT().
+		CreateDashboardRelease(gomock.Any(), gomock.Any(), "stg", "v1.2.0", gomock.Any()).
+		Times(1).
+		Return(&mockRelease, nil)
+	mockCacheService.
+		EXPECT().
+		Set("releases_created_o/r/v1.2.0", &mockRelease, "3600").
+		Times(1)
+
+	releasesHandler := handler.ReleasesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+		Enabled:          true,
+		Secret:           mockReleasesSecret,
+	}
+
+	form := url.Values{
+		"from":     {"stg"},
+		"redirect": {"true"},
+		"service":  {"app"},
+		"tag":      {"v1.2.0"},
+		"to":       {"prod"},
+	}
+	rr := serveReleasesForm(releasesHandler, form, "http://dash.example.com", mockReleasesCsrfToken)
+
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Equal(t, mockRelease.HtmlUrl, rr.Header().Get("Location"))
+}
+
+func TestReleasesCreateError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	setupReleasesCache(mockCacheService)
+
+	mockDashboardService.
+		EXPECT().
+		CreateDashboardRelease(gomock.Any(), gomock.Any(), "stg", "v1.2.0", gomock.Any()).
+		Times(1).
+		Return(nil, errors.New("error"))
+
+	releasesHandler := handler.ReleasesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+		Enabled:          true,
+		Secret:           mockReleasesSecret,
+	}
+
+	rr := serveReleases(releasesHandler, "POST", "/api/repos/o/r/releases?from=stg&to=prod&tag=v1.2.0", url.Values{})
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestReleasesBadRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	releasesHandler := handler.ReleasesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+		Enabled:          true,
+		Secret:           mockReleasesSecret,
+	}
+
+	rr := serveReleases(releasesHandler, "POST", "/api/repos/o/r/releases?from=stg&to=prod", url.Values{})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serveReleases(releasesHandler, "GET", "/api/repos/o/r/releases?from=stg&to=prod&tag=v1.2.0", url.Values{})
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func TestReleasesNotEnabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	releasesHandler := handler.ReleasesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	rr := serveReleases(releasesHandler, "POST", "/api/repos/o/r/releases?from=stg&to=prod&tag=v1.2.0", url.Values{})
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestReleasesDeduplicated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockRelease := scm.ScmRelease{HtmlUrl: "https://github.com/o/r/releases/tag/untagged-1", TagName: "v1.2.0"}
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return([]dashboard.DashboardRepo{newMockReleaseNotesRepo()}, true)
+	mockCacheService.
+		EXPECT().
+		Get("releases_created_o/r/v1.2.0").
+		Times(1).
+		Return(&mockRelease, true)
+	mockDashboardService.
+		EXPECT().
+		CreateDashboardRelease(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	releasesHandler := handler.ReleasesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+		Enabled:          true,
+		Secret:           mockReleasesSecret,
+	}
+
+	rr := serveReleases(releasesHandler, "POST", "/api/repos/O/R/releases?from=stg&to=prod&tag=V1.2.0", url.Values{})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"html_url":"https://github.com/o/r/releases/tag/untagged-1"`)
+}
+
+func TestReleasesUnauthorised(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockDashboardService.
+		EXPECT().
+		CreateDashboardRelease(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	releasesHandler := handler.ReleasesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+		Enabled:          true,
+		Secret:           mockReleasesSecret,
+	}
+
+	target := "/api/repos/o/r/releases?from=stg&to=prod&tag=v1.2.0"
+	for name, setup := range map[string]func(*http.Request){
+		"none":         func(req *http.Request) {},
+		"wrong bearer": func(req *http.Request) { req.Header.Set("Authorization", "Bearer nope") },
+		"wrong basic":  func(req *http.Request) { req.SetBasicAuth("me", "nope") },
+	} {
+		rr := serveReleasesRequest(releasesHandler, "POST", target, url.Values{}, setup)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, name)
+		assert.Equal(t, `Basic realm="release-dash releases"`, rr.Header().Get("WWW-Authenticate"), name)
+	}
+
+	// Releases can't be created without a secret configured
+	releasesHandler.Secret = ""
+	rr := serveReleasesRequest(releasesHandler, "POST", target, url.Values{}, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer ")
+	})
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestReleasesFormForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockDashboardService.
+		EXPECT().
+		CreateDashboardRelease(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	releasesHandler := handler.ReleasesHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+		Enabled:          true,
+		Secret:           mockReleasesSecret,
+	}
+
+	form := func() url.Values {
+		return url.Values{"from": {"stg"}, "tag": {"v1.2.0"}, "to": {"prod"}}
+	}
+
+	rr := serveReleasesForm(releasesHandler, form(), "https://evil.example.com", mockReleasesCsrfToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = serveReleasesForm(releasesHandler, form(), "", mockReleasesCsrfToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = serveReleasesForm(releasesHandler, form(), "http://dash.example.com", "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = serveReleasesForm(releasesHandler, form(), "http://dash.example.com", strings.Repeat("0", 64))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
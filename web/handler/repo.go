@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/lobsterdore/release-dash/asset"
+	"github.com/lobsterdore/release-dash/cache"
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/lobsterdore/release-dash/web/templatefns"
+)
+
+type RepoData struct {
+	HtmlUrl     string
+	Owner       string
+	RefreshedAt time.Time
+	Repo        string
+	Services    []RepoServiceData
+}
+
+type RepoServiceData struct {
+	// Changelog is nil until changelogs have been fetched for the repo
+	Changelog     *dashboard.DashboardRepoChangelog
+	DashboardRepo dashboard.DashboardRepo
+	Environments  []dashboard.DashboardEnvironment
+	// Trend is nil unless the history is enabled
+	Trend *TrendChart
+}
+
+type RepoLookupData struct {
+	Owner   string
+	Ref     string
+	Repo    string
+	Results []RepoLookupResult
+}
+
+type RepoLookupResult struct {
+	DashboardRepo dashboard.DashboardRepo
+	Error         string
+	Location      *dashboard.DashboardCommitLocation
+}
+
+type RepoHandler struct {
+	CacheService     cache.CacheAdapter
+	DashboardService dashboard.DashboardProvider
+	HistoryService   history.HistoryProvider
+}
+
+func NewRepoHandler(dashboardService *dashboard.DashboardService, cacheService cache.CacheAdapter, historyService history.HistoryProvider) *RepoHandler {
+	repoHandler := RepoHandler{
+		CacheService:     cacheService,
+		DashboardService: dashboardService,
+		HistoryService:   historyService,
+	}
+
+	return &repoHandler
+}
+
+// Http serves /repos/{owner}/{repo}, the full pipeline for a single repo
+// including any services in a monorepo, and /repos/{owner}/{repo}/lookup?ref=
+// which finds the environments that contain a commit or pull request.
+func (h *RepoHandler) Http(respWriter http.ResponseWriter, request *http.Request) {
+	pathParts := strings.Split(strings.Trim(strings.TrimPrefix(request.URL.Path, "/repos/"), "/"), "/")
+	isLookup := len(pathParts) == 3 && pathParts[2] == "lookup"
+	if (len(pathParts) != 2 && !isLookup) || pathParts[0] == "" || pathParts[1] == "" {
+		http.NotFound(respWriter, request)
+		return
+	}
+	owner := pathParts[0]
+	repo := pathParts[1]
+
+	var tmpl *template.Template
+	var data interface{}
+	var err error
+
+	cachedData, found := h.CacheService.Get("homepage_repo_data")
+	if found {
+		dashboardRepos := findDashboardRepos(cachedData.([]dashboard.DashboardRepo), owner, repo, "")
+		if len(dashboardRepos) == 0 {
+			http.NotFound(respWriter, request)
+			return
+		}
+
+		templateFile := "html/repo.html"
+		if isLookup {
+			templateFile = "html/repo_lookup.html"
+		}
+
+		tmpl, err = template.New("repo").Funcs(templatefns.TemplateFnsMap).Parse(asset.ReadTemplateFile("html/base.html"))
+		if err != nil {
+			log.Error().Err(err).Msg("Could not get html/base.html")
+			return
+		}
+
+		for _, parseFile := range []string{templateFile, "html/trend_chart.html", "html/ci_status.html"} {
+			tmpl, err = tmpl.Parse(asset.ReadTemplateFile(parseFile))
+			if err != nil {
+				log.Error().Err(err).Msgf("Could not get %s", parseFile)
+				return
+			}
+		}
+
+		if isLookup {
+			data = h.getRepoLookupData(request, dashboardRepos)
+		} else {
+			data = h.getRepoData(request, dashboardRepos)
+		}
+	} else {
+		tmpl, err = template.New("homepage_loading").Funcs(templatefns.TemplateFnsMap).Parse(asset.ReadTemplateFile("html/base.html"))
+		if err != nil {
+			log.Error().Err(err).Msg("Could not get html/base.html")
+			return
+		}
+
+		tmpl, err = tmpl.Parse(asset.ReadTemplateFile("html/homepage_loading.html"))
+		if err != nil {
+			log.Error().Err(err).Msg("Could not get html/homepage_loading.html")
+			return
+		}
+	}
+
+	err = tmpl.Execute(respWriter, data)
+	if err != nil {
+		respWriter.WriteHeader(http.StatusInternalServerError)
+		_, _ = respWriter.Write([]byte(err.Error()))
+		return
+	}
+}
+
+func (h *RepoHandler) getRepoData(request *http.Request, dashboardRepos []dashboard.DashboardRepo) RepoData {
+	repository := dashboardRepos[0].Repository
+	data := RepoData{
+		HtmlUrl: repository.HtmlUrl,
+		Owner:   repository.OwnerName,
+		Repo:    repository.Name,
+	}
+
+	if cachedRefreshed, found := h.CacheService.Get("homepage_changelog_refreshed"); found {
+		data.RefreshedAt = cachedRefreshed.(time.Time)
+	}
+
+	trendUntil := time.Now()
+	trendSince := trendUntil.AddDate(0, 0, -trendDaysDefault)
+
+	var repoChangelogs []dashboard.DashboardRepoChangelog
+	if cachedChangelogs, found := h.CacheService.Get("homepage_changelog_data"); found {
+		repoChangelogs = cachedChangelogs.([]dashboard.DashboardRepoChangelog)
+	}
+
+	snapshots, snapshotsFound := h.getRepoSnapshots(repository, trendSince, trendUntil)
+
+	for _, dashboardRepo := range dashboardRepos {
+		service := RepoServiceData{DashboardRepo: dashboardRepo}
+		if dashboardRepo.HasConfig() {
+			if snapshotsFound {
+				trend := newTrendChart(filterServiceSnapshots(snapshots, dashboardRepo.Config.Name), trendSince, trendUntil)
+				service.Trend = &trend
+			}
+		}
+		for index := range repoChangelogs {
+			repoChangelog := &repoChangelogs[index]
+			if isSameDashboardRepo(repoChangelog.Repository.OwnerName, repoChangelog.Repository.Name, repoChangelog.Config, dashboardRepo) {
+				service.Changelog = repoChangelog
+				service.Environments = repoChangelog.Environments
+				break
+			}
+		}
+		data.Services = append(data.Services, service)
+	}
+
+	return data
+}
+
+// getRepoSnapshots reads the pending commit snapshots for the trend charts,
+// false is returned if the history is disabled or could not be read.
+func (h *RepoHandler) getRepoSnapshots(repository scm.ScmRepository, since time.Time, until time.Time) ([]history.HistorySnapshot, bool) {
+	if h.HistoryService == nil {
+		return nil, false
+	}
+	snapshots, err := h.HistoryService.GetSnapshots(history.HistoryFilter{
+		Owner: repository.OwnerName,
+		Repo:  repository.Name,
+		Since: since,
+		Until: until,
+	})
+	if err != nil {
+		log.Error().Err(err).Msgf("Could not get snapshots for repo %s/%s from history", repository.OwnerName, repository.Name)
+		return nil, false
+	}
+	return snapshots, true
+}
+
+func filterServiceSnapshots(snapshots []history.HistorySnapshot, service string) []history.HistorySnapshot {
+	var serviceSnapshots []history.HistorySnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.Service == service {
+			serviceSnapshots = append(serviceSnapshots, snapshot)
+		}
+	}
+	return serviceSnapshots
+}
+
+func (h *RepoHandler) getRepoLookupData(request *http.Request, dashboardRepos []dashboard.DashboardRepo) RepoLookupData {
+	repository := dashboardRepos[0].Repository
+	data := RepoLookupData{
+		Owner: repository.OwnerName,
+		Ref:   strings.TrimSpace(request.URL.Query().Get("ref")),
+		Repo:  repository.Name,
+	}
+	if data.Ref == "" {
+		return data
+	}
+
+	data.Results = lookupDashboardCommit(request.Context(), h.DashboardService, dashboardRepos, data.Ref)
+	return data
+}
+
+// lookupDashboardCommit locates a commit in each service of a repo, services
+// without a config are skipped.
+func lookupDashboardCommit(ctx context.Context, dashboardService dashboard.DashboardProvider, dashboardRepos []dashboard.DashboardRepo, ref string) []RepoLookupResult {
+	var results []RepoLookupResult
+	for _, dashboardRepo := range dashboardRepos {
+		if !dashboardRepo.HasConfig() {
+			continue
+		}
+		result := RepoLookupResult{DashboardRepo: dashboardRepo}
+		location, err := dashboardService.GetDashboardCommitLocation(ctx, dashboardRepo, ref)
+		if err != nil {
+			log.Error().Err(err).Msgf("Could not look up %s in repo %s/%s", ref, dashboardRepo.Repository.OwnerName, dashboardRepo.Repository.Name)
+			result.Error = err.Error()
+		} else {
+			result.Location = location
+		}
+		results = append(results, result)
+	}
+	return results
+}
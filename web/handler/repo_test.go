@@ -0,0 +1,326 @@
+package handler_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/lobsterdore/release-dash/web/handler"
+
+	mock_cache "github.com/lobsterdore/release-dash/mocks/cache"
+	mock_dashboard "github.com/lobsterdore/release-dash/mocks/dashboard"
+	mock_history "github.com/lobsterdore/release-dash/mocks/history"
+)
+
+func serveRepo(repoHandler handler.RepoHandler, url string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", url, nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(repoHandler.Http).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestRepoHasEnvironments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockRepo := newMockReleaseNotesRepo()
+	mockBadRepo := dashboard.DashboardRepo{
+		Config:      &dashboard.DashboardRepoConfig{Name: "worker"},
+		ConfigError: "yaml: line 2: did not find expected key",
+		ConfigPath:  "worker/.releasedash.yml",
+		Repository:  mockRepo.Repository,
+	}
+	mockOtherRepo := dashboard.DashboardRepo{
+		Config:     &dashboard.DashboardRepoConfig{Name: "other"},
+		Repository: scm.ScmRepository{Name: "other", OwnerName: "o"},
+	}
+	mockRepoChangelogs := []dashboard.DashboardRepoChangelog{{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{newMockReleaseNotesChangelogCommits()},
+		Config:           mockRepo.Config,
+		Environments: []dashboard.DashboardEnvironment{
+			{
+				Commit: &scm.ScmCommit{
+					AuthorLogin: "l",
+					AuthoredAt:  time.Now().Add(-3 * time.Hour),
+					HtmlUrl:     "https://github.com/o/r/commit/3e0f3d8",
+					Message:     "feat: add refunds\n\nbody",
+					Sha:         "3e0f3d8c432ca2a03a3222fb55de63934338022f",
+				},
+				Name: "dev",
+			},
+			{Name: "stg"},
+		},
+		Repository: mockRepo.Repository,
+	}}
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return([]dashboard.DashboardRepo{mockOtherRepo, mockRepo, mockBadRepo}, true)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_refreshed").
+		Times(1).
+		Return(time.Now().Add(-2*time.Minute), true)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		Times(1).
+		Return(mockRepoChangelogs, true)
+
+	repoHandler := handler.RepoHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	rr := serveRepo(repoHandler, "/repos/O/R")
+	resBody := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, resBody, "o/r")
+	assert.Contains(t, resBody, "Refreshed 2 minutes ago")
+	assert.Contains(t, resBody, "3e0f3d8</a>")
+	assert.Contains(t, resBody, "<td>feat: add refunds</td>")
+	assert.Contains(t, resBody, "3 hours ago")
+	assert.Contains(t, resBody, "Ref not found")
+	assert.Contains(t, resBody, "stg > prod")
+	assert.Contains(t, resBody, "2 pending changes")
+	assert.Contains(t, resBody, "fix: rounding")
+	assert.Contains(t, resBody, "Config file error")
+	assert.Contains(t, resBody, "did not find expected key")
+	assert.NotContains(t, resBody, "other")
+}
+
+func TestRepoConfigErrorWithCentralConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+
+	// Central settings applied without the invalid repo file
+	mockRepo := newMockReleaseNotesRepo()
+	mockRepo.ConfigError = "Unknown repo config display mode bad"
+	mockRepoChangelogs := []dashboard.DashboardRepoChangelog{{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{newMockReleaseNotesChangelogCommits()},
+		Config:           mockRepo.Config,
+		Repository:       mockRepo.Repository,
+	}}
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return([]dashboard.DashboardRepo{mockRepo}, true)
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_refreshed").
+		Times(1).
+		Return(nil, false)
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		Times(1).
+		Return(mockRepoChangelogs, true)
+
+	repoHandler := handler.RepoHandler{CacheService: mockCacheService}
+
+	rr := serveRepo(repoHandler, "/repos/o/r")
+	resBody := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, resBody, "Config file error")
+	assert.Contains(t, resBody, "Unknown repo config display mode bad")
+	assert.Contains(t, resBody, "stg > prod")
+}
+
+func TestRepoHasTrend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+	mockHistoryService := mock_history.NewMockHistoryProvider(ctrl)
+
+	mockRepo := newMockReleaseNotesRepo()
+	takenAt := time.Now().Add(-48 * time.Hour)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return([]dashboard.DashboardRepo{mockRepo}, true)
+	mockCacheService.
+		EXPECT().
+		Get(gomock.Any()).
+		Times(2).
+		Return(nil, false)
+	mockHistoryService.
+		EXPECT().
+		GetSnapshots(gomock.Any()).
+		Times(1).
+		DoAndReturn(func(filter history.HistoryFilter) ([]history.HistorySnapshot, error) {
+			assert.Equal(t, "o", filter.Owner)
+			assert.Equal(t, "r", filter.Repo)
+			return []history.HistorySnapshot{
+				{Owner: "o", Pairs: []history.HistorySnapshotPair{{Commits: 3, FromRef: "prod", ToRef: "stg"}}, Repo: "r", Service: "app", TakenAt: takenAt},
+				{Owner: "o", Pairs: []history.HistorySnapshotPair{{Commits: 9, FromRef: "live", ToRef: "main"}}, Repo: "r", Service: "worker", TakenAt: takenAt},
+			}, nil
+		})
+
+	repoHandler := handler.RepoHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+		HistoryService:   mockHistoryService,
+	}
+
+	rr := serveRepo(repoHandler, "/repos/o/r")
+	resBody := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, resBody, "Pending changes, last 90 days")
+	assert.Contains(t, resBody, `href="/trends?owner=o&amp;repo=r"`)
+	assert.Contains(t, resBody, "<polyline")
+	assert.Contains(t, resBody, "stg &gt; prod <span class=\"grey-text\">3 pending</span>")
+	assert.NotContains(t, resBody, "main &gt; live")
+}
+
+func TestRepoNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return([]dashboard.DashboardRepo{newMockReleaseNotesRepo()}, true)
+
+	repoHandler := handler.RepoHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	rr := serveRepo(repoHandler, "/repos/o/missing")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = serveRepo(repoHandler, "/repos/o")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestRepoNoData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return(nil, false)
+
+	repoHandler := handler.RepoHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	rr := serveRepo(repoHandler, "/repos/o/r")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Initialising...")
+}
+
+func TestRepoLookup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockRepo := newMockReleaseNotesRepo()
+	mockWorkerRepo := newMockReleaseNotesRepo()
+	mockWorkerRepo.Config = &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"dev"}, Name: "worker"}
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return([]dashboard.DashboardRepo{mockRepo, mockWorkerRepo}, true)
+
+	mockDashboardService.
+		EXPECT().
+		GetDashboardCommitLocation(gomock.Any(), mockRepo, "#12").
+		Times(1).
+		Return(&dashboard.DashboardCommitLocation{
+			Commit: &scm.ScmCommit{
+				HtmlUrl: "https://github.com/o/r/commit/3e0f3d8",
+				Message: "feat: add refunds\n\nbody",
+				Sha:     "3e0f3d8c432ca2a03a3222fb55de63934338022f",
+			},
+			Environments: []dashboard.DashboardCommitEnvironment{
+				{Name: "dev", Status: dashboard.CommitLocationContained},
+				{Name: "stg", Status: dashboard.CommitLocationMissing},
+				{Name: "prod", Status: dashboard.CommitLocationUnknown},
+			},
+			PullRequest: &scm.ScmPullRequest{HtmlUrl: "https://github.com/o/r/pull/12", Number: 12, Title: "Refunds"},
+		}, nil)
+
+	mockDashboardService.
+		EXPECT().
+		GetDashboardCommitLocation(gomock.Any(), mockWorkerRepo, "#12").
+		Times(1).
+		Return(nil, errors.New("Pull request #12 has not been merged"))
+
+	repoHandler := handler.RepoHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	rr := serveRepo(repoHandler, "/repos/o/r/lookup?ref=%2312")
+	resBody := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, resBody, `value="#12"`)
+	assert.Contains(t, resBody, "#12</a> Refunds")
+	assert.Contains(t, resBody, "3e0f3d8</a> feat: add refunds")
+	assert.Contains(t, resBody, "deployed")
+	assert.Contains(t, resBody, "not yet")
+	assert.Contains(t, resBody, "unknown")
+	assert.Contains(t, resBody, "Pull request #12 has not been merged")
+}
+
+func TestRepoLookupNoRef(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockDashboardService := mock_dashboard.NewMockDashboardProvider(ctrl)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return([]dashboard.DashboardRepo{newMockReleaseNotesRepo()}, true)
+
+	repoHandler := handler.RepoHandler{
+		CacheService:     mockCacheService,
+		DashboardService: mockDashboardService,
+	}
+
+	rr := serveRepo(repoHandler, "/repos/o/r/lookup")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `action="/repos/o/r/lookup"`)
+
+	rr = serveRepo(repoHandler, "/repos/o/r/other")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/lobsterdore/release-dash/asset"
+	"github.com/lobsterdore/release-dash/cache"
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/web/templatefns"
+)
+
+type SearchData struct {
+	Query   string
+	Results []dashboard.DashboardSearchResult
+}
+
+type SearchHandler struct {
+	CacheService cache.CacheAdapter
+}
+
+func NewSearchHandler(cacheService cache.CacheAdapter) *SearchHandler {
+	searchHandler := SearchHandler{
+		CacheService: cacheService,
+	}
+
+	return &searchHandler
+}
+
+// Http serves /search?q=, the search box on every page submits here.
+func (h *SearchHandler) Http(respWriter http.ResponseWriter, request *http.Request) {
+	var tmpl *template.Template
+	var data SearchData
+	var err error
+
+	cachedData, found := h.CacheService.Get("homepage_changelog_data")
+	if found {
+		tmpl, err = template.New("search").Funcs(templatefns.TemplateFnsMap).Parse(asset.ReadTemplateFile("html/base.html"))
+		if err != nil {
+			log.Error().Err(err).Msg("Could not get html/base.html")
+			return
+		}
+
+		tmpl, err = tmpl.Parse(asset.ReadTemplateFile("html/search.html"))
+		if err != nil {
+			log.Error().Err(err).Msg("Could not get html/search.html")
+			return
+		}
+
+		query := strings.TrimSpace(request.URL.Query().Get("q"))
+		data = SearchData{
+			Query:   query,
+			Results: dashboard.SearchDashboardRepoChangelogs(cachedData.([]dashboard.DashboardRepoChangelog), query),
+		}
+	} else {
+		tmpl, err = template.New("homepage_loading").Funcs(templatefns.TemplateFnsMap).Parse(asset.ReadTemplateFile("html/base.html"))
+		if err != nil {
+			log.Error().Err(err).Msg("Could not get html/base.html")
+			return
+		}
+
+		tmpl, err = tmpl.Parse(asset.ReadTemplateFile("html/homepage_loading.html"))
+		if err != nil {
+			log.Error().Err(err).Msg("Could not get html/homepage_loading.html")
+			return
+		}
+	}
+
+	err = tmpl.Execute(respWriter, data)
+	if err != nil {
+		respWriter.WriteHeader(http.StatusInternalServerError)
+		_, _ = respWriter.Write([]byte(err.Error()))
+		return
+	}
+}
@@ -0,0 +1,81 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/web/handler"
+
+	mock_cache "github.com/lobsterdore/release-dash/mocks/cache"
+)
+
+func newMockSearchChangelogs() []dashboard.DashboardRepoChangelog {
+	mockRepo := newMockReleaseNotesRepo()
+	mockChangelogCommits := newMockReleaseNotesChangelogCommits()
+	mockChangelogCommits.Tickets[0].CommitShas = []string{mockChangelogCommits.Commits[0].Sha}
+	mockStgChangelogCommits := newMockReleaseNotesChangelogCommits()
+	mockStgChangelogCommits.FromRef = "stg"
+	mockStgChangelogCommits.Tickets[0].CommitShas = []string{mockChangelogCommits.Commits[0].Sha}
+	mockStgChangelogCommits.ToRef = "dev"
+
+	return []dashboard.DashboardRepoChangelog{{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{mockStgChangelogCommits, mockChangelogCommits},
+		Config:           mockRepo.Config,
+		Repository:       mockRepo.Repository,
+	}}
+}
+
+func TestSearchHasResults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		Times(1).
+		Return(newMockSearchChangelogs(), true)
+
+	searchHandler := handler.SearchHandler{CacheService: mockCacheService}
+
+	req, _ := http.NewRequest("GET", "/search?q=refunds", nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(searchHandler.Http).ServeHTTP(rr, req)
+	resBody := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, resBody, `value="refunds"`)
+	assert.Contains(t, resBody, `1 pending commit matching "refunds"`)
+	assert.Contains(t, resBody, `href="/repos/o/r"`)
+	assert.Contains(t, resBody, "https://jira/browse/PAY-1")
+	assert.Contains(t, resBody, "3e0f3d8</a>")
+	assert.Contains(t, resBody, "dev > stg")
+	assert.Contains(t, resBody, "stg > prod")
+	assert.NotContains(t, resBody, "rounding")
+}
+
+func TestSearchNoData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		Times(1).
+		Return(nil, false)
+
+	searchHandler := handler.SearchHandler{CacheService: mockCacheService}
+
+	req, _ := http.NewRequest("GET", "/search?q=refunds", nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(searchHandler.Http).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Initialising...")
+}
@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/lobsterdore/release-dash/asset"
+	"github.com/lobsterdore/release-dash/cache"
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/web/templatefns"
+)
+
+const (
+	trendDaysDefault = 90
+	trendDaysMax     = 365
+
+	trendChartHeight = 220
+	trendChartWidth  = 800
+	trendPlotBottom  = trendChartHeight - 24
+	trendPlotLeft    = 40
+	trendPlotRight   = trendChartWidth - 10
+	trendPlotTop     = 10
+	trendYTicks      = 4
+)
+
+// trendColours are picked for each line in turn, they repeat for charts with
+// more pairs of environments than colours.
+var trendColours = []string{"#2196f3", "#f44336", "#4caf50", "#ff9800", "#9c27b0", "#009688", "#795548", "#607d8b"}
+
+// TrendChart is an SVG line chart of pending commits over time, one line for
+// each pair of environments.
+type TrendChart struct {
+	Height     int
+	Lines      []TrendLine
+	PlotBottom int
+	PlotLeft   int
+	PlotRight  int
+	PlotTop    int
+	Width      int
+	XTicks     []TrendTick
+	YTicks     []TrendTick
+}
+
+type TrendLine struct {
+	Colour string
+	Label  string
+	// Latest is the number of pending commits in the last snapshot, which is
+	// marked at LatestX and LatestY
+	Latest  int
+	LatestX float64
+	LatestY float64
+	Points  string
+}
+
+type TrendTick struct {
+	Label string
+	X     float64
+	Y     float64
+}
+
+type TrendsData struct {
+	Chart TrendChart
+	Days  int
+	// Error is set when the history could not be read
+	Error   string
+	Owner   string
+	Repo    string
+	Teams   []TrendsTeam
+	Windows []DaysWindow
+}
+
+type TrendsTeam struct {
+	Name     string
+	Selected bool
+	Url      string
+}
+
+type TrendsHandler struct {
+	CacheService   cache.CacheAdapter
+	HistoryService history.HistoryProvider
+}
+
+func NewTrendsHandler(historyService history.HistoryProvider, cacheService cache.CacheAdapter) *TrendsHandler {
+	trendsHandler := TrendsHandler{
+		CacheService:   cacheService,
+		HistoryService: historyService,
+	}
+
+	return &trendsHandler
+}
+
+// Http serves /trends, pending commits for each pair of environments summed
+// across every repo over the last ?days=90, narrowed down by ?team= or
+// ?owner=&repo=.
+func (h *TrendsHandler) Http(respWriter http.ResponseWriter, request *http.Request) {
+	tmpl, err := template.New("trends").Funcs(templatefns.TemplateFnsMap).Parse(asset.ReadTemplateFile("html/base.html"))
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get html/base.html")
+		return
+	}
+
+	for _, templateFile := range []string{"html/trends.html", "html/trend_chart.html"} {
+		tmpl, err = tmpl.Parse(asset.ReadTemplateFile(templateFile))
+		if err != nil {
+			log.Error().Err(err).Msgf("Could not get %s", templateFile)
+			return
+		}
+	}
+
+	query := request.URL.Query()
+	days := queryInt(query, "days", trendDaysDefault, 1, trendDaysMax)
+	team := strings.TrimSpace(query.Get("team"))
+	data := TrendsData{
+		Days:    days,
+		Owner:   strings.TrimSpace(query.Get("owner")),
+		Repo:    strings.TrimSpace(query.Get("repo")),
+		Windows: newDaysWindows(request.URL, days),
+	}
+
+	var dashboardRepos []dashboard.DashboardRepo
+	if cachedData, found := h.CacheService.Get("homepage_repo_data"); found {
+		dashboardRepos = cachedData.([]dashboard.DashboardRepo)
+	}
+	data.Teams = newTrendsTeams(request.URL, dashboardRepos, team)
+
+	until := time.Now()
+	since := until.AddDate(0, 0, -days)
+	snapshots, err := h.HistoryService.GetSnapshots(history.HistoryFilter{
+		Owner: data.Owner,
+		Repo:  data.Repo,
+		Since: since,
+		Until: until,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get snapshots from history")
+		respWriter.WriteHeader(http.StatusInternalServerError)
+		data.Error = "Could not read the history"
+	} else {
+		if team != "" {
+			snapshots = filterTeamSnapshots(snapshots, dashboardRepos, team)
+		}
+		data.Chart = newTrendChart(snapshots, since, until)
+	}
+
+	err = tmpl.Execute(respWriter, data)
+	if err != nil {
+		respWriter.WriteHeader(http.StatusInternalServerError)
+		_, _ = respWriter.Write([]byte(err.Error()))
+		return
+	}
+}
+
+// newTrendsTeams lists every team on the dashboard, the selected team is
+// cleared by following its url.
+func newTrendsTeams(requestUrl *url.URL, dashboardRepos []dashboard.DashboardRepo, selectedTeam string) []TrendsTeam {
+	var teams []TrendsTeam
+	for _, team := range dashboard.GetDashboardBoardFacets(newTrendsRepoChangelogs(dashboardRepos)).Teams {
+		query := requestUrl.Query()
+		selected := team == selectedTeam
+		if selected {
+			query.Del("team")
+		} else {
+			query.Set("team", team)
+		}
+		teams = append(teams, TrendsTeam{
+			Name:     team,
+			Selected: selected,
+			Url:      requestUrl.Path + "?" + query.Encode(),
+		})
+	}
+	return teams
+}
+
+// newTrendsRepoChangelogs wraps repos as changelogs so that the board facets
+// can be reused.
+func newTrendsRepoChangelogs(dashboardRepos []dashboard.DashboardRepo) []dashboard.DashboardRepoChangelog {
+	var repoChangelogs []dashboard.DashboardRepoChangelog
+	for _, dashboardRepo := range dashboardRepos {
+		if dashboardRepo.Config == nil {
+			continue
+		}
+		repoChangelogs = append(repoChangelogs, dashboard.DashboardRepoChangelog{
+			Config:     dashboardRepo.Config,
+			Repository: dashboardRepo.Repository,
+		})
+	}
+	return repoChangelogs
+}
+
+func filterTeamSnapshots(snapshots []history.HistorySnapshot, dashboardRepos []dashboard.DashboardRepo, team string) []history.HistorySnapshot {
+	teamRepos := map[string]bool{}
+	for _, dashboardRepo := range dashboardRepos {
+		if dashboardRepo.Config != nil && dashboardRepo.Config.Team == team {
+			teamRepos[newTrendsRepoKey(dashboardRepo.Repository.OwnerName, dashboardRepo.Repository.Name, dashboardRepo.Config.Name)] = true
+		}
+	}
+
+	var teamSnapshots []history.HistorySnapshot
+	for _, snapshot := range snapshots {
+		if teamRepos[newTrendsRepoKey(snapshot.Owner, snapshot.Repo, snapshot.Service)] {
+			teamSnapshots = append(teamSnapshots, snapshot)
+		}
+	}
+	return teamSnapshots
+}
+
+func newTrendsRepoKey(owner string, repo string, service string) string {
+	return strings.ToLower(owner+"/"+repo) + "/" + service
+}
+
+// newTrendChart sums the pending commits of each pair of environments across
+// the snapshots, pairs are labelled as they are on the dashboard and keep the
+// order they are first seen in.
+func newTrendChart(snapshots []history.HistorySnapshot, since time.Time, until time.Time) TrendChart {
+	chart := TrendChart{
+		Height:     trendChartHeight,
+		PlotBottom: trendPlotBottom,
+		PlotLeft:   trendPlotLeft,
+		PlotRight:  trendPlotRight,
+		PlotTop:    trendPlotTop,
+		Width:      trendChartWidth,
+	}
+
+	var labels []string
+	var times []time.Time
+	seenTimes := map[time.Time]bool{}
+	totals := map[string]map[time.Time]int{}
+	for _, snapshot := range snapshots {
+		// Map keys compare locations as well as instants
+		takenAt := snapshot.TakenAt.UTC()
+		if !seenTimes[takenAt] {
+			seenTimes[takenAt] = true
+			times = append(times, takenAt)
+		}
+		for _, pair := range snapshot.Pairs {
+			label := pair.ToRef + " > " + pair.FromRef
+			if _, found := totals[label]; !found {
+				labels = append(labels, label)
+				totals[label] = map[time.Time]int{}
+			}
+			totals[label][takenAt] += pair.Commits
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	maxCommits := 0
+	for _, labelTotals := range totals {
+		for _, commits := range labelTotals {
+			if commits > maxCommits {
+				maxCommits = commits
+			}
+		}
+	}
+	yStep := (maxCommits + trendYTicks - 1) / trendYTicks
+	if yStep == 0 {
+		yStep = 1
+	}
+	yMax := yStep * trendYTicks
+
+	// Positions are rounded to keep the SVG small
+	xPosition := func(at time.Time) float64 {
+		return math.Round((trendPlotLeft+float64(at.Sub(since))/float64(until.Sub(since))*(trendPlotRight-trendPlotLeft))*10) / 10
+	}
+	yPosition := func(commits int) float64 {
+		return math.Round((trendPlotBottom-float64(commits)/float64(yMax)*(trendPlotBottom-trendPlotTop))*10) / 10
+	}
+
+	for index, label := range labels {
+		line := TrendLine{
+			Colour: trendColours[index%len(trendColours)],
+			Label:  label,
+		}
+		var points []string
+		for _, takenAt := range times {
+			commits, found := totals[label][takenAt]
+			if !found {
+				continue
+			}
+			line.Latest = commits
+			line.LatestX = xPosition(takenAt)
+			line.LatestY = yPosition(commits)
+			points = append(points, fmt.Sprintf("%g,%g", line.LatestX, line.LatestY))
+		}
+		line.Points = strings.Join(points, " ")
+		chart.Lines = append(chart.Lines, line)
+	}
+
+	for tick := 0; tick <= trendYTicks; tick++ {
+		chart.YTicks = append(chart.YTicks, TrendTick{
+			Label: strconv.Itoa(tick * yStep),
+			X:     trendPlotLeft,
+			Y:     yPosition(tick * yStep),
+		})
+	}
+
+	days := int(until.Sub(since).Hours() / 24)
+	stepDays := (days + 6) / 7
+	if stepDays == 0 {
+		stepDays = 1
+	}
+	for tickAt := since.Truncate(24*time.Hour).AddDate(0, 0, 1); tickAt.Before(until); tickAt = tickAt.AddDate(0, 0, stepDays) {
+		chart.XTicks = append(chart.XTicks, TrendTick{
+			Label: tickAt.Format("Jan 2"),
+			X:     xPosition(tickAt),
+			Y:     trendPlotBottom,
+		})
+	}
+
+	return chart
+}
@@ -0,0 +1,119 @@
+package handler_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/lobsterdore/release-dash/web/handler"
+
+	mock_cache "github.com/lobsterdore/release-dash/mocks/cache"
+	mock_history "github.com/lobsterdore/release-dash/mocks/history"
+)
+
+func newMockTrendsRepos() []dashboard.DashboardRepo {
+	return []dashboard.DashboardRepo{
+		{
+			Config:     &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"stg", "prod"}, Name: "api", Team: "payments"},
+			Repository: scm.ScmRepository{Name: "api", OwnerName: "o"},
+		},
+		{
+			Config:     &dashboard.DashboardRepoConfig{EnvironmentTags: []string{"stg", "prod"}, Name: "web", Team: "frontend"},
+			Repository: scm.ScmRepository{Name: "web", OwnerName: "o"},
+		},
+	}
+}
+
+func newMockTrendsSnapshots() []history.HistorySnapshot {
+	takenAt := time.Now().Add(-48 * time.Hour).Truncate(time.Hour)
+	pairs := func(commits int) []history.HistorySnapshotPair {
+		return []history.HistorySnapshotPair{{Commits: commits, FromRef: "prod", ToRef: "stg"}}
+	}
+	return []history.HistorySnapshot{
+		{Owner: "o", Pairs: pairs(2), Repo: "api", Service: "api", TakenAt: takenAt},
+		{Owner: "o", Pairs: pairs(7), Repo: "web", Service: "web", TakenAt: takenAt},
+		{Owner: "o", Pairs: pairs(4), Repo: "api", Service: "api", TakenAt: takenAt.Add(time.Hour)},
+		{Owner: "o", Pairs: pairs(5), Repo: "web", Service: "web", TakenAt: takenAt.Add(time.Hour)},
+	}
+}
+
+func serveTrends(t *testing.T, urlPath string, snapshots []history.HistorySnapshot, err error) *httptest.ResponseRecorder {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+	mockHistoryService := mock_history.NewMockHistoryProvider(ctrl)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_repo_data").
+		Times(1).
+		Return(newMockTrendsRepos(), true)
+	mockHistoryService.
+		EXPECT().
+		GetSnapshots(gomock.Any()).
+		Times(1).
+		DoAndReturn(func(filter history.HistoryFilter) ([]history.HistorySnapshot, error) {
+			assert.WithinDuration(t, time.Now(), filter.Until, time.Minute)
+			return snapshots, err
+		})
+
+	trendsHandler := handler.NewTrendsHandler(mockHistoryService, mockCacheService)
+
+	req, reqErr := http.NewRequest("GET", urlPath, nil)
+	if reqErr != nil {
+		t.Fatal(reqErr)
+	}
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(trendsHandler.Http).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestTrendsHandler(t *testing.T) {
+	rr := serveTrends(t, "/trends", newMockTrendsSnapshots(), nil)
+	resBody := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, resBody, "Last 90 days")
+	assert.Contains(t, resBody, `class="chip blue lighten-1 white-text" href="/trends?days=90"`)
+	assert.Contains(t, resBody, `href="/trends?team=frontend"`)
+	assert.Contains(t, resBody, `href="/trends?team=payments"`)
+	assert.Contains(t, resBody, `<svg class="trend-chart"`)
+	// The busiest snapshot has 9 pending commits so the axis goes up in 3s
+	assert.Contains(t, resBody, `text-anchor="end">12</text>`)
+	assert.Contains(t, resBody, "stg &gt; prod <span class=\"grey-text\">9 pending</span>")
+}
+
+func TestTrendsHandlerTeam(t *testing.T) {
+	rr := serveTrends(t, "/trends?days=30&team=payments", newMockTrendsSnapshots(), nil)
+	resBody := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, resBody, "Last 30 days")
+	assert.Contains(t, resBody, `class="chip blue lighten-1 white-text" href="/trends?days=30">payments</a>`)
+	assert.Contains(t, resBody, "stg &gt; prod <span class=\"grey-text\">4 pending</span>")
+}
+
+func TestTrendsHandlerRepo(t *testing.T) {
+	rr := serveTrends(t, "/trends?owner=o&repo=api", nil, nil)
+	resBody := rr.Body.String()
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, resBody, "Pending changes in o/api")
+	assert.Contains(t, resBody, "No snapshots of pending changes in this period yet")
+}
+
+func TestTrendsHandlerError(t *testing.T) {
+	rr := serveTrends(t, "/trends", nil, errors.New("error"))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Could not read the history")
+}
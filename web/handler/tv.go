@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/lobsterdore/release-dash/asset"
+	"github.com/lobsterdore/release-dash/cache"
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/lobsterdore/release-dash/web/templatefns"
+)
+
+const (
+	tvPerPageDefault       = 6
+	tvPerPageMax           = 24
+	tvRotateSecondsDefault = 30
+	tvRotateSecondsMin     = 5
+)
+
+type TvData struct {
+	// NextUrl is the page shown after RotateSeconds, the last page wraps
+	// around to the first
+	NextUrl string
+	// Loading is set until the first changelogs have been fetched
+	Loading        bool
+	OldestCommit   *scm.ScmCommit
+	OldestRepo     *dashboard.DashboardRepoChangelog
+	Page           int
+	Pages          int
+	RepoChangelogs []dashboard.DashboardRepoChangelog
+	RotateSeconds  int
+	TotalRepos     int
+}
+
+type TvHandler struct {
+	CacheService cache.CacheAdapter
+}
+
+func NewTvHandler(cacheService cache.CacheAdapter) *TvHandler {
+	tvHandler := TvHandler{
+		CacheService: cacheService,
+	}
+
+	return &tvHandler
+}
+
+// Http serves /tv, a wallboard of repos with pending changes that rotates
+// through pages. The page size and rotation speed are set via the per_page
+// and rotate query params, the board filters from the homepage also apply.
+func (h *TvHandler) Http(respWriter http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	data := TvData{
+		Page:          1,
+		Pages:         1,
+		RotateSeconds: queryInt(query, "rotate", tvRotateSecondsDefault, tvRotateSecondsMin, 0),
+	}
+
+	cachedData, found := h.CacheService.Get("homepage_changelog_data")
+	data.Loading = !found
+	if found {
+		var pendingChangelogs []dashboard.DashboardRepoChangelog
+		for _, repoChangelog := range newDashboardBoardFilter(query).Apply(cachedData.([]dashboard.DashboardRepoChangelog)) {
+			if repoChangelog.HasChangelogCommits() {
+				pendingChangelogs = append(pendingChangelogs, repoChangelog)
+			}
+		}
+		data.TotalRepos = len(pendingChangelogs)
+
+		data.OldestCommit, data.OldestRepo = dashboard.GetDashboardBoardOldestCommit(pendingChangelogs)
+
+		perPage := queryInt(query, "per_page", tvPerPageDefault, 1, tvPerPageMax)
+		if data.TotalRepos > 0 {
+			data.Pages = (data.TotalRepos + perPage - 1) / perPage
+		}
+		data.Page = queryInt(query, "page", 1, 1, data.Pages)
+		pageStart := (data.Page - 1) * perPage
+		pageEnd := pageStart + perPage
+		if pageEnd > data.TotalRepos {
+			pageEnd = data.TotalRepos
+		}
+		data.RepoChangelogs = pendingChangelogs[pageStart:pageEnd]
+	}
+
+	nextQuery := url.Values{}
+	for key, values := range query {
+		nextQuery[key] = values
+	}
+	nextQuery.Set("page", strconv.Itoa(data.Page%data.Pages+1))
+	data.NextUrl = "/tv?" + nextQuery.Encode()
+
+	tmpl, err := template.New("tv").Funcs(templatefns.TemplateFnsMap).Parse(asset.ReadTemplateFile("html/tv.html"))
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get html/tv.html")
+		return
+	}
+
+	tmpl, err = tmpl.Parse(asset.ReadTemplateFile("html/ci_status.html"))
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get html/ci_status.html")
+		return
+	}
+
+	err = tmpl.Execute(respWriter, data)
+	if err != nil {
+		respWriter.WriteHeader(http.StatusInternalServerError)
+		_, _ = respWriter.Write([]byte(err.Error()))
+		return
+	}
+}
+
+// queryInt reads a whole number query param, values outside of the min and
+// max are clamped and a max of zero means there is no max.
+func queryInt(query url.Values, key string, defaultValue int, min int, max int) int {
+	value, err := strconv.Atoi(query.Get(key))
+	if err != nil {
+		return defaultValue
+	}
+	if value < min {
+		return min
+	}
+	if max > 0 && value > max {
+		return max
+	}
+	return value
+}
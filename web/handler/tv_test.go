@@ -0,0 +1,113 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/scm"
+	"github.com/lobsterdore/release-dash/web/handler"
+
+	mock_cache "github.com/lobsterdore/release-dash/mocks/cache"
+)
+
+func newMockTvChangelogs() []dashboard.DashboardRepoChangelog {
+	var repoChangelogs []dashboard.DashboardRepoChangelog
+	for _, name := range []string{"api", "billing", "web"} {
+		mockRepo := newMockReleaseNotesRepo()
+		mockRepo.Config.Name = name
+		mockChangelogCommits := newMockReleaseNotesChangelogCommits()
+		mockChangelogCommits.Commits[0].AuthoredAt = time.Now().Add(-3 * time.Hour)
+		mockChangelogCommits.Commits[1].AuthoredAt = time.Now().Add(-1 * time.Hour)
+		repoChangelogs = append(repoChangelogs, dashboard.DashboardRepoChangelog{
+			ChangelogCommits: []dashboard.DashboardChangelogCommits{mockChangelogCommits},
+			Config:           mockRepo.Config,
+			Repository:       mockRepo.Repository,
+		})
+	}
+	repoChangelogs[1].ChangelogCommits[0].Commits[0].AuthoredAt = time.Now().Add(-50 * time.Hour)
+	repoChangelogs[1].ChangelogCommits[0].Staleness = dashboard.StalenessAmber
+	repoChangelogs[2].ChangelogCommits[0].Status = &scm.ScmCommitStatus{State: scm.CommitStatusPending}
+
+	releasedRepo := newMockReleaseNotesRepo()
+	releasedRepo.Config.Name = "released"
+	repoChangelogs = append(repoChangelogs, dashboard.DashboardRepoChangelog{
+		ChangelogCommits: []dashboard.DashboardChangelogCommits{{FromRef: "prod", ToRef: "stg", Commits: []scm.ScmCommit{}}},
+		Config:           releasedRepo.Config,
+		Repository:       releasedRepo.Repository,
+	})
+	return repoChangelogs
+}
+
+func serveTv(t *testing.T, url string, cachedData interface{}, found bool) string {
+	ctrl := gomock.NewController(t)
+
+	mockCacheService := mock_cache.NewMockCacheAdapter(ctrl)
+
+	mockCacheService.
+		EXPECT().
+		Get("homepage_changelog_data").
+		Times(1).
+		Return(cachedData, found)
+
+	tvHandler := handler.NewTvHandler(mockCacheService)
+
+	req, _ := http.NewRequest("GET", url, nil)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(tvHandler.Http).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	return rr.Body.String()
+}
+
+func TestTvPendingRepos(t *testing.T) {
+	resBody := serveTv(t, "/tv", newMockTvChangelogs(), true)
+
+	assert.Contains(t, resBody, `content="30;url=/tv?page=1"`)
+	assert.Contains(t, resBody, "3 repos with pending changes")
+	assert.Contains(t, resBody, "2 days ago</span> in billing")
+	assert.Contains(t, resBody, `<div class="tv-card tv-staleness-amber">`)
+	assert.Contains(t, resBody, `<div class="tv-card tv-staleness-none">`)
+	assert.Contains(t, resBody, `<div class="tv-card-title">api</div>`)
+	assert.Contains(t, resBody, "feat: PAY-1 add refunds")
+	assert.Contains(t, resBody, "ci pending")
+	assert.NotContains(t, resBody, "released</div>")
+	assert.NotContains(t, resBody, "page 1 of")
+}
+
+func TestTvRotatesPages(t *testing.T) {
+	resBody := serveTv(t, "/tv?per_page=2&rotate=10&page=2", newMockTvChangelogs(), true)
+
+	assert.Contains(t, resBody, `content="10;url=/tv?page=1&amp;per_page=2&amp;rotate=10"`)
+	assert.Contains(t, resBody, "page 2 of 2")
+	assert.Contains(t, resBody, `<div class="tv-card-title">web</div>`)
+	assert.NotContains(t, resBody, `<div class="tv-card-title">api</div>`)
+
+	resBody = serveTv(t, "/tv?per_page=2&rotate=1&page=1", newMockTvChangelogs(), true)
+
+	assert.Contains(t, resBody, `content="5;url=/tv?page=2&amp;per_page=2&amp;rotate=1"`)
+	assert.Contains(t, resBody, "page 1 of 2")
+}
+
+func TestTvFilters(t *testing.T) {
+	mockRepoChangelogs := newMockTvChangelogs()
+	mockRepoChangelogs[2].Config.Team = "Payments"
+
+	resBody := serveTv(t, "/tv?team=payments", mockRepoChangelogs, true)
+
+	assert.Contains(t, resBody, "1 repo with pending changes")
+	assert.Contains(t, resBody, `<div class="tv-card-title">web</div>`)
+	assert.NotContains(t, resBody, `<div class="tv-card-title">billing</div>`)
+}
+
+func TestTvNoData(t *testing.T) {
+	resBody := serveTv(t, "/tv", nil, false)
+
+	assert.Contains(t, resBody, "Changelogs are being fetched")
+	assert.NotContains(t, resBody, "Everything has been released")
+}
@@ -0,0 +1,61 @@
+// Keeps pages up to date via the /events stream. Pages opt in with a
+// data-live attribute, "reload" reloads the page when new changelogs are ready
+// and "board" swaps in any data-live-id elements that changed.
+(function () {
+  var live = document.querySelector('[data-live]');
+  if (!live || !window.EventSource || !window.fetch || !window.DOMParser) {
+    return;
+  }
+
+  function liveIds(element) {
+    var ids = [];
+    var items = element.querySelectorAll('[data-live-id]');
+    for (var i = 0; i < items.length; i++) {
+      ids.push(items[i].getAttribute('data-live-id'));
+    }
+    return ids.join('\n');
+  }
+
+  function updateBoard(board, newBoard) {
+    // Cards were added, removed or moved so the whole board is swapped
+    if (liveIds(board) !== liveIds(newBoard)) {
+      board.innerHTML = newBoard.innerHTML;
+      return;
+    }
+
+    var items = board.querySelectorAll('[data-live-id]');
+    var newItems = newBoard.querySelectorAll('[data-live-id]');
+    for (var i = 0; i < items.length; i++) {
+      if (items[i].innerHTML !== newItems[i].innerHTML) {
+        items[i].innerHTML = newItems[i].innerHTML;
+      }
+    }
+  }
+
+  function refresh() {
+    if (live.getAttribute('data-live') === 'reload') {
+      window.location.reload();
+      return;
+    }
+
+    fetch(window.location.href, { credentials: 'same-origin' })
+      .then(function (response) {
+        return response.text();
+      })
+      .then(function (html) {
+        var page = new DOMParser().parseFromString(html, 'text/html');
+        var newLive = page.querySelector('[data-live]');
+        if (!newLive) {
+          return;
+        }
+        if (newLive.getAttribute('data-live') !== live.getAttribute('data-live')) {
+          window.location.reload();
+          return;
+        }
+        updateBoard(live, newLive);
+      });
+  }
+
+  var events = new EventSource('/events');
+  events.addEventListener('refresh', refresh);
+})();
@@ -4,6 +4,15 @@ import (
 	"html/template"
 	"math"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lobsterdore/release-dash/dashboard"
 )
 
 var TemplateFnsMap = template.FuncMap{
@@ -37,4 +46,144 @@ var TemplateFnsMap = template.FuncMap{
 	"dividetoint": func(dividend int, divisor int) int {
 		return int(math.RoundToEven(float64(dividend) / float64(divisor)))
 	},
+	"duration": FormatDuration,
+	"firstline": func(text string) string {
+		return strings.TrimSpace(strings.SplitN(text, "\n", 2)[0])
+	},
+	"linktickets":         LinkTickets,
+	"linkticketsmarkdown": LinkTicketsMarkdown,
+	"shortsha": func(sha string) string {
+		if len(sha) > 7 {
+			return sha[:7]
+		}
+		return sha
+	},
+	"timeago": func(t time.Time) string {
+		return TimeAgo(t, time.Now())
+	},
+}
+
+// TimeAgo describes how long before now a time was in the largest whole unit,
+// an empty string is returned for the zero time.
+func TimeAgo(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	elapsed := now.Sub(t)
+	var count int
+	var unit string
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		count, unit = int(elapsed/time.Minute), "minute"
+	case elapsed < 24*time.Hour:
+		count, unit = int(elapsed/time.Hour), "hour"
+	case elapsed < 30*24*time.Hour:
+		count, unit = int(elapsed/(24*time.Hour)), "day"
+	case elapsed < 365*24*time.Hour:
+		count, unit = int(elapsed/(30*24*time.Hour)), "month"
+	default:
+		count, unit = int(elapsed/(365*24*time.Hour)), "year"
+	}
+
+	if count != 1 {
+		unit += "s"
+	}
+	return strconv.Itoa(count) + " " + unit + " ago"
+}
+
+// FormatDuration describes a duration in its two largest units, e.g. 2d 4h or
+// 3h 12m.
+func FormatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return "< 1m"
+	}
+
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	switch {
+	case days > 0 && hours > 0:
+		return strconv.Itoa(days) + "d " + strconv.Itoa(hours) + "h"
+	case days > 0:
+		return strconv.Itoa(days) + "d"
+	case hours > 0 && minutes > 0:
+		return strconv.Itoa(hours) + "h " + strconv.Itoa(minutes) + "m"
+	case hours > 0:
+		return strconv.Itoa(hours) + "h"
+	default:
+		return strconv.Itoa(minutes) + "m"
+	}
+}
+
+// MessagePart is a piece of a commit message, parts with a Url are ticket keys
+// that are rendered as links by the ticket_links template.
+type MessagePart struct {
+	Text string
+	Url  string
+}
+
+// LinkTickets splits a commit message around any ticket keys in it, keys that
+// are part of a longer word are left alone. The parts are escaped when they
+// are rendered so the message and ticket urls can't inject html.
+func LinkTickets(message string, tickets []dashboard.DashboardTicket) []MessagePart {
+	if len(tickets) == 0 {
+		return []MessagePart{{Text: message}}
+	}
+
+	ticketUrls := map[string]string{}
+	var keys []string
+	for _, ticket := range tickets {
+		ticketUrls[ticket.Key] = ticket.Url
+		keys = append(keys, regexp.QuoteMeta(ticket.Key))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return len(keys[i]) > len(keys[j])
+	})
+	keysRegexp := regexp.MustCompile(strings.Join(keys, "|"))
+
+	var parts []MessagePart
+	position := 0
+	for _, match := range keysRegexp.FindAllStringIndex(message, -1) {
+		if isWordRune(message[:match[0]], true) || isWordRune(message[match[1]:], false) {
+			continue
+		}
+		key := message[match[0]:match[1]]
+		if match[0] > position {
+			parts = append(parts, MessagePart{Text: message[position:match[0]]})
+		}
+		parts = append(parts, MessagePart{Text: key, Url: ticketUrls[key]})
+		position = match[1]
+	}
+	if position < len(message) || len(parts) == 0 {
+		parts = append(parts, MessagePart{Text: message[position:]})
+	}
+
+	return parts
+}
+
+// LinkTicketsMarkdown turns any ticket keys in a commit message into Markdown
+// links, the rest of the message is left as it is.
+func LinkTicketsMarkdown(message string, tickets []dashboard.DashboardTicket) string {
+	var linked strings.Builder
+	for _, part := range LinkTickets(message, tickets) {
+		if part.Url == "" {
+			linked.WriteString(part.Text)
+		} else {
+			linked.WriteString("[" + part.Text + "](" + part.Url + ")")
+		}
+	}
+	return linked.String()
+}
+
+func isWordRune(text string, last bool) bool {
+	var r rune
+	if last {
+		r, _ = utf8.DecodeLastRuneInString(text)
+	} else {
+		r, _ = utf8.DecodeRuneInString(text)
+	}
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
 }
@@ -3,7 +3,11 @@ package templatefns
 import (
 	"bytes"
 	"html/template"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/lobsterdore/release-dash/dashboard"
 )
 
 func AssertEqual(t *testing.T, buffer *bytes.Buffer, testString string) {
@@ -50,3 +54,119 @@ func TestGtfFuncMap(t *testing.T) {
 	AssertEqual(t, &buffer, "false")
 
 }
+
+func TestLinkTickets(t *testing.T) {
+	tickets := []dashboard.DashboardTicket{
+		{Key: "#45", Url: "https://github.com/o/r/issues/45"},
+		{Key: "#456", Url: "https://github.com/o/r/issues/456"},
+		{Key: "PAY-1", Url: "https://jira/browse/PAY-1?a=1&b=2"},
+	}
+
+	linked := LinkTickets("<b>PAY-1</b> fix #456, see #45 not PAY-12 or X#45", tickets)
+
+	expected := []MessagePart{
+		{Text: "<b>"},
+		{Text: "PAY-1", Url: "https://jira/browse/PAY-1?a=1&b=2"},
+		{Text: "</b> fix "},
+		{Text: "#456", Url: "https://github.com/o/r/issues/456"},
+		{Text: ", see "},
+		{Text: "#45", Url: "https://github.com/o/r/issues/45"},
+		{Text: " not PAY-12 or X#45"},
+	}
+
+	if !reflect.DeepEqual(linked, expected) {
+		t.Errorf("Expected %v, got %v", expected, linked)
+	}
+}
+
+func TestLinkTicketsNoTickets(t *testing.T) {
+	linked := LinkTickets("<b>PAY-1</b>", nil)
+
+	if !reflect.DeepEqual(linked, []MessagePart{{Text: "<b>PAY-1</b>"}}) {
+		t.Errorf("Expected the whole message, got %v", linked)
+	}
+}
+
+func TestTicketLinksTemplate(t *testing.T) {
+	var buffer bytes.Buffer
+
+	tickets := []dashboard.DashboardTicket{
+		{Key: "PAY-1", Url: "https://jira/browse/PAY-1?a=1&b=2"},
+		{Key: "BAD-1", Url: "javascript:alert(1)"},
+	}
+	body := `{{ define "ticket_links" }}{{ range . }}{{ if .Url }}<a href="{{ .Url }}" target="_blank">{{ .Text }}</a>{{ else }}{{ .Text }}{{ end }}{{ end }}{{ end }}` +
+		`{{ template "ticket_links" (linktickets "<b>PAY-1</b> BAD-1" .) }}`
+	tpl := template.Must(template.New("test").Funcs(TemplateFnsMap).Parse(body))
+	if err := tpl.Execute(&buffer, tickets); err != nil {
+		t.Fatal(err)
+	}
+
+	AssertEqual(t, &buffer, `&lt;b&gt;<a href="https://jira/browse/PAY-1?a=1&amp;b=2" target="_blank">PAY-1</a>&lt;/b&gt; `+
+		`<a href="#ZgotmplZ" target="_blank">BAD-1</a>`)
+}
+
+func TestLinkTicketsMarkdown(t *testing.T) {
+	tickets := []dashboard.DashboardTicket{
+		{Key: "PAY-1", Url: "https://jira/browse/PAY-1"},
+	}
+
+	linked := LinkTicketsMarkdown("PAY-1 add <refunds>, not PAY-12", tickets)
+
+	if linked != "[PAY-1](https://jira/browse/PAY-1) add <refunds>, not PAY-12" {
+		t.Errorf("Expected markdown links, got %s", linked)
+	}
+}
+
+func TestFirstLineShortSha(t *testing.T) {
+	var buffer bytes.Buffer
+
+	ParseTest(&buffer, "{{ firstline . }}", "subject \nbody")
+	AssertEqual(t, &buffer, "subject")
+
+	ParseTest(&buffer, "{{ shortsha . }}", "3e0f3d8c432ca2a03a3222fb55de63934338022f")
+	AssertEqual(t, &buffer, "3e0f3d8")
+
+	ParseTest(&buffer, "{{ shortsha . }}", "abc")
+	AssertEqual(t, &buffer, "abc")
+}
+
+func TestTimeAgo(t *testing.T) {
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := map[time.Duration]string{
+		30 * time.Second:         "just now",
+		time.Minute:              "1 minute ago",
+		45 * time.Minute:         "45 minutes ago",
+		3 * time.Hour:            "3 hours ago",
+		24 * time.Hour:           "1 day ago",
+		40 * 24 * time.Hour:      "1 month ago",
+		3 * 365 * 24 * time.Hour: "3 years ago",
+	}
+
+	for elapsed, expected := range tests {
+		if timeAgo := TimeAgo(now.Add(-elapsed), now); timeAgo != expected {
+			t.Errorf("Expected %s, got %s", expected, timeAgo)
+		}
+	}
+
+	if timeAgo := TimeAgo(time.Time{}, now); timeAgo != "" {
+		t.Errorf("Expected empty string for zero time, got %s", timeAgo)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Second:              "< 1m",
+		45 * time.Minute:              "45m",
+		3 * time.Hour:                 "3h",
+		3*time.Hour + 12*time.Minute:  "3h 12m",
+		48 * time.Hour:                "2d",
+		52*time.Hour + 30*time.Minute: "2d 4h",
+	}
+
+	for duration, expected := range tests {
+		if formatted := FormatDuration(duration); formatted != expected {
+			t.Errorf("Expected %s, got %s", expected, formatted)
+		}
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"os"
+	"path"
 	"time"
 
 	"github.com/lobsterdore/release-dash/cache"
 	"github.com/lobsterdore/release-dash/config"
 	"github.com/lobsterdore/release-dash/dashboard"
+	"github.com/lobsterdore/release-dash/history"
 	"github.com/lobsterdore/release-dash/logging"
+	"github.com/lobsterdore/release-dash/notify"
 	"github.com/lobsterdore/release-dash/scm"
 	"github.com/lobsterdore/release-dash/web/handler"
 
@@ -26,25 +29,70 @@ type WebProvider interface {
 }
 
 type web struct {
-	Config             config.Config
-	DashboardService   dashboard.DashboardProvider
-	HealthcheckHandler *handler.HealthcheckHandler
-	HomepageHandler    *handler.HomepageHandler
+	ApiHandler          *handler.ApiHandler
+	Config              config.Config
+	DashboardService    dashboard.DashboardProvider
+	DoraHandler         *handler.DoraHandler
+	EventsHandler       *handler.EventsHandler
+	FeedHandler         *handler.FeedHandler
+	HealthcheckHandler  *handler.HealthcheckHandler
+	HistoryHandler      *handler.HistoryHandler
+	HomepageHandler     *handler.HomepageHandler
+	ReleaseNotesHandler *handler.ReleaseNotesHandler
+	ReleasesHandler     *handler.ReleasesHandler
+	RepoHandler         *handler.RepoHandler
+	SearchHandler       *handler.SearchHandler
+	TrendsHandler       *handler.TrendsHandler
+	TvHandler           *handler.TvHandler
 }
 
-func NewWeb(cfg config.Config, ctx context.Context, scmService scm.ScmAdapter, cacheService cache.CacheAdapter) WebProvider {
-	dashboardService := dashboard.NewDashboardService(ctx, cfg, scmService)
+func NewWeb(cfg config.Config, ctx context.Context, scmService scm.ScmAdapter, releaseScmService scm.ScmAdapter, cacheService cache.CacheAdapter, historyService history.HistoryProvider, notifyService notify.NotifyProvider) (WebProvider, error) {
+	dashboardService := dashboard.NewDashboardService(ctx, cfg, scmService, releaseScmService, cacheService)
 
+	apiHandler := handler.NewApiHandler(dashboardService, cacheService)
+	eventsHandler := handler.NewEventsHandler(time.Duration(cfg.Server.Timeout.Write) * time.Second)
+	feedHandler := handler.NewFeedHandler(cacheService, historyService)
 	healthcheckHandler := handler.NewHealthcheckHandler()
-	homepageHandler := handler.NewHomepageHandler(dashboardService, cacheService)
+	homepageHandler := handler.NewHomepageHandler(dashboardService, cacheService, eventsHandler, feedHandler, historyService, notifyService, cfg.Github.ReleasesEnabled)
+	releaseNotesHandler := handler.NewReleaseNotesHandler(dashboardService, cacheService)
+	releasesHandler := handler.NewReleasesHandler(dashboardService, cacheService, cfg.Github.ReleasesEnabled, cfg.Github.ReleasesSecret)
+	repoHandler := handler.NewRepoHandler(dashboardService, cacheService, historyService)
+	searchHandler := handler.NewSearchHandler(cacheService)
+	tvHandler := handler.NewTvHandler(cacheService)
+
+	// The DORA, history and trends pages are only served when the history is
+	// enabled
+	var doraHandler *handler.DoraHandler
+	var historyHandler *handler.HistoryHandler
+	var trendsHandler *handler.TrendsHandler
+	if historyService != nil {
+		doraHandler = handler.NewDoraHandler(historyService, cacheService, cfg.Dora.ProductionEnvironments)
+		trendsHandler = handler.NewTrendsHandler(historyService, cacheService)
+		var err error
+		historyHandler, err = handler.NewHistoryHandler(historyService, cfg.History.Timezone)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	web := web{
-		Config:             cfg,
-		DashboardService:   dashboardService,
-		HealthcheckHandler: healthcheckHandler,
-		HomepageHandler:    homepageHandler,
+		ApiHandler:          apiHandler,
+		Config:              cfg,
+		DashboardService:    dashboardService,
+		DoraHandler:         doraHandler,
+		EventsHandler:       eventsHandler,
+		FeedHandler:         feedHandler,
+		HealthcheckHandler:  healthcheckHandler,
+		HistoryHandler:      historyHandler,
+		HomepageHandler:     homepageHandler,
+		ReleaseNotesHandler: releaseNotesHandler,
+		ReleasesHandler:     releasesHandler,
+		RepoHandler:         repoHandler,
+		SearchHandler:       searchHandler,
+		TrendsHandler:       trendsHandler,
+		TvHandler:           tvHandler,
 	}
-	return web
+	return web, nil
 }
 
 func (w web) Run(ctx context.Context) {
@@ -90,7 +138,29 @@ func (w web) SetupRouter(ctx context.Context) *http.ServeMux {
 
 	router.Handle("/static/", http.StripPrefix("/static", fs))
 	router.HandleFunc("/", w.HomepageHandler.Http)
+	router.HandleFunc("/environments/", w.FeedHandler.Http)
+	router.HandleFunc("/events", w.EventsHandler.Http)
+	router.HandleFunc("/feed.atom", w.FeedHandler.Http)
 	router.HandleFunc("/healthcheck", w.HealthcheckHandler.Http)
+	router.HandleFunc("/api/repos/", w.apiRepos)
+	router.HandleFunc("/api/v1/repos", w.ApiHandler.Http)
+	router.HandleFunc("/api/v1/repos/", w.ApiHandler.Http)
+	router.HandleFunc("/api/v1/search", w.ApiHandler.Search)
+	router.HandleFunc("/repos/", w.repos)
+	router.HandleFunc("/search", w.SearchHandler.Http)
+	router.HandleFunc("/tv", w.TvHandler.Http)
+
+	if w.HistoryHandler != nil {
+		router.HandleFunc("/api/v1/history", w.HistoryHandler.Api)
+		router.HandleFunc("/history", w.HistoryHandler.Http)
+	}
+	if w.DoraHandler != nil {
+		router.HandleFunc("/api/v1/metrics/dora", w.DoraHandler.Api)
+		router.HandleFunc("/metrics/dora", w.DoraHandler.Http)
+	}
+	if w.TrendsHandler != nil {
+		router.HandleFunc("/trends", w.TrendsHandler.Http)
+	}
 
 	if w.Config.Profiling.Enabled {
 		log.Log().Msg("Enabling profiling")
@@ -98,3 +168,26 @@ func (w web) SetupRouter(ctx context.Context) *http.ServeMux {
 	}
 	return router
 }
+
+// apiRepos routes /api/repos/{owner}/{repo}/{action} as the router can't match
+// path parameters.
+func (w web) apiRepos(respWriter http.ResponseWriter, request *http.Request) {
+	switch path.Base(request.URL.Path) {
+	case "release-notes":
+		w.ReleaseNotesHandler.Http(respWriter, request)
+	case "releases":
+		w.ReleasesHandler.Http(respWriter, request)
+	default:
+		http.NotFound(respWriter, request)
+	}
+}
+
+// repos routes /repos/{owner}/{repo}/feed.atom to the feed handler, every
+// other repo page is served by the repo handler.
+func (w web) repos(respWriter http.ResponseWriter, request *http.Request) {
+	if path.Base(request.URL.Path) == "feed.atom" {
+		w.FeedHandler.Http(respWriter, request)
+		return
+	}
+	w.RepoHandler.Http(respWriter, request)
+}